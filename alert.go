@@ -0,0 +1,12 @@
+package cringletest
+
+import "time"
+
+// Alert describes a watch rule which matched. Rate is the rate which matched the rule and
+// Message describes why, e.g. "GBP to EUR is 1.1523, above 1.15"
+type Alert struct {
+	Rule    string
+	Message string
+	Rate    *ExchangeRate
+	Fired   time.Time
+}
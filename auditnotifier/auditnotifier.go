@@ -0,0 +1,118 @@
+// Package auditnotifier implements the cringletest.Notifier interface by appending a Record of
+// every result, with the exact rates it used, to an append-only JSONL audit log
+package auditnotifier
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/report"
+)
+
+type notifier struct {
+	path string
+	host string
+	user string
+	now  func() time.Time
+}
+
+// New returns a new cringletest.Notifier which appends a Record of each notification to the
+// audit log at path
+func New(path string) (cringletest.Notifier, error) {
+	if len(path) == 0 {
+		return nil, errors.New("no audit log path")
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	username := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+
+	return &notifier{path: path, host: host, user: username, now: time.Now}, nil
+}
+
+func init() {
+	// audit:///var/log/cconv-audit.jsonl for an absolute path or audit:audit.jsonl for a relative one
+	cringletest.RegisterNotifier("audit", func(u *url.URL) (cringletest.Notifier, error) {
+		if len(u.Opaque) != 0 {
+			return New(u.Opaque)
+		}
+		if len(u.Host) != 0 && u.Host != "localhost" {
+			return nil, errors.New("audit urls cannot name a host")
+		}
+		return New(u.Path)
+	})
+}
+
+func (n *notifier) append(record *Record) error {
+	if len(record.Rates) == 0 {
+		return cringletest.ErrNoRates
+	}
+
+	record.Time = n.now().UTC()
+	record.Host = n.host
+	record.User = n.user
+	return Append(n.path, record)
+}
+
+func (n *notifier) NotifyRates(ctx context.Context, rates []*cringletest.ExchangeRate) error {
+	return n.append(newRecord(report.KindRates, rates))
+}
+
+func (n *notifier) NotifyValue(ctx context.Context, conversions []*cringletest.Conversion) error {
+	return n.append(newValueRecord(conversions))
+}
+
+func (n *notifier) NotifyBest(ctx context.Context, best *cringletest.BestRate) error {
+	record := newRecord(report.KindBest, []*cringletest.ExchangeRate{best.Rate})
+	record.Inputs.Days = best.Days
+	record.Inputs.Worst = best.Worst
+	record.Inputs.Until = report.Date(best.Until)
+	return n.append(record)
+}
+
+func (n *notifier) NotifyHistory(ctx context.Context, history *cringletest.RateHistory) error {
+	rates := []*cringletest.ExchangeRate{}
+	for _, day := range history.Series {
+		for _, currency := range history.To {
+			if rate, ok := day.Rates[currency]; ok {
+				rates = append(rates, rate)
+			}
+		}
+	}
+
+	record := newRecord(report.KindHistory, rates)
+	record.Inputs.From = history.From
+	record.Inputs.To = append([]string{}, history.To...)
+	record.Inputs.Start = report.Date(history.Start)
+	record.Inputs.End = report.Date(history.End)
+	record.Inputs.Interval = history.Interval
+	return n.append(record)
+}
+
+func (n *notifier) NotifyAlert(ctx context.Context, alert *cringletest.Alert) error {
+	record := newRecord(report.KindAlert, []*cringletest.ExchangeRate{alert.Rate})
+	record.Inputs.Rule = alert.Rule
+	return n.append(record)
+}
+
+func (n *notifier) NotifyNeed(ctx context.Context, requirements []*cringletest.Requirement) error {
+	return n.append(newNeedRecord(requirements))
+}
+
+func (n *notifier) NotifyMatrix(ctx context.Context, matrix *cringletest.RateMatrix) error {
+	record := newRecord(report.KindMatrix, matrix.CrossRates())
+	record.Inputs.From = matrix.Currencies[0]
+	record.Inputs.To = append([]string{}, matrix.Currencies[1:]...)
+	return n.append(record)
+}
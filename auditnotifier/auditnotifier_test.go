@@ -0,0 +1,245 @@
+package auditnotifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/report"
+	"github.com/stretchr/testify/require"
+)
+
+var testDate = time.Date(2018, 5, 25, 0, 0, 0, 0, time.UTC)
+
+var testFetched = time.Date(2018, 5, 25, 16, 0, 1, 500, time.UTC)
+
+func getTestNotifier(t *testing.T) (*notifier, string, func()) {
+	dir, err := ioutil.TempDir("", "auditnotifier")
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "logs", "audit.jsonl")
+	n, err := New(path)
+	require.NoError(t, err)
+	return n.(*notifier), path, func() { os.RemoveAll(dir) }
+}
+
+func getTestRates() []*cringletest.ExchangeRate {
+	return []*cringletest.ExchangeRate{
+		{From: "ABC", To: "DEF", Date: testDate, Value: decimal.New(123456789012345, 14), Provider: "ecb", Fetched: testFetched},
+		{From: "ABC", To: "GHI", Date: testDate, Value: decimal.New(5, 1), Provider: "ecb", Warning: "stale"},
+	}
+}
+
+func queryAll(t *testing.T, path string, filter *Filter) []*Record {
+	records := []*Record{}
+	require.NoError(t, Query(path, filter, func(record *Record) error {
+		records = append(records, record)
+		return nil
+	}))
+	return records
+}
+
+func TestNotifyValueAppendsExactRecord(t *testing.T) {
+	r := require.New(t)
+	n, path, cleanup := getTestNotifier(t)
+	defer cleanup()
+	n.host, n.user = "box", "someone"
+	n.now = func() time.Time { return testFetched }
+
+	conversions, err := cringletest.Convert(cringletest.NewMoney(decimal.New(3, 0), "ABC"), getTestRates(), cringletest.DefaultRounding)
+	r.NoError(err)
+	r.NoError(n.NotifyValue(context.Background(), conversions))
+
+	records := queryAll(t, path, &Filter{})
+	r.Len(records, 1)
+	record := records[0]
+	r.Equal(Schema, record.Schema)
+	r.Equal(report.KindValue, record.Kind)
+	r.Equal("box", record.Host)
+	r.Equal("someone", record.User)
+	r.True(testFetched.Equal(record.Time))
+	r.Equal(&Inputs{From: "ABC", To: []string{"DEF", "GHI"}, Amount: "3"}, record.Inputs)
+
+	r.Len(record.Rates, 2)
+	r.Equal(&Rate{
+		From:      "ABC",
+		To:        "DEF",
+		Date:      "2018-05-25",
+		Value:     "1.23456789012345",
+		Converted: "3.70",
+		Provider:  "ecb",
+		Fetched:   "2018-05-25T16:00:01.0000005Z",
+	}, record.Rates[0])
+	r.Equal("stale", record.Rates[1].Warning)
+	r.Empty(record.Rates[1].Fetched)
+}
+
+func TestNotifyNeedRecordsEachAmount(t *testing.T) {
+	r := require.New(t)
+	n, path, cleanup := getTestNotifier(t)
+	defer cleanup()
+
+	rates := []*cringletest.ExchangeRate{
+		{From: "DEF", To: "ABC", Date: testDate, Value: decimal.New(2, 0)},
+		{From: "GHI", To: "ABC", Date: testDate, Value: decimal.New(5, 1)},
+	}
+	requirements, err := cringletest.Need(cringletest.NewMoney(decimal.New(10, 0), "ABC"), rates, cringletest.DefaultRounding, nil)
+	r.NoError(err)
+	r.NoError(n.NotifyNeed(context.Background(), requirements))
+
+	records := queryAll(t, path, &Filter{Currency: "GHI"})
+	r.Len(records, 1)
+	record := records[0]
+	r.Equal(report.KindNeed, record.Kind)
+	r.Equal(&Inputs{To: []string{"ABC"}, Sources: []string{"DEF", "GHI"}, Amount: "10"}, record.Inputs)
+	r.Equal("5.00", record.Rates[0].Amount)
+	r.Equal("19.99", record.Rates[1].Amount)
+	r.Equal("10.00", record.Rates[1].Converted)
+}
+
+func TestNotifyMatrixRecordsCrossRates(t *testing.T) {
+	r := require.New(t)
+	n, path, cleanup := getTestNotifier(t)
+	defer cleanup()
+
+	matrix, err := cringletest.NewRateMatrix([]string{"ABC", "DEF", "GHI"}, cringletest.RateMap{
+		"DEF": {From: "ABC", To: "DEF", Date: testDate, Value: decimal.New(2, 0)},
+		"GHI": {From: "ABC", To: "GHI", Date: testDate, Value: decimal.New(5, 0)},
+	})
+	r.NoError(err)
+	r.NoError(n.NotifyMatrix(context.Background(), matrix))
+
+	records := queryAll(t, path, &Filter{Kind: report.KindMatrix})
+	r.Len(records, 1)
+	record := records[0]
+	r.Equal(&Inputs{From: "ABC", To: []string{"DEF", "GHI"}}, record.Inputs)
+	r.Len(record.Rates, 6)
+	r.Equal("DEF", record.Rates[3].From)
+	r.Equal("GHI", record.Rates[3].To)
+	r.Equal("2.5", record.Rates[3].Value)
+}
+
+func TestNotifyWithoutRatesFails(t *testing.T) {
+	n, path, cleanup := getTestNotifier(t)
+	defer cleanup()
+	require.Equal(t, cringletest.ErrNoRates, n.NotifyRates(context.Background(), nil))
+
+	_, err := os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestNotifyBestRecordsInputs(t *testing.T) {
+	r := require.New(t)
+	n, path, cleanup := getTestNotifier(t)
+	defer cleanup()
+
+	best := &cringletest.BestRate{Rate: getTestRates()[0], Days: 30, Worst: true, Until: testDate}
+	r.NoError(n.NotifyBest(context.Background(), best))
+
+	records := queryAll(t, path, &Filter{})
+	r.Len(records, 1)
+	r.Equal(report.KindBest, records[0].Kind)
+	r.Equal(30, records[0].Inputs.Days)
+	r.True(records[0].Inputs.Worst)
+	r.Equal("2018-05-25", records[0].Inputs.Until)
+}
+
+func TestConcurrentAppendsDoNotInterleave(t *testing.T) {
+	r := require.New(t)
+	n, path, cleanup := getTestNotifier(t)
+	defer cleanup()
+
+	// a long rate list makes each line far bigger than a single write to a pipe or page
+	rates := []*cringletest.ExchangeRate{}
+	for i := 0; i < 500; i++ {
+		rates = append(rates, &cringletest.ExchangeRate{From: "ABC", To: fmt.Sprintf("X%02d", i%100), Date: testDate, Value: decimal.New(int64(i), 2)})
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.NoError(n.NotifyRates(context.Background(), rates))
+		}()
+	}
+	wg.Wait()
+
+	records := queryAll(t, path, &Filter{})
+	r.Len(records, 20)
+	for _, record := range records {
+		r.Len(record.Rates, 500)
+	}
+}
+
+func TestQueryFilters(t *testing.T) {
+	r := require.New(t)
+	n, path, cleanup := getTestNotifier(t)
+	defer cleanup()
+
+	day := func(d int) func() time.Time {
+		return func() time.Time { return time.Date(2018, 5, d, 12, 0, 0, 0, time.UTC) }
+	}
+
+	n.now = day(1)
+	r.NoError(n.NotifyRates(context.Background(), getTestRates()))
+	n.now = day(2)
+	r.NoError(n.NotifyRates(context.Background(), []*cringletest.ExchangeRate{
+		{From: "JKL", To: "MNO", Date: testDate, Value: decimal.New(2, 0)},
+	}))
+	n.now = day(3)
+	r.NoError(n.NotifyAlert(context.Background(), &cringletest.Alert{Rule: "ABC/DEF > 1", Rate: getTestRates()[0]}))
+
+	r.Len(queryAll(t, path, &Filter{}), 3)
+	r.Len(queryAll(t, path, &Filter{Since: time.Date(2018, 5, 2, 0, 0, 0, 0, time.UTC)}), 2)
+	r.Len(queryAll(t, path, &Filter{Until: time.Date(2018, 5, 2, 0, 0, 0, 0, time.UTC)}), 1)
+	r.Len(queryAll(t, path, &Filter{Currency: "def"}), 2)
+	r.Len(queryAll(t, path, &Filter{Currency: "MNO"}), 1)
+	r.Len(queryAll(t, path, &Filter{Currency: "XYZ"}), 0)
+
+	alerts := queryAll(t, path, &Filter{Kind: report.KindAlert})
+	r.Len(alerts, 1)
+	r.Equal("ABC/DEF > 1", alerts[0].Inputs.Rule)
+}
+
+func TestQueryReportsBadLines(t *testing.T) {
+	r := require.New(t)
+	n, path, cleanup := getTestNotifier(t)
+	defer cleanup()
+	r.NoError(n.NotifyRates(context.Background(), getTestRates()))
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	r.NoError(err)
+	_, err = f.Write([]byte("\n{\"schema\":\n"))
+	r.NoError(err)
+	r.NoError(f.Close())
+
+	err = Query(path, &Filter{}, func(*Record) error { return nil })
+	r.Error(err)
+	r.Contains(err.Error(), "bad audit record on line 3")
+}
+
+func TestRegistryBuildsAuditNotifier(t *testing.T) {
+	r := require.New(t)
+	_, path, cleanup := getTestNotifier(t)
+	defer cleanup()
+
+	n, err := cringletest.NewNotifier("audit://" + path)
+	r.NoError(err)
+	r.NoError(n.NotifyRates(context.Background(), getTestRates()))
+
+	data, err := ioutil.ReadFile(path)
+	r.NoError(err)
+	r.Equal(1, bytes.Count(data, []byte("\n")))
+
+	_, err = cringletest.NewNotifier("audit://elsewhere/audit.jsonl")
+	r.Error(err)
+}
@@ -0,0 +1,112 @@
+package auditnotifier
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest/filelock"
+)
+
+// maxRecordSize is the longest line Query will read from the log
+const maxRecordSize = 16 * 1024 * 1024
+
+// Append appends record to the log at path as a single line, creating the log if it does not
+// exist. The log is locked while the line is written so that concurrent writers, including other
+// cconv processes, never interleave, and the write is synced to disk before Append returns
+func Append(path string, record *Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "could not encode audit record")
+	}
+	data = append(data, '\n')
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "could not create audit log directory")
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return errors.Wrap(err, "could not open audit log")
+	}
+	defer f.Close()
+
+	unlock, err := filelock.Lock(f, true)
+	if err != nil {
+		return errors.Wrap(err, "could not lock audit log")
+	}
+	defer unlock()
+
+	if _, err := f.Write(data); err != nil {
+		return errors.Wrap(err, "could not write audit log")
+	}
+	return errors.Wrap(f.Sync(), "could not sync audit log")
+}
+
+// Filter selects records from the log. Records are selected when their Time is within Since and
+// Until, either of which may be zero for no limit, and when they involve Currency and are of
+// Kind, if those are set
+type Filter struct {
+	Since    time.Time
+	Until    time.Time
+	Currency string
+	Kind     string
+}
+
+func (f *Filter) matches(record *Record) bool {
+	if !f.Since.IsZero() && record.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && record.Time.After(f.Until) {
+		return false
+	}
+	if len(f.Currency) != 0 && !record.matches(strings.ToUpper(f.Currency)) {
+		return false
+	}
+	return len(f.Kind) == 0 || f.Kind == record.Kind
+}
+
+// Query calls fn with each record of the log at path which matches filter, in the order they
+// were written. It stops at the first error returned by fn
+func Query(path string, filter *Filter, fn func(*Record) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "could not open audit log")
+	}
+	defer f.Close()
+
+	// a shared lock means a record being appended is never seen half written
+	unlock, err := filelock.Lock(f, false)
+	if err != nil {
+		return errors.Wrap(err, "could not lock audit log")
+	}
+	defer unlock()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxRecordSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		record := &Record{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			return errors.Wrapf(err, "bad audit record on line %d", line)
+		}
+		if record.Inputs == nil {
+			return fmt.Errorf("bad audit record on line %d: no inputs", line)
+		}
+
+		if filter.matches(record) {
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+	}
+	return errors.Wrap(scanner.Err(), "could not read audit log")
+}
@@ -0,0 +1,153 @@
+package auditnotifier
+
+import (
+	"time"
+
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/report"
+)
+
+// Schema identifies the version of the Record layout
+const Schema = "cconv.audit/v1"
+
+// Record is a single entry in the audit log. It holds everything needed to show which rate
+// was used for a result and where it came from
+type Record struct {
+	Schema string    `json:"schema"`
+	Time   time.Time `json:"time"`
+	Kind   string    `json:"kind"`
+	Host   string    `json:"host"`
+	User   string    `json:"user"`
+	Inputs *Inputs   `json:"inputs"`
+	Rates  []*Rate   `json:"rates"`
+}
+
+// Inputs are the parameters of the request a Record describes. Only those which apply to its
+// Kind are set. A need record has no From, but the Sources which could be converted to receive
+// Amount of its only To
+type Inputs struct {
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	Sources  []string `json:"sources,omitempty"`
+	Amount   string   `json:"amount,omitempty"`
+	Fees     string   `json:"fees,omitempty"`
+	Days     int      `json:"days,omitempty"`
+	Worst    bool     `json:"worst,omitempty"`
+	Until    string   `json:"until,omitempty"`
+	Start    string   `json:"start,omitempty"`
+	End      string   `json:"end,omitempty"`
+	Interval string   `json:"interval,omitempty"`
+	Rule     string   `json:"rule,omitempty"`
+}
+
+// Rate is an exchange rate used for a result, with its exact value. Converted is set, as it was
+// rounded, when an amount was converted at the rate. Amount is what was converted when it is not
+// the Amount of the Inputs, as for a need record. Gross, Fees and EffectiveRate are set when
+// fees were paid, and Converted is then the net amount received. Fetched is an RFC 3339
+// timestamp, empty if it is not known
+type Rate struct {
+	From          string `json:"from"`
+	To            string `json:"to"`
+	Date          string `json:"date"`
+	Value         string `json:"value"`
+	Amount        string `json:"amount,omitempty"`
+	Converted     string `json:"converted,omitempty"`
+	Gross         string `json:"gross,omitempty"`
+	Fees          string `json:"fees,omitempty"`
+	EffectiveRate string `json:"effective_rate,omitempty"`
+	Provider      string `json:"provider"`
+	Warning       string `json:"warning,omitempty"`
+	Fetched       string `json:"fetched,omitempty"`
+}
+
+func newRate(rate *cringletest.ExchangeRate) *Rate {
+	r := &Rate{
+		From:     rate.From,
+		To:       rate.To,
+		Date:     report.Date(rate.Date),
+		Value:    report.Decimal(rate.Value),
+		Provider: rate.Provider,
+		Warning:  rate.Warning,
+	}
+	if !rate.Fetched.IsZero() {
+		r.Fetched = rate.Fetched.UTC().Format(time.RFC3339Nano)
+	}
+	return r
+}
+
+func newRecord(kind string, rates []*cringletest.ExchangeRate) *Record {
+	record := &Record{
+		Schema: Schema,
+		Kind:   kind,
+		Inputs: &Inputs{To: []string{}},
+		Rates:  []*Rate{},
+	}
+
+	seen := map[string]bool{}
+	for _, rate := range rates {
+		record.Inputs.From = rate.From
+		if !seen[rate.To] {
+			record.Inputs.To = append(record.Inputs.To, rate.To)
+			seen[rate.To] = true
+		}
+		record.Rates = append(record.Rates, newRate(rate))
+	}
+	return record
+}
+
+// newValueRecord returns the Record of an amount converted at several rates. Each rate holds
+// the converted amount exactly as it was rounded
+func newValueRecord(conversions []*cringletest.Conversion) *Record {
+	return newConversionRecord(report.KindValue, conversions)
+}
+
+// newNeedRecord returns the Record of the amounts of several currencies needed to receive a
+// target amount. Each rate holds the amount needed and what it buys
+func newNeedRecord(requirements []*cringletest.Requirement) *Record {
+	conversions := []*cringletest.Conversion{}
+	for _, requirement := range requirements {
+		conversions = append(conversions, requirement.Conversion)
+	}
+
+	record := newConversionRecord(report.KindNeed, conversions)
+	record.Inputs.From = ""
+	for i, requirement := range requirements {
+		record.Inputs.Sources = append(record.Inputs.Sources, requirement.Amount.Currency)
+		record.Inputs.Amount = report.Decimal(requirement.Target.Amount)
+		record.Rates[i].Amount = report.Decimal(requirement.Amount.Amount)
+	}
+	return record
+}
+
+func newConversionRecord(kind string, conversions []*cringletest.Conversion) *Record {
+	rates := []*cringletest.ExchangeRate{}
+	for _, conversion := range conversions {
+		rates = append(rates, conversion.Rate)
+	}
+
+	record := newRecord(kind, rates)
+	for i, conversion := range conversions {
+		record.Inputs.Amount = report.Decimal(conversion.Amount.Amount)
+		record.Rates[i].Converted = report.Decimal(conversion.Converted.Amount)
+		if charges := conversion.Charges; charges != nil {
+			record.Inputs.Fees = charges.Fees.String()
+			record.Rates[i].Gross = report.Decimal(charges.Gross.Amount)
+			record.Rates[i].Fees = report.Decimal(charges.Charged.Amount)
+			record.Rates[i].EffectiveRate = report.Decimal(charges.EffectiveRate)
+		}
+	}
+	return record
+}
+
+// matches reports whether the record involves currency
+func (r *Record) matches(currency string) bool {
+	if r.Inputs.From == currency {
+		return true
+	}
+	for _, rate := range r.Rates {
+		if rate.From == currency || rate.To == currency {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,254 @@
+// Package batch converts every row of a CSV or JSONL file into a single currency, looking up
+// each distinct (date, currency) pair only once
+package batch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+)
+
+// The supported file formats
+const (
+	CSV   = "csv"
+	JSONL = "jsonl"
+)
+
+// The columns added to every converted row
+const (
+	ConvertedColumn = "converted"
+	RateColumn      = "rate"
+	ErrorColumn     = "error"
+)
+
+// DateFormat is the format of the dates in the date column
+const DateFormat = "2006-01-02"
+
+// Columns names the input columns holding the amount, currency and date of each row
+type Columns struct {
+	Amount   string
+	Currency string
+	Date     string
+}
+
+// DefaultColumns are the column names used when none are given
+var DefaultColumns = Columns{Amount: "amount", Currency: "currency", Date: "date"}
+
+// Summary describes the result of converting a file
+type Summary struct {
+	Rows    int
+	Errors  int
+	Lookups int
+}
+
+// record is a single row of the input. names holds the column names in the order they should
+// be written
+type record struct {
+	names  []string
+	values map[string]interface{}
+}
+
+func (r *record) get(name string) string {
+	value, ok := r.values[name]
+	if !ok || value == nil {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprint(value))
+}
+
+type reader interface {
+	// read returns io.EOF when there are no more records
+	read() (*record, error)
+}
+
+type writer interface {
+	write(rec *record, converted, rate string, err error) error
+	flush() error
+}
+
+// lookupKey identifies a distinct rate lookup. A zero date is the live rate
+type lookupKey struct {
+	date     time.Time
+	currency string
+}
+
+type lookup struct {
+	rate *decimal.Big
+	err  error
+}
+
+// Converter converts rows into a single currency
+type Converter struct {
+	client  cringletest.RateClient
+	to      string
+	columns Columns
+	lookups map[lookupKey]*lookup
+}
+
+// New returns a Converter which converts rows into to using rates from client
+func New(client cringletest.RateClient, to string, columns Columns) *Converter {
+	return &Converter{
+		client:  client,
+		to:      strings.ToUpper(to),
+		columns: columns,
+		lookups: map[lookupKey]*lookup{},
+	}
+}
+
+// isFatal reports whether err would stop every lookup from succeeding, rather than just this one
+func isFatal(err error) bool {
+	switch errors.Cause(err) {
+	case cringletest.ErrBadAuth, cringletest.ErrNoAuth, context.Canceled, context.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// rate returns the rate from currency to c.to on date, only asking the client the first
+// time each (date, currency) pair is seen
+func (c *Converter) rate(ctx context.Context, date time.Time, currency string) (*decimal.Big, error) {
+	key := lookupKey{date: date, currency: currency}
+	if found, ok := c.lookups[key]; ok {
+		return found.rate, found.err
+	}
+
+	var rates cringletest.RateMap
+	var err error
+	if date.IsZero() {
+		rates, err = c.client.Get(ctx, currency, c.to)
+	} else {
+		rates, err = c.client.GetOn(ctx, date, currency, c.to)
+	}
+	if err != nil && isFatal(err) {
+		return nil, err
+	}
+
+	found := &lookup{err: err}
+	if err == nil {
+		if rate, ok := rates[c.to]; ok {
+			found.rate = rate.Value
+		} else {
+			found.err = fmt.Errorf("no rate from %s to %s", currency, c.to)
+		}
+	}
+	c.lookups[key] = found
+	return found.rate, found.err
+}
+
+// convert returns the converted amount and the rate used for rec
+func (c *Converter) convert(ctx context.Context, rec *record) (converted, rate *decimal.Big, err error) {
+	amount, ok := new(decimal.Big).SetString(rec.get(c.columns.Amount))
+	if !ok {
+		return nil, nil, fmt.Errorf("%q in column %s cannot be formatted as a number", rec.get(c.columns.Amount), c.columns.Amount)
+	}
+
+	currency := strings.ToUpper(rec.get(c.columns.Currency))
+	if len(currency) == 0 {
+		return nil, nil, fmt.Errorf("no currency in column %s", c.columns.Currency)
+	}
+
+	var date time.Time
+	if value := rec.get(c.columns.Date); len(value) != 0 {
+		if date, err = time.Parse(DateFormat, value); err != nil {
+			return nil, nil, fmt.Errorf("%q in column %s is not a date like %s", value, c.columns.Date, DateFormat)
+		}
+	}
+
+	rate = decimal.New(1, 0)
+	if currency != c.to {
+		if rate, err = c.rate(ctx, date, currency); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return new(decimal.Big).Mul(amount, rate), rate, nil
+}
+
+func formatDecimal(x *decimal.Big) string {
+	if x == nil {
+		return ""
+	}
+	return fmt.Sprintf("%f", x)
+}
+
+// Convert reads rows from in, in the inFormat, and writes each of them to out, in the
+// outFormat, with the converted amount, the rate used and any error converting that row. It
+// only stops early if the input cannot be read, the output cannot be written or the rate
+// client fails in a way which would affect every row
+func (c *Converter) Convert(ctx context.Context, in io.Reader, inFormat string, out io.Writer, outFormat string) (*Summary, error) {
+	r, err := newReader(in, inFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := newWriter(out, outFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{}
+	for {
+		rec, err := r.read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return summary, errors.Wrapf(err, "could not read row %d", summary.Rows+1)
+		}
+
+		converted, rate, err := c.convert(ctx, rec)
+		if err != nil && isFatal(err) {
+			return summary, errors.Wrapf(err, "could not convert row %d", summary.Rows+1)
+		}
+
+		summary.Rows++
+		if err != nil {
+			summary.Errors++
+		}
+		if err := w.write(rec, formatDecimal(converted), formatDecimal(rate), err); err != nil {
+			return summary, errors.Wrapf(err, "could not write row %d", summary.Rows)
+		}
+	}
+
+	summary.Lookups = len(c.lookups)
+	return summary, w.flush()
+}
+
+// FormatFromPath returns the format of a file based on its extension
+func FormatFromPath(path string) string {
+	lower := strings.ToLower(path)
+	if strings.HasSuffix(lower, ".jsonl") || strings.HasSuffix(lower, ".ndjson") {
+		return JSONL
+	}
+	return CSV
+}
+
+func unknownFormat(format string) error {
+	return fmt.Errorf("unknown file format %s, expected %s or %s", format, CSV, JSONL)
+}
+
+func newReader(in io.Reader, format string) (reader, error) {
+	switch format {
+	case CSV:
+		return newCSVReader(in), nil
+	case JSONL:
+		return newJSONLReader(in), nil
+	}
+	return nil, unknownFormat(format)
+}
+
+func newWriter(out io.Writer, format string) (writer, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(out), nil
+	case JSONL:
+		return newJSONLWriter(out), nil
+	}
+	return nil, unknownFormat(format)
+}
@@ -0,0 +1,128 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/stretchr/testify/require"
+)
+
+// countingClient is a cringletest.RateClient which knows about every currency except XYZ and
+// counts how often it is called
+type countingClient struct {
+	calls int
+	err   error
+}
+
+func (c *countingClient) rates(date time.Time, from string, to ...string) (cringletest.RateMap, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	if from == "XYZ" {
+		return nil, cringletest.ErrBadFromCurrency
+	}
+
+	rates := cringletest.RateMap{}
+	for _, currency := range to {
+		rates[currency] = &cringletest.ExchangeRate{From: from, To: currency, Date: date, Value: decimal.New(125, 2)}
+	}
+	return rates, nil
+}
+
+func (c *countingClient) Get(ctx context.Context, from string, to ...string) (cringletest.RateMap, error) {
+	return c.rates(time.Now(), from, to...)
+}
+
+func (c *countingClient) GetOn(ctx context.Context, date time.Time, from string, to ...string) (cringletest.RateMap, error) {
+	return c.rates(date, from, to...)
+}
+
+const testCSV = `id,amount,currency,date
+1,10,EUR,2018-05-25
+2,20.50,eur,2018-05-25
+3,5,GBP,2018-05-25
+4,7,XYZ,2018-05-25
+5,8,XYZ,2018-05-25
+6,1,EUR,2018-05-24
+7,abc,EUR,2018-05-24
+8,3,USD,
+9,4,USD,25/05/2018
+`
+
+func TestConvertCSVLooksUpEachPairOnce(t *testing.T) {
+	r := require.New(t)
+	client := &countingClient{}
+	out := bytes.NewBuffer(nil)
+
+	summary, err := New(client, "GBP", DefaultColumns).Convert(context.Background(), strings.NewReader(testCSV), CSV, out, CSV)
+	r.NoError(err)
+	r.Equal(&Summary{Rows: 9, Errors: 4, Lookups: 4}, summary)
+	r.Equal(4, client.calls)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	r.Len(lines, 10)
+	r.Equal("id,amount,currency,date,converted,rate,error", lines[0])
+	r.Equal("1,10,EUR,2018-05-25,12.50,1.25,", lines[1])
+	r.Equal("2,20.50,eur,2018-05-25,25.6250,1.25,", lines[2])
+	r.Equal("3,5,GBP,2018-05-25,5,1,", lines[3])
+	r.Equal("4,7,XYZ,2018-05-25,,,"+cringletest.ErrBadFromCurrency.Error(), lines[4])
+	r.Equal("5,8,XYZ,2018-05-25,,,"+cringletest.ErrBadFromCurrency.Error(), lines[5])
+	r.Equal("6,1,EUR,2018-05-24,1.25,1.25,", lines[6])
+	r.Contains(lines[7], "cannot be formatted as a number")
+	r.Equal("8,3,USD,,3.75,1.25,", lines[8])
+	r.Contains(lines[9], "is not a date like 2006-01-02")
+}
+
+func TestConvertCSVToJSONL(t *testing.T) {
+	r := require.New(t)
+	out := bytes.NewBuffer(nil)
+
+	in := "amount,currency,when\n1.5,EUR,2018-05-25\n1,XYZ,2018-05-25\n"
+	columns := Columns{Amount: "amount", Currency: "currency", Date: "when"}
+	_, err := New(&countingClient{}, "GBP", columns).Convert(context.Background(), strings.NewReader(in), CSV, out, JSONL)
+	r.NoError(err)
+
+	expected := `{"amount":"1.5","converted":"1.875","currency":"EUR","rate":"1.25","when":"2018-05-25"}` + "\n" +
+		`{"amount":"1","currency":"XYZ","error":"` + cringletest.ErrBadFromCurrency.Error() + `","when":"2018-05-25"}` + "\n"
+	r.Equal(expected, out.String())
+}
+
+func TestConvertJSONLKeepsNumbers(t *testing.T) {
+	r := require.New(t)
+	out := bytes.NewBuffer(nil)
+
+	in := `{"amount": 0.1, "currency": "EUR", "date": "2018-05-25", "note": null}` + "\n" +
+		`{"amount": "2", "currency": "EUR", "date": "2018-05-25"}` + "\n"
+	summary, err := New(&countingClient{}, "GBP", DefaultColumns).Convert(context.Background(), strings.NewReader(in), JSONL, out, JSONL)
+	r.NoError(err)
+	r.Equal(&Summary{Rows: 2, Lookups: 1}, summary)
+
+	expected := `{"amount":0.1,"converted":"0.125","currency":"EUR","date":"2018-05-25","note":null,"rate":"1.25"}` + "\n" +
+		`{"amount":"2","converted":"2.50","currency":"EUR","date":"2018-05-25","rate":"1.25"}` + "\n"
+	r.Equal(expected, out.String())
+}
+
+func TestConvertStopsWhenClientCannotAuthenticate(t *testing.T) {
+	r := require.New(t)
+	client := &countingClient{err: cringletest.ErrBadAuth}
+
+	summary, err := New(client, "GBP", DefaultColumns).Convert(context.Background(), strings.NewReader(testCSV), CSV, bytes.NewBuffer(nil), CSV)
+	r.Error(err)
+	r.Contains(err.Error(), cringletest.ErrBadAuth.Error())
+	r.Equal(0, summary.Rows)
+	r.Equal(1, client.calls)
+}
+
+func TestFormatFromPath(t *testing.T) {
+	r := require.New(t)
+	r.Equal(JSONL, FormatFromPath("export.JSONL"))
+	r.Equal(JSONL, FormatFromPath("export.ndjson"))
+	r.Equal(CSV, FormatFromPath("export.csv"))
+	r.Equal(CSV, FormatFromPath("-"))
+}
@@ -0,0 +1,78 @@
+package batch
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+type csvReader struct {
+	r      *csv.Reader
+	header []string
+}
+
+func newCSVReader(in io.Reader) *csvReader {
+	r := csv.NewReader(in)
+	// short rows are reported as an error on that row rather than stopping the file
+	r.FieldsPerRecord = -1
+	return &csvReader{r: r}
+}
+
+func (cr *csvReader) read() (*record, error) {
+	if cr.header == nil {
+		header, err := cr.r.Read()
+		if err != nil {
+			return nil, err
+		}
+		cr.header = header
+	}
+
+	fields, err := cr.r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &record{names: cr.header, values: map[string]interface{}{}}
+	for i, name := range cr.header {
+		if i < len(fields) {
+			rec.values[name] = fields[i]
+		}
+	}
+	return rec, nil
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	header []string
+}
+
+func newCSVWriter(out io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(out)}
+}
+
+// write writes rec with the columns of the first record written, so every row lines up
+// with the header
+func (cw *csvWriter) write(rec *record, converted, rate string, err error) error {
+	if cw.header == nil {
+		cw.header = rec.names
+		header := append(append([]string{}, rec.names...), ConvertedColumn, RateColumn, ErrorColumn)
+		if err := cw.w.Write(header); err != nil {
+			return err
+		}
+	}
+
+	fields := []string{}
+	for _, name := range cw.header {
+		fields = append(fields, rec.get(name))
+	}
+
+	message := ""
+	if err != nil {
+		message = err.Error()
+	}
+	return cw.w.Write(append(fields, converted, rate, message))
+}
+
+func (cw *csvWriter) flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
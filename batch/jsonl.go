@@ -0,0 +1,63 @@
+package batch
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+type jsonlReader struct {
+	dec *json.Decoder
+}
+
+func newJSONLReader(in io.Reader) *jsonlReader {
+	dec := json.NewDecoder(in)
+	// keep numbers exactly as they were written rather than converting them to float64
+	dec.UseNumber()
+	return &jsonlReader{dec: dec}
+}
+
+func (jr *jsonlReader) read() (*record, error) {
+	values := map[string]interface{}{}
+	if err := jr.dec.Decode(&values); err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return &record{names: names, values: values}, nil
+}
+
+type jsonlWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLWriter(out io.Writer) *jsonlWriter {
+	buf := bufio.NewWriter(out)
+	return &jsonlWriter{buf: buf, enc: json.NewEncoder(buf)}
+}
+
+// write writes rec as a single line with either the converted amount and rate, as strings so
+// that no precision is lost, or the error converting it
+func (jw *jsonlWriter) write(rec *record, converted, rate string, err error) error {
+	values := map[string]interface{}{}
+	for name, value := range rec.values {
+		values[name] = value
+	}
+	if err != nil {
+		values[ErrorColumn] = err.Error()
+	} else {
+		values[ConvertedColumn] = converted
+		values[RateColumn] = rate
+	}
+	return jw.enc.Encode(values)
+}
+
+func (jw *jsonlWriter) flush() error {
+	return jw.buf.Flush()
+}
@@ -0,0 +1,349 @@
+// Package cacheclient implements a cringletest.RateClient which caches the results
+// of another cringletest.RateClient on disk
+package cacheclient
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/rangeclient"
+)
+
+const (
+	historicalDir = "historical"
+	liveDir       = "live"
+	fileExt       = ".json"
+	dateFormat    = "2006-01-02"
+)
+
+type rateClient struct {
+	client cringletest.RateClient
+	dir    string
+	ttl    time.Duration
+	// set the clock as a member of the client so that it can be modified during testing
+	now func() time.Time
+
+	// each cache file is guarded by its own lock so that concurrent requests for
+	// different dates do not wait on each other
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// entry is the on disk representation of a single cached exchange rate. The value is
+// stored as a string so that no precision is lost
+type entry struct {
+	Value    string    `json:"value"`
+	Date     time.Time `json:"date"`
+	Fetched  time.Time `json:"fetched"`
+	Provider string    `json:"provider,omitempty"`
+}
+
+type cacheFile struct {
+	Rates map[string]*entry `json:"rates"`
+	// Closed is set to the time of fetching when a range request showed that the provider
+	// published no rates for the date
+	Closed *time.Time `json:"closed,omitempty"`
+}
+
+// New returns a cringletest.RangeRateClient which caches the results of client in dir.
+// Historical rates are cached forever and live rates are cached for ttl
+func New(client cringletest.RateClient, dir string, ttl time.Duration) cringletest.RangeRateClient {
+	return &rateClient{client: client, dir: dir, ttl: ttl, now: time.Now, locks: map[string]*sync.Mutex{}}
+}
+
+func (rc *rateClient) lock(path string) func() {
+	rc.mu.Lock()
+	l, ok := rc.locks[path]
+	if !ok {
+		l = new(sync.Mutex)
+		rc.locks[path] = l
+	}
+	rc.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+func (rc *rateClient) livePath(from string) string {
+	return filepath.Join(rc.dir, liveDir, from+fileExt)
+}
+
+func (rc *rateClient) historicalPath(date time.Time, from string) string {
+	return filepath.Join(rc.dir, historicalDir, from, date.Format(dateFormat)+fileExt)
+}
+
+// isFresh reports whether a live entry was fetched within the ttl
+func (rc *rateClient) isFresh(e *entry) bool {
+	return rc.now().Sub(e.Fetched) <= rc.ttl
+}
+
+// isFinal reports whether a historical entry for date can no longer change. Rates
+// fetched before the end of the UTC day they describe were still moving when they were
+// fetched and so are only fresh for the ttl
+func (rc *rateClient) isFinal(date time.Time, e *entry) bool {
+	year, month, day := date.Date()
+	end := time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC)
+	return !e.Fetched.UTC().Before(end) || rc.isFresh(e)
+}
+
+func readCacheFile(path string) (*cacheFile, error) {
+	cf := &cacheFile{Rates: map[string]*entry{}}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cf, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read cache file")
+	}
+
+	if err := json.Unmarshal(b, cf); err != nil {
+		// a corrupt cache file is treated as empty and will be overwritten
+		return &cacheFile{Rates: map[string]*entry{}}, nil
+	}
+	if cf.Rates == nil {
+		cf.Rates = map[string]*entry{}
+	}
+	return cf, nil
+}
+
+// writeCacheFile writes via a temporary file so that readers never see a partially written file
+func writeCacheFile(path string, cf *cacheFile) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "could not create cache directory")
+	}
+
+	b, err := json.Marshal(cf)
+	if err != nil {
+		return errors.Wrap(err, "could not encode cache file")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return errors.Wrap(err, "could not create cache file")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "could not write cache file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "could not write cache file")
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), path), "could not write cache file")
+}
+
+// split separates the requested currencies into those which can be answered from
+// the cache and those which must be fetched
+func (rc *rateClient) split(cf *cacheFile, valid func(*entry) bool, from string, to []string) (cringletest.RateMap, []string) {
+	rates := cringletest.RateMap{}
+	missing := []string{}
+
+	for _, currency := range to {
+		e, ok := cf.Rates[currency]
+		if !ok || !valid(e) {
+			missing = append(missing, currency)
+			continue
+		}
+
+		value, ok := new(decimal.Big).SetString(e.Value)
+		if !ok {
+			missing = append(missing, currency)
+			continue
+		}
+
+		rates[currency] = &cringletest.ExchangeRate{
+			From:     from,
+			To:       currency,
+			Date:     e.Date,
+			Value:    value,
+			Provider: e.Provider,
+			Fetched:  e.Fetched,
+		}
+	}
+
+	return rates, missing
+}
+
+func (rc *rateClient) get(path string, valid func(*entry) bool, fetch func(to ...string) (cringletest.RateMap, error), from string, to ...string) (cringletest.RateMap, error) {
+	if len(to) == 0 {
+		return nil, cringletest.ErrNoToCurrencies
+	}
+
+	defer rc.lock(path)()
+
+	cf, err := readCacheFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rates, missing := rc.split(cf, valid, from, to)
+	if len(missing) == 0 {
+		return rates, nil
+	}
+
+	fetched, err := fetch(missing...)
+	if err != nil {
+		return nil, err
+	}
+
+	now := rc.now()
+	for currency, rate := range fetched {
+		rates[currency] = rate
+		cf.Rates[currency] = &entry{Value: rate.Value.String(), Date: rate.Date, Fetched: now, Provider: rate.Provider}
+	}
+
+	// failing to write the cache should not prevent the rates from being returned
+	writeCacheFile(path, cf)
+
+	return rates, nil
+}
+
+// Implements cringletest.RateClient.Get, caching the results for the configured ttl
+func (rc *rateClient) Get(ctx context.Context, from string, to ...string) (cringletest.RateMap, error) {
+	fetch := func(missing ...string) (cringletest.RateMap, error) {
+		return rc.client.Get(ctx, from, missing...)
+	}
+	return rc.get(rc.livePath(from), rc.isFresh, fetch, from, to...)
+}
+
+// Implements cringletest.RateClient.GetOn, caching historical results forever
+func (rc *rateClient) GetOn(ctx context.Context, date time.Time, from string, to ...string) (cringletest.RateMap, error) {
+	fetch := func(missing ...string) (cringletest.RateMap, error) {
+		return rc.client.GetOn(ctx, date, from, missing...)
+	}
+	valid := func(e *entry) bool {
+		return rc.isFinal(date, e)
+	}
+	return rc.get(rc.historicalPath(date, from), valid, fetch, from, to...)
+}
+
+// cachedDay returns the cached rates for date, whether the provider is known to have published
+// no rates for date and whether anything needs to be fetched
+func (rc *rateClient) cachedDay(date time.Time, from string, to []string) (*cringletest.DatedRates, bool, error) {
+	path := rc.historicalPath(date, from)
+	defer rc.lock(path)()
+
+	cf, err := readCacheFile(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if cf.Closed != nil && rc.isFinal(date, &entry{Fetched: *cf.Closed}) {
+		return nil, false, nil
+	}
+
+	rates, missing := rc.split(cf, func(e *entry) bool { return rc.isFinal(date, e) }, from, to)
+	if len(missing) != 0 {
+		return nil, true, nil
+	}
+
+	// GetOn answers for dates with no published rates using the last rates published before
+	// them. Those are not part of a series
+	for _, rate := range rates {
+		if rangeclient.Day(rate.Date) != date {
+			return nil, false, nil
+		}
+	}
+
+	return &cringletest.DatedRates{Date: date, Rates: rates}, false, nil
+}
+
+// storeDay records the rates fetched for date, or that there were none
+func (rc *rateClient) storeDay(date time.Time, from string, day *cringletest.DatedRates) {
+	path := rc.historicalPath(date, from)
+	defer rc.lock(path)()
+
+	cf, err := readCacheFile(path)
+	if err != nil {
+		return
+	}
+
+	now := rc.now()
+	if day == nil {
+		cf.Closed = &now
+	} else {
+		for currency, rate := range day.Rates {
+			cf.Rates[currency] = &entry{Value: rate.Value.String(), Date: rate.Date, Fetched: now, Provider: rate.Provider}
+		}
+	}
+
+	// failing to write the cache should not prevent the rates from being returned
+	writeCacheFile(path, cf)
+}
+
+// consecutive splits days, which are in order, into runs of consecutive days
+func consecutive(days []time.Time) [][]time.Time {
+	runs := [][]time.Time{}
+	for i, day := range days {
+		if i == 0 || !day.Equal(days[i-1].AddDate(0, 0, 1)) {
+			runs = append(runs, []time.Time{})
+		}
+		runs[len(runs)-1] = append(runs[len(runs)-1], day)
+	}
+	return runs
+}
+
+// Implements cringletest.RangeRateClient.GetRange. Only the days of the range which are not
+// already cached are fetched from the underlying client, in a single range request for each run
+// of consecutive days if it supports them
+func (rc *rateClient) GetRange(ctx context.Context, start, end time.Time, from string, to ...string) (cringletest.RateSeries, error) {
+	if len(to) == 0 {
+		return nil, cringletest.ErrNoToCurrencies
+	}
+
+	days := rangeclient.Days(start, end)
+	cached := map[time.Time]*cringletest.DatedRates{}
+	missing := []time.Time{}
+	for _, date := range days {
+		day, fetch, err := rc.cachedDay(date, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if fetch {
+			missing = append(missing, date)
+		} else if day != nil {
+			cached[date] = day
+		}
+	}
+
+	client := rangeclient.New(rc.client, cringletest.RangeConcurrency)
+	for _, run := range consecutive(missing) {
+		first, last := run[0], run[len(run)-1]
+		fetched, err := client.GetRange(ctx, first, last, from, to...)
+		if err != nil {
+			return nil, err
+		}
+
+		byDate := map[time.Time]*cringletest.DatedRates{}
+		for _, day := range fetched {
+			byDate[rangeclient.Day(day.Date)] = day
+		}
+
+		for _, date := range run {
+			day := byDate[date]
+			rc.storeDay(date, from, day)
+			if day != nil {
+				cached[date] = &cringletest.DatedRates{Date: date, Rates: day.Rates}
+			}
+		}
+	}
+
+	series := cringletest.RateSeries{}
+	for _, date := range days {
+		if day, ok := cached[date]; ok {
+			series = append(series, day)
+		}
+	}
+	return series, nil
+}
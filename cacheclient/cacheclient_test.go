@@ -0,0 +1,292 @@
+package cacheclient
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/stretchr/testify/require"
+)
+
+// countingClient is a cringletest.RateClient which records the currencies it is asked for
+type countingClient struct {
+	calls     int
+	requested []string
+	err       error
+}
+
+func (c *countingClient) rates(date time.Time, from string, to ...string) (cringletest.RateMap, error) {
+	c.calls++
+	c.requested = append(c.requested, to...)
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	rates := cringletest.RateMap{}
+	for _, currency := range to {
+		rates[currency] = &cringletest.ExchangeRate{From: from, To: currency, Date: date, Value: decimal.New(1234, 3)}
+	}
+	return rates, nil
+}
+
+func (c *countingClient) Get(ctx context.Context, from string, to ...string) (cringletest.RateMap, error) {
+	return c.rates(time.Now(), from, to...)
+}
+
+func (c *countingClient) GetOn(ctx context.Context, date time.Time, from string, to ...string) (cringletest.RateMap, error) {
+	return c.rates(date, from, to...)
+}
+
+func getTestClient(t *testing.T) (*rateClient, *countingClient, func()) {
+	dir, err := ioutil.TempDir("", "cacheclient")
+	require.NoError(t, err)
+
+	counter := &countingClient{}
+	client := New(counter, dir, time.Hour).(*rateClient)
+	return client, counter, func() { os.RemoveAll(dir) }
+}
+
+func TestGetOnCachesHistoricalRates(t *testing.T) {
+	r := require.New(t)
+	client, counter, done := getTestClient(t)
+	defer done()
+
+	date := time.Now().Add(-72 * time.Hour)
+
+	_, err := client.GetOn(context.Background(), date, "GBP", "EUR", "CAD")
+	r.NoError(err)
+
+	// even long after the ttl historical rates should come from the cache
+	client.now = func() time.Time { return time.Now().Add(1000 * time.Hour) }
+	rates, err := client.GetOn(context.Background(), date, "GBP", "EUR", "CAD")
+	r.NoError(err)
+	r.Equal(1, counter.calls)
+	r.Contains(rates, "EUR")
+	r.Contains(rates, "CAD")
+	r.Equal(0, rates["EUR"].Value.Cmp(decimal.New(1234, 3)))
+}
+
+func TestGetOnOnlyFetchesMissingCurrencies(t *testing.T) {
+	r := require.New(t)
+	client, counter, done := getTestClient(t)
+	defer done()
+
+	date := time.Now().Add(-72 * time.Hour)
+
+	_, err := client.GetOn(context.Background(), date, "GBP", "EUR")
+	r.NoError(err)
+
+	rates, err := client.GetOn(context.Background(), date, "GBP", "EUR", "CAD")
+	r.NoError(err)
+	r.Equal(2, counter.calls)
+	r.Equal([]string{"EUR", "CAD"}, counter.requested)
+	r.Len(rates, 2)
+}
+
+func TestGetOnRefetchesRatesFetchedOnTheSameDay(t *testing.T) {
+	r := require.New(t)
+	client, counter, done := getTestClient(t)
+	defer done()
+
+	date := time.Now()
+
+	_, err := client.GetOn(context.Background(), date, "GBP", "EUR")
+	r.NoError(err)
+
+	client.now = func() time.Time { return date.Add(2 * time.Hour) }
+	_, err = client.GetOn(context.Background(), date, "GBP", "EUR")
+	r.NoError(err)
+	r.Equal(2, counter.calls)
+}
+
+func TestGetOnRefetchesRatesFetchedBeforeTheUTCDayEnded(t *testing.T) {
+	r := require.New(t)
+	client, counter, done := getTestClient(t)
+	defer done()
+
+	// 08:00 on the 26th in Sydney is still 22:00 on the 25th in UTC
+	sydney := time.FixedZone("AEST", 10*60*60)
+	date := time.Date(2018, 5, 25, 0, 0, 0, 0, time.UTC)
+	fetched := time.Date(2018, 5, 26, 8, 0, 0, 0, sydney)
+
+	client.now = func() time.Time { return fetched }
+	_, err := client.GetOn(context.Background(), date, "GBP", "EUR")
+	r.NoError(err)
+
+	client.now = func() time.Time { return fetched.Add(90 * time.Minute) }
+	_, err = client.GetOn(context.Background(), date, "GBP", "EUR")
+	r.NoError(err)
+	r.Equal(2, counter.calls)
+
+	// the rates fetched at 23:30 UTC are not final either, but those fetched after the UTC day ended are
+	client.now = func() time.Time { return fetched.Add(4 * time.Hour) }
+	_, err = client.GetOn(context.Background(), date, "GBP", "EUR")
+	r.NoError(err)
+	r.Equal(3, counter.calls)
+
+	client.now = func() time.Time { return fetched.Add(100 * time.Hour) }
+	_, err = client.GetOn(context.Background(), date, "GBP", "EUR")
+	r.NoError(err)
+	r.Equal(3, counter.calls)
+}
+
+func TestGetCachesLiveRatesForTTL(t *testing.T) {
+	r := require.New(t)
+	client, counter, done := getTestClient(t)
+	defer done()
+
+	_, err := client.Get(context.Background(), "GBP", "EUR")
+	r.NoError(err)
+
+	_, err = client.Get(context.Background(), "GBP", "EUR")
+	r.NoError(err)
+	r.Equal(1, counter.calls)
+
+	client.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = client.Get(context.Background(), "GBP", "EUR")
+	r.NoError(err)
+	r.Equal(2, counter.calls)
+}
+
+func TestGetReturnsClientError(t *testing.T) {
+	r := require.New(t)
+	client, counter, done := getTestClient(t)
+	defer done()
+
+	counter.err = cringletest.ErrBadAuth
+	_, err := client.Get(context.Background(), "GBP", "EUR")
+	r.EqualError(err, cringletest.ErrBadAuth.Error())
+}
+
+func TestGetReturnsCorrectErrorWhenNoToCurrenciesSupplied(t *testing.T) {
+	r := require.New(t)
+	client, _, done := getTestClient(t)
+	defer done()
+
+	_, err := client.Get(context.Background(), "GBP")
+	r.EqualError(err, cringletest.ErrNoToCurrencies.Error())
+}
+
+func TestStatsAndClear(t *testing.T) {
+	r := require.New(t)
+	client, _, done := getTestClient(t)
+	defer done()
+
+	_, err := client.Get(context.Background(), "GBP", "EUR", "CAD")
+	r.NoError(err)
+	_, err = client.GetOn(context.Background(), time.Now().Add(-72*time.Hour), "GBP", "EUR")
+	r.NoError(err)
+
+	stats, err := GetStats(client.dir)
+	r.NoError(err)
+	r.Equal(1, stats.LiveFiles)
+	r.Equal(1, stats.HistoricalFiles)
+	r.Equal(3, stats.Rates)
+
+	r.NoError(Clear(client.dir))
+
+	stats, err = GetStats(client.dir)
+	r.NoError(err)
+	r.Equal(0, stats.Rates)
+}
+
+func TestGetRangeOnlyFetchesUncachedDays(t *testing.T) {
+	r := require.New(t)
+	client, counter, done := getTestClient(t)
+	defer done()
+
+	end := rangeDay(time.Now().AddDate(0, 0, -2))
+	start := end.AddDate(0, 0, -9)
+
+	// cache the first and last days of the range
+	_, err := client.GetOn(context.Background(), start, "GBP", "EUR")
+	r.NoError(err)
+	_, err = client.GetOn(context.Background(), end, "GBP", "EUR")
+	r.NoError(err)
+	r.Equal(2, counter.calls)
+
+	series, err := client.GetRange(context.Background(), start, end, "GBP", "EUR")
+	r.NoError(err)
+	r.Len(series, 10)
+	// the eight uncached days in the middle are fetched one at a time by the range adapter
+	r.Equal(10, counter.calls)
+
+	series, err = client.GetRange(context.Background(), start, end, "GBP", "EUR")
+	r.NoError(err)
+	r.Len(series, 10)
+	r.Equal(10, counter.calls)
+	r.Equal(start, series[0].Date)
+	r.Equal(end, series[9].Date)
+}
+
+// gappyClient is a cringletest.RangeRateClient which publishes no rates at weekends
+type gappyClient struct {
+	countingClient
+	rangeCalls int
+	// starts holds the first day of each range requested
+	starts []time.Time
+}
+
+func (c *gappyClient) GetRange(ctx context.Context, start, end time.Time, from string, to ...string) (cringletest.RateSeries, error) {
+	c.rangeCalls++
+	c.starts = append(c.starts, start)
+	series := cringletest.RateSeries{}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+		rates, _ := c.rates(day, from, to...)
+		series = append(series, &cringletest.DatedRates{Date: day, Rates: rates})
+	}
+	return series, nil
+}
+
+func TestGetRangeRemembersDatesWithNoRates(t *testing.T) {
+	r := require.New(t)
+	client, _, done := getTestClient(t)
+	defer done()
+
+	gappy := &gappyClient{}
+	client.client = gappy
+
+	end := rangeDay(time.Now().AddDate(0, 0, -2))
+	start := end.AddDate(0, 0, -13)
+
+	series, err := client.GetRange(context.Background(), start, end, "GBP", "EUR")
+	r.NoError(err)
+	r.Len(series, 10)
+	r.Equal(1, gappy.rangeCalls)
+
+	series, err = client.GetRange(context.Background(), start, end, "GBP", "EUR")
+	r.NoError(err)
+	r.Len(series, 10)
+	r.Equal(1, gappy.rangeCalls)
+}
+
+func TestGetRangeOnlyFetchesRunsOfMissingDays(t *testing.T) {
+	r := require.New(t)
+	client, _, done := getTestClient(t)
+	defer done()
+
+	gappy := &gappyClient{}
+	client.client = gappy
+
+	end := rangeDay(time.Now().AddDate(0, 0, -2))
+	start := end.AddDate(0, 0, -13)
+	middle := start.AddDate(0, 0, 6)
+	_, err := client.GetRange(context.Background(), middle, middle, "GBP", "EUR")
+	r.NoError(err)
+
+	_, err = client.GetRange(context.Background(), start, end, "GBP", "EUR")
+	r.NoError(err)
+	r.Equal(3, gappy.rangeCalls)
+	r.Equal([]time.Time{middle, start, middle.AddDate(0, 0, 1)}, gappy.starts)
+}
+
+func rangeDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+}
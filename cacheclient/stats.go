@@ -0,0 +1,67 @@
+package cacheclient
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Stats describes the contents of a cache directory
+type Stats struct {
+	Dir             string
+	HistoricalFiles int
+	LiveFiles       int
+	Rates           int
+	Bytes           int64
+}
+
+// GetStats returns statistics about the cache held in dir
+func GetStats(dir string) (*Stats, error) {
+	stats := &Stats{Dir: dir}
+
+	for _, sub := range []string{historicalDir, liveDir} {
+		err := filepath.Walk(filepath.Join(dir, sub), func(path string, info os.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || filepath.Ext(path) != fileExt || strings.HasPrefix(info.Name(), ".") {
+				return nil
+			}
+
+			cf, err := readCacheFile(path)
+			if err != nil {
+				return err
+			}
+
+			if sub == historicalDir {
+				stats.HistoricalFiles++
+			} else {
+				stats.LiveFiles++
+			}
+			stats.Rates += len(cf.Rates)
+			stats.Bytes += info.Size()
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read cache")
+		}
+	}
+
+	return stats, nil
+}
+
+// Clear removes all cached rates from dir. Only the files written by the cache are
+// removed so that pointing the cache at an existing directory is safe
+func Clear(dir string) error {
+	for _, sub := range []string{historicalDir, liveDir} {
+		if err := os.RemoveAll(filepath.Join(dir, sub)); err != nil {
+			return errors.Wrap(err, "could not clear cache")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/currency"
+)
+
+// The validation shared by the cobra Args functions and the serve handlers, so that a request
+// is accepted or rejected in the same way however it arrives
+
+const dateFormat = "2006-01-02"
+
+// parseCurrencyArgs parses arguments of the form [from] to [to]...
+func parseCurrencyArgs(command string, args []string) (from string, to []string, err error) {
+	return parseCurrencyList(command, "to", args)
+}
+
+// errBestTargets is returned for a best rate query to more than one currency
+var errBestTargets = errors.New("best needs exactly one \"to\" currency")
+
+// parseBestArgs parses arguments of the form [from] to [to], since a best rate is between
+// exactly two currencies
+func parseBestArgs(args []string) (from, to string, err error) {
+	from, targets, err := parseCurrencyArgs("best", args)
+	if err != nil {
+		return "", "", err
+	}
+	if len(targets) != 1 {
+		return "", "", errBestTargets
+	}
+	return from, targets[0], nil
+}
+
+// parseNeedArgs parses arguments of the form [target] from [source]...
+func parseNeedArgs(args []string) (target string, sources []string, err error) {
+	return parseCurrencyList("need", "from", args)
+}
+
+// parseMatrixArgs parses a basket of currencies, of which the first is the one the rates are
+// fetched from
+func parseMatrixArgs(args []string) ([]string, error) {
+	currencies, err := parseBasket(args)
+	if err != nil {
+		return nil, err
+	}
+	return currencies, currency.Validate(currencies...)
+}
+
+// parseBasket returns the distinct currencies of codes in the order they first appear
+func parseBasket(codes []string) ([]string, error) {
+	currencies, seen := []string{}, map[string]bool{}
+	for _, code := range codes {
+		code = strings.ToUpper(code)
+		if !seen[code] {
+			currencies = append(currencies, code)
+			seen[code] = true
+		}
+	}
+	if len(currencies) < 2 {
+		return nil, errors.New("a matrix needs at least two different currencies")
+	}
+	return currencies, nil
+}
+
+// parseCurrencyList parses arguments of the form [currency] word [currency]...
+func parseCurrencyList(command, word string, args []string) (first string, rest []string, err error) {
+	if len(args) < 3 {
+		return "", nil, fmt.Errorf("not enough args to %s", command)
+	}
+
+	if args[1] != word {
+		return "", nil, errors.New("incorrect argument format")
+	}
+
+	first = strings.ToUpper(args[0])
+	for _, cur := range args[2:] {
+		rest = append(rest, strings.ToUpper(cur))
+	}
+	if err := validateCurrencies(first, rest); err != nil {
+		return first, rest, err
+	}
+	// catch typos before they are sent to a provider, which would only report a bad currency
+	return first, rest, currency.Validate(append([]string{first}, rest...)...)
+}
+
+// validateCurrencies checks that there is a currency to convert from and at least one to convert to
+func validateCurrencies(from string, to []string) error {
+	if len(from) == 0 {
+		return errors.New("no \"from\" currency")
+	}
+
+	if len(to) == 0 {
+		return cringletest.ErrNoToCurrencies
+	}
+	return nil
+}
+
+// parseValue parses the amount to be converted
+func parseValue(s string) (*decimal.Big, error) {
+	value, ok := new(decimal.Big).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot be formatted as a number", s)
+	}
+	return value, nil
+}
+
+// parseDate parses a date in the 2006-01-02 format. An empty string is the zero date
+func parseDate(s string) (date time.Time, err error) {
+	if len(s) == 0 {
+		return date, nil
+	}
+
+	date, err = time.Parse(dateFormat, s)
+	if err != nil {
+		return date, fmt.Errorf("%s is not a date like %s", s, dateFormat)
+	}
+	return date, nil
+}
+
+// validateDays checks the number of days searched for a best rate
+func validateDays(days int) error {
+	if days < 1 {
+		return errors.New("days must be at least 1")
+	}
+	return nil
+}
+
+// validateInterval checks the interval a history is sampled at
+func validateInterval(interval string) error {
+	switch interval {
+	case cringletest.IntervalDay, cringletest.IntervalWeek, cringletest.IntervalMonth:
+		return nil
+	}
+	return fmt.Errorf("unknown interval %s, expected day, week or month", interval)
+}
+
+// validatePeriod checks that a history does not end before it starts
+func validatePeriod(start, end time.Time) error {
+	if start.After(end) {
+		return errors.New("the start of a history must not be after its end")
+	}
+	return nil
+}
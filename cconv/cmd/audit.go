@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/envy"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/auditnotifier"
+	"github.com/robotlovesyou/cringletest/formatnotifier"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditFrom     string
+	auditTo       string
+	auditCurrency string
+	auditKind     string
+)
+
+// getAuditLog returns the path of the audit log from --audit-log or CCONV_AUDIT_LOG, or an
+// empty string if there is none
+func getAuditLog() string {
+	if len(auditLog) != 0 {
+		return auditLog
+	}
+	return envy.Get(cringletest.AuditLogEnvVar, "")
+}
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit query [--from date] [--to date] [--currency code] [--kind kind]",
+	Short: "Search the audit log",
+	Long: `
+When --audit-log or $` + cringletest.AuditLogEnvVar + ` is set every result cconv sends is also appended to that
+file as a single line of JSON, with the exact value, provider and fetch time of every rate it used and
+the host and user which asked for it. The log is locked while it is written so that cconv commands
+running at the same time never mix up their records.
+
+cconv audit query prints the records in the log, oldest first, optionally only those written between
+--from and --to, involving --currency or of one --kind (rates, value, best, history, alert, need or matrix).
+Use --output json or jsonl to print the records as they are held in the log.
+
+For example:
+
+cconv audit query --audit-log audit.jsonl --from 2018-05-01 --to 2018-05-31 --currency GBP
+
+would print every record written in May 2018 which involved GBP`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 || args[0] != "query" {
+			return errors.New("audit requires exactly one operation, query")
+		}
+
+		if len(getAuditLog()) == 0 {
+			return fmt.Errorf("no audit log, use --audit-log or $%s to set one", cringletest.AuditLogEnvVar)
+		}
+
+		switch outputFormat {
+		case textOutput, formatnotifier.JSON, formatnotifier.JSONL:
+		default:
+			return fmt.Errorf("audit query cannot write %s, expected one of %s, %s or %s", outputFormat, textOutput, formatnotifier.JSON, formatnotifier.JSONL)
+		}
+
+		if _, err := getAuditFilter(); err != nil {
+			return err
+		}
+
+		return nil
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		filter, err := getAuditFilter()
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		if err := queryAudit(os.Stdout, getAuditLog(), filter, outputFormat); err != nil {
+			fmt.Printf("Cannot query audit log: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.Flags().StringVar(&auditFrom, "from", "", "Only show records written on or after this day")
+	auditCmd.Flags().StringVar(&auditTo, "to", "", "Only show records written on or before this day")
+	auditCmd.Flags().StringVar(&auditCurrency, "currency", "", "Only show records involving this currency")
+	auditCmd.Flags().StringVar(&auditKind, "kind", "", "Only show records of this kind, one of rates, value, best, history, alert, need or matrix")
+}
+
+func getAuditFilter() (*auditnotifier.Filter, error) {
+	since, err := parseDate(auditFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	until, err := parseDate(auditTo)
+	if err != nil {
+		return nil, err
+	}
+	if !until.IsZero() {
+		// include the whole of the last day
+		until = until.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	if !since.IsZero() && !until.IsZero() && until.Before(since) {
+		return nil, errors.New("--to must not be before --from")
+	}
+
+	return &auditnotifier.Filter{
+		Since:    since,
+		Until:    until,
+		Currency: strings.ToUpper(auditCurrency),
+		Kind:     strings.ToLower(auditKind),
+	}, nil
+}
+
+// queryAudit writes each record of the log at path which matches filter to out in format
+func queryAudit(out io.Writer, path string, filter *auditnotifier.Filter, format string) error {
+	enc := json.NewEncoder(out)
+	if format == formatnotifier.JSON {
+		enc.SetIndent("", "  ")
+	}
+
+	return auditnotifier.Query(path, filter, func(record *auditnotifier.Record) error {
+		if format != textOutput {
+			return enc.Encode(record)
+		}
+
+		fmt.Fprintf(out, "%s %s by %s@%s\n", record.Time.Format(time.RFC3339), record.Kind, record.User, record.Host)
+		for _, rate := range record.Rates {
+			line := fmt.Sprintf("  %s → %s on %s: %s", rate.From, rate.To, rate.Date, rate.Value)
+			if len(rate.Converted) != 0 {
+				amount := record.Inputs.Amount
+				if len(rate.Amount) != 0 {
+					amount = rate.Amount
+				}
+				line += fmt.Sprintf(" (%s %s = %s %s)", amount, rate.From, rate.Converted, rate.To)
+			}
+			if len(rate.Fees) != 0 {
+				line += fmt.Sprintf(" after %s %s fees", rate.Fees, rate.To)
+			}
+			if len(rate.Provider) != 0 {
+				line += " from " + rate.Provider
+			}
+			if len(rate.Fetched) != 0 {
+				line += " fetched " + rate.Fetched
+			}
+			if len(rate.Warning) != 0 {
+				line += " warning: " + rate.Warning
+			}
+			fmt.Fprintln(out, line)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/auditnotifier"
+	"github.com/robotlovesyou/cringletest/formatnotifier"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditQueryWritesText(t *testing.T) {
+	r := require.New(t)
+
+	dir, err := ioutil.TempDir("", "cconv-audit")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.jsonl")
+	audit, err := auditnotifier.New(path)
+	r.NoError(err)
+
+	date := time.Date(2018, 5, 25, 0, 0, 0, 0, time.UTC)
+	conversions, err := cringletest.Convert(cringletest.NewMoney(decimal.New(2, 0), "GBP"), []*cringletest.ExchangeRate{
+		{From: "GBP", To: "EUR", Date: date, Value: decimal.New(114371, 5), Provider: "ecb"},
+	}, cringletest.DefaultRounding)
+	r.NoError(err)
+	r.NoError(audit.NotifyValue(context.Background(), conversions))
+
+	auditCurrency = "eur"
+	defer func() { auditCurrency = "" }()
+	filter, err := getAuditFilter()
+	r.NoError(err)
+
+	buf := bytes.NewBuffer(nil)
+	r.NoError(queryAudit(buf, path, filter, textOutput))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	r.Len(lines, 2)
+	r.Contains(lines[0], " value by ")
+	r.Equal("  GBP → EUR on 2018-05-25: 1.14371 (2 GBP = 2.29 EUR) from ecb", lines[1])
+
+	buf.Reset()
+	r.NoError(queryAudit(buf, path, filter, formatnotifier.JSONL))
+	r.Equal(1, strings.Count(buf.String(), "\n"))
+	r.Contains(buf.String(), `"schema":"cconv.audit/v1"`)
+
+	auditCurrency = "JPY"
+	filter, err = getAuditFilter()
+	r.NoError(err)
+	buf.Reset()
+	r.NoError(queryAudit(buf, path, filter, textOutput))
+	r.Empty(buf.String())
+}
+
+func TestAuditFilterIncludesTheLastDay(t *testing.T) {
+	r := require.New(t)
+
+	auditFrom, auditTo = "2018-05-01", "2018-05-31"
+	defer func() { auditFrom, auditTo = "", "" }()
+
+	filter, err := getAuditFilter()
+	r.NoError(err)
+	r.Equal(time.Date(2018, 5, 1, 0, 0, 0, 0, time.UTC), filter.Since)
+	r.True(filter.Until.After(time.Date(2018, 5, 31, 23, 59, 59, 0, time.UTC)))
+	r.True(filter.Until.Before(time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)))
+
+	auditFrom, auditTo = "2018-06-01", "2018-05-31"
+	_, err = getAuditFilter()
+	r.EqualError(err, "--to must not be before --from")
+}
@@ -16,22 +16,29 @@ package cmd
 
 import (
 	"context"
-	"strings"
 	"time"
 
 	"github.com/ericlagergren/decimal"
 	"github.com/pkg/errors"
 	"github.com/robotlovesyou/cringletest"
-	"github.com/robotlovesyou/cringletest/clclient"
+	"github.com/robotlovesyou/cringletest/outbox"
+	"github.com/robotlovesyou/cringletest/rangeclient"
 	"github.com/spf13/cobra"
 )
 
+var (
+	bestDays  int
+	bestUntil string
+	bestWorst bool
+	bestStats bool
+)
+
 // bestCmd represents the best command
 var bestCmd = &cobra.Command{
-	Use:   "best [from currency] to [to currency] [--address someone@example.com]",
-	Short: "Get the best exchange rate between the given currencies over the last 7 days",
+	Use:   "best [from currency] to [to currency] [--days 7] [--until 2006-01-02] [--worst] [--stats] [--address someone@example.com]",
+	Short: "Get the best (or worst) exchange rate between the given currencies over a window of days",
 	Long: `
-cconv best fetches the best exchange rate between two currencies from the last 7 days.
+cconv best fetches the best exchange rate between two currencies over a window of days, the last 7 by default.
 if an email address is supplied the result will be emailed in additon to being reported on the command line.
 
 For example:
@@ -40,24 +47,23 @@ cconv best GBP to EUR --address someone@example.com
 
 would get the best exchange rate between GBP and EUR and would mail the result to someone@example.com
 
-cconv best ignores the --date flag`,
-	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) < 3 {
-			return errors.New("not enough args to rate")
-		}
+The window can be changed with --days and can end on a day other than today with --until (or --date).
+--worst reports the worst rate instead of the best and --stats adds the min, max, mean, median,
+standard deviation and the percentage gap between the best and worst days.
 
-		if args[1] != "to" {
-			return errors.New("incorrect argument format")
+cconv best GBP to EUR --days 30 --until 2018-05-25 --stats`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if _, _, err := parseBestArgs(args); err != nil {
+			return err
 		}
 
-		return nil
+		return validateDays(bestDays)
 	},
 
 	Run: func(cmd *cobra.Command, args []string) {
-		from := strings.ToUpper(args[0])
-		to := args[2]
+		from, to, _ := parseBestArgs(args)
 
-		client, err := clclient.New()
+		client, err := getClient()
 		if err != nil {
 			errorResult(err)
 			return
@@ -69,9 +75,19 @@ cconv best ignores the --date flag`,
 			return
 		}
 
+		until, err := getUntilDate()
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
 		err = fetchBest(context.Background(), &requestConfig{
 			From:      from,
 			To:        []string{to},
+			Date:      until,
+			Days:      bestDays,
+			Worst:     bestWorst,
+			Stats:     bestStats,
 			Client:    client,
 			Notifiers: notifiers,
 		})
@@ -82,42 +98,40 @@ cconv best ignores the --date flag`,
 }
 
 func init() {
+	bestCmd.Flags().IntVar(&bestDays, "days", defaultBestDays, "The number of days to search")
+	bestCmd.Flags().StringVar(&bestUntil, "until", "", "The last day to search (default --date or today)")
+	bestCmd.Flags().BoolVar(&bestWorst, "worst", false, "Report the worst rate rather than the best")
+	bestCmd.Flags().BoolVar(&bestStats, "stats", false, "Report statistics for the whole window")
 	rootCmd.AddCommand(bestCmd)
 }
 
-type rateResult struct {
-	rates cringletest.RateMap
-	err   error
-}
+const defaultBestDays = 7
 
-// pass requestConfig by value to create a copy which can be modified safely
-func getRateOnDate(ctx context.Context, config requestConfig, date time.Time) chan rateResult {
-	config.Date = date
-	ch := make(chan rateResult)
-	go func() {
-		rm, err := getRates(ctx, &config)
-		ch <- rateResult{rates: rm, err: err}
-	}()
-	return ch
+// getUntilDate returns the --until date, falling back to --date
+func getUntilDate() (time.Time, error) {
+	if len(bestUntil) != 0 {
+		return parseDate(bestUntil)
+	}
+	return getTargetDate()
 }
 
-func getLastSevenRates(ctx context.Context, config *requestConfig) (rates []*cringletest.ExchangeRate, err error) {
-	date := time.Now()
-	channels := []chan rateResult{}
-
-	for i := 7; i > 0; i-- {
-		channels = append(channels, getRateOnDate(ctx, *config, date))
-		date = date.Add(-24 * time.Hour)
+// getRateWindow fetches the days of rates ending on config.Date (or today) in a single range
+// request where the provider supports it
+func getRateWindow(ctx context.Context, config *requestConfig) (rates []*cringletest.ExchangeRate, err error) {
+	end := config.Date
+	if end.IsZero() {
+		end = time.Now()
 	}
+	start := end.AddDate(0, 0, 1-config.Days)
 
-	for _, ch := range channels {
-		result := <-ch
-		if result.err != nil {
-			return nil, errors.Wrap(result.err, "error getting best rates")
-		}
+	series, err := rangeclient.New(config.Client, cringletest.RangeConcurrency).GetRange(ctx, start, end, config.From, config.To...)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting best rates")
+	}
 
+	for _, day := range series {
 		// There will be only one rate in each map so we can add it to the rates
-		for _, rate := range result.rates {
+		for _, rate := range day.Rates {
 			rates = append(rates, rate)
 		}
 	}
@@ -136,8 +150,24 @@ func selectBestRate(rates []*cringletest.ExchangeRate) *cringletest.ExchangeRate
 	return bestRate
 }
 
+func selectWorstRate(rates []*cringletest.ExchangeRate) *cringletest.ExchangeRate {
+	worstRate := rates[0]
+
+	for _, rate := range rates[1:] {
+		if worstRate.Value.Cmp(rate.Value) > 0 {
+			worstRate = rate
+		}
+	}
+
+	return worstRate
+}
+
 func fetchBest(ctx context.Context, config *requestConfig) error {
-	rates, err := getLastSevenRates(ctx, config)
+	if config.Days == 0 {
+		config.Days = defaultBestDays
+	}
+
+	rates, err := getRateWindow(ctx, config)
 	if err != nil {
 		return err
 	}
@@ -146,11 +176,18 @@ func fetchBest(ctx context.Context, config *requestConfig) error {
 		return cringletest.ErrBadCurrencies
 	}
 
-	bestRate := selectBestRate(rates)
-
-	nfunc := func(cx context.Context, n cringletest.Notifier, value *decimal.Big, rates []*cringletest.ExchangeRate) error {
-		return n.NotifyBest(ctx, rates[0])
+	best := &cringletest.BestRate{
+		Rate:  selectBestRate(rates),
+		Worst: config.Worst,
+		Days:  config.Days,
+		Until: config.Date,
+	}
+	if config.Worst {
+		best.Rate = selectWorstRate(rates)
+	}
+	if config.Stats {
+		best.Stats = cringletest.NewRateStats(rates)
 	}
 
-	return notifyAll(ctx, config.Notifiers, decimal.New(1, 0), []*cringletest.ExchangeRate{bestRate}, nfunc)
+	return notifyAll(ctx, config.Notifiers, outbox.Best(best))
 }
@@ -3,9 +3,12 @@ package cmd
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/ericlagergren/decimal"
 	"github.com/pkg/errors"
 	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/report"
 	"github.com/stretchr/testify/require"
 )
 
@@ -18,6 +21,19 @@ func getBestRateArgs(cl cringletest.RateClient, notifiers []cringletest.Notifier
 	}
 }
 
+func TestParseBestArgsNeedsOneTarget(t *testing.T) {
+	r := require.New(t)
+
+	from, to, err := parseBestArgs([]string{"gbp", "to", "eur"})
+	r.NoError(err)
+	r.Equal("GBP", from)
+	r.Equal("EUR", to)
+
+	_, _, err = parseBestArgs([]string{"GBP", "to", "EUR", "USD"})
+	r.EqualError(err, "best needs exactly one \"to\" currency")
+	r.Error(bestCmd.Args(bestCmd, []string{"GBP", "to", "EUR", "USD"}))
+}
+
 func TestFetchBest(t *testing.T) {
 	r := require.New(t)
 	cl, n1, n2 := getClientAndNotifiers(nil, nil, nil)
@@ -41,3 +57,51 @@ func TestFetchBestReturnsCorrectNotifierError(t *testing.T) {
 	err := fetchBest(context.Background(), getBestRateArgs(cl, []cringletest.Notifier{n1, n2}))
 	r.EqualError(errors.Cause(err), cringletest.ErrBadAuth.Error())
 }
+
+func TestGetRateWindowFetchesRequestedDays(t *testing.T) {
+	r := require.New(t)
+	cl, _, _ := getClientAndNotifiers(nil, nil, nil)
+
+	until := time.Date(2018, 5, 25, 0, 0, 0, 0, time.UTC)
+	config := getBestRateArgs(cl, nil)
+	config.Days = 30
+	config.Date = until
+
+	rates, err := getRateWindow(context.Background(), config)
+	r.NoError(err)
+	r.Len(rates, 30)
+	r.Equal(until, rates[29].Date)
+	r.Equal(until.AddDate(0, 0, -29), rates[0].Date)
+}
+
+func TestSelectBestAndWorstRate(t *testing.T) {
+	r := require.New(t)
+
+	rates := []*cringletest.ExchangeRate{
+		&cringletest.ExchangeRate{Value: decimal.New(2, 0)},
+		&cringletest.ExchangeRate{Value: decimal.New(3, 0)},
+		&cringletest.ExchangeRate{Value: decimal.New(1, 0)},
+	}
+
+	r.Equal(rates[1], selectBestRate(rates))
+	r.Equal(rates[2], selectWorstRate(rates))
+}
+
+func TestFetchWorstWithStats(t *testing.T) {
+	r := require.New(t)
+	cl, n1, n2 := getClientAndNotifiers(nil, nil, nil)
+
+	capture := &captureNotifier{}
+	config := getBestRateArgs(cl, []cringletest.Notifier{n1, n2, capture})
+	config.Worst = true
+	config.Stats = true
+
+	err := fetchBest(context.Background(), config)
+	r.NoError(err)
+
+	r.Equal(report.KindBest, capture.doc.Kind)
+	r.True(capture.doc.Best.Worst)
+	r.Equal(defaultBestDays, capture.doc.Best.Days)
+	r.NotNil(capture.doc.Best.Stats)
+	r.Equal(defaultBestDays, capture.doc.Best.Stats.Count)
+}
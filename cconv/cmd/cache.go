@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest/cacheclient"
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache stats|clear [--cache-dir /path/to/cache]",
+	Short: "Inspect or clear the local rate cache",
+	Long: `
+cconv caches the rates it fetches so that repeated requests do not use up the provider's quota.
+Historical rates are cached forever and live rates are cached for --cache-ttl.
+
+For example:
+
+cconv cache stats
+
+would show how many rates are held in the cache and
+
+cconv cache clear
+
+would remove them all`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("cache requires exactly one of stats or clear")
+		}
+
+		if args[0] != "stats" && args[0] != "clear" {
+			return fmt.Errorf("unknown cache operation %s", args[0])
+		}
+
+		return nil
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := getCacheDir()
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		for _, name := range providerNames() {
+			providerDir := filepath.Join(dir, name)
+			if args[0] == "clear" {
+				err = cacheclient.Clear(providerDir)
+			} else {
+				err = showCacheStats(name, providerDir)
+			}
+			if err != nil {
+				errorResult(err)
+				return
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+}
+
+func showCacheStats(provider, dir string) error {
+	stats, err := cacheclient.GetStats(dir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Provider:         %s\n", provider)
+	fmt.Printf("Cache directory:  %s\n", stats.Dir)
+	fmt.Printf("Historical files: %d\n", stats.HistoricalFiles)
+	fmt.Printf("Live files:       %d\n", stats.LiveFiles)
+	fmt.Printf("Cached rates:     %d\n", stats.Rates)
+	fmt.Printf("Size on disk:     %d bytes\n\n", stats.Bytes)
+	return nil
+}
@@ -3,45 +3,322 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/ericlagergren/decimal"
+	"github.com/gobuffalo/envy"
+	"github.com/pkg/errors"
 	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/auditnotifier"
+	"github.com/robotlovesyou/cringletest/chatnotifier"
 	"github.com/robotlovesyou/cringletest/consolenotifier"
+	"github.com/robotlovesyou/cringletest/formatnotifier"
+	"github.com/robotlovesyou/cringletest/outbox"
 	"github.com/robotlovesyou/cringletest/sgnotifier"
+	"github.com/robotlovesyou/cringletest/smtpnotifier"
+	"github.com/robotlovesyou/cringletest/webhooknotifier"
 )
 
 type requestConfig struct {
 	From      string
 	To        []string
 	Value     *decimal.Big
+	Rounding  *cringletest.Rounding
+	Fees      *cringletest.Fees
 	Date      time.Time
+	Start     time.Time
+	Interval  string
+	Days      int
+	Worst     bool
+	Stats     bool
 	Client    cringletest.RateClient
 	Notifiers []cringletest.Notifier
 }
 
 func errorResult(err error) {
+	if nerr, ok := err.(*notifyError); ok {
+		if !nerr.required() {
+			// the results were delivered by every notifier which had to deliver them
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			return
+		}
+		fmt.Printf("Cannot send results: %v\n", err)
+		return
+	}
 	fmt.Printf("Cannot get rates: %v\n", err)
 }
 
+// failResult writes why a command failed and exits with a non zero status, so that scripts can
+// tell that it did
+func failResult(what string, err error) {
+	fmt.Printf("Cannot %s: %v\n", what, err)
+	os.Exit(1)
+}
+
+func getCacheDir() (string, error) {
+	if len(cacheDir) != 0 {
+		return cacheDir, nil
+	}
+
+	if dir := envy.Get(cringletest.CacheDirEnvVar, ""); len(dir) != 0 {
+		return dir, nil
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "could not find a cache directory, use --cache-dir to set one")
+	}
+	return filepath.Join(dir, "cconv"), nil
+}
+
+// textOutput is the --output format written by the console notifier
+const textOutput = "text"
+
+func outputFormats() []string {
+	return append([]string{textOutput}, formatnotifier.Formats...)
+}
+
+// getOutputNotifier returns the notifier which writes results to stdout in the --output format
+func getOutputNotifier() (cringletest.Notifier, error) {
+	if len(outputFormat) == 0 || outputFormat == textOutput {
+		return consolenotifier.New(), nil
+	}
+	for _, format := range formatnotifier.Formats {
+		if outputFormat == format {
+			return formatnotifier.New(outputFormat)
+		}
+	}
+	return nil, fmt.Errorf("unknown output format %s, expected one of %s", outputFormat, strings.Join(outputFormats(), ", "))
+}
+
+// The services email can be sent with
+const (
+	sendgridMailer = "sendgrid"
+	smtpMailer     = "smtp"
+)
+
+var mailers = map[string]func(to string) (cringletest.Notifier, error){
+	sendgridMailer: sgnotifier.New,
+	smtpMailer:     smtpnotifier.New,
+}
+
+// getMailNotifier returns a notifier which emails to using the service chosen by --mailer, along
+// with the notifier URL it can be built again from
+func getMailNotifier(to string) (cringletest.Notifier, string, error) {
+	name := mailerFlag
+	if len(name) == 0 {
+		name = envy.Get(cringletest.MailerEnvVar, sendgridMailer)
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	newMailer, ok := mailers[name]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown mailer %s, expected one of %s or %s", name, sendgridMailer, smtpMailer)
+	}
+
+	notifier, err := newMailer(to)
+	if err != nil {
+		return nil, "", err
+	}
+	return notifier, mailTarget(name, to), nil
+}
+
+// mailTarget returns the notifier URL of the notifier which emails to using mailer. The SMTP
+// password is left out of the URL and read from the environment when it is built
+func mailTarget(mailer, to string) string {
+	if mailer == smtpMailer {
+		config, err := smtpnotifier.ConfigFromEnv()
+		if err != nil {
+			return ""
+		}
+		return config.URL(to)
+	}
+	return (&url.URL{Scheme: "sendgrid", RawQuery: url.Values{"to": {to}}.Encode()}).String()
+}
+
+// auditTarget returns the notifier URL of the audit log at path
+func auditTarget(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	return (&url.URL{Scheme: "audit", Path: filepath.ToSlash(abs)}).String()
+}
+
+// The names notifiers are reported by and can be passed to --required as
+const (
+	outputNotifierName  = "output"
+	emailNotifierName   = "email"
+	webhookNotifierName = "webhook"
+	chatNotifierName    = "chat"
+	auditNotifierName   = "audit"
+)
+
+// webhookName returns the name of the webhook at index i of hooks, such as webhook:example.com.
+// Webhooks to the same host are told apart by their position in hooks, such as webhook:example.com#2
+func webhookName(hooks []string, i int) string {
+	host := func(hook string) string {
+		if u, err := url.Parse(hook); err == nil && len(u.Host) != 0 {
+			return u.Host
+		}
+		return hook
+	}
+
+	names := []string{}
+	for _, hook := range hooks {
+		names = append(names, webhookNotifierName+":"+host(hook))
+	}
+	return uniqueName(names, i)
+}
+
+// uniqueName returns names[i], followed by its position in names when another of them is the
+// same, such as smtp#2
+func uniqueName(names []string, i int) string {
+	for j, name := range names {
+		if j != i && name == names[i] {
+			return fmt.Sprintf("%s#%d", names[i], i+1)
+		}
+	}
+	return names[i]
+}
+
+// nameNotifier names notifier, marking it as required if it was passed to --required
+func nameNotifier(name string, notifier cringletest.Notifier) *namedNotifier {
+	required := false
+	for _, r := range requiredNotifiers {
+		if r == name {
+			required = true
+		}
+	}
+
+	named := newNamedNotifier(name, notifier, required)
+	named.timeout = notifyTimeout
+	return named
+}
+
 func getNotifiers() ([]cringletest.Notifier, error) {
-	notifiers := []cringletest.Notifier{consolenotifier.New()}
+	if err := loadTemplates(); err != nil {
+		return nil, err
+	}
+	if err := loadLocale(); err != nil {
+		return nil, err
+	}
+
+	output, err := getOutputNotifier()
+	if err != nil {
+		return nil, err
+	}
+
+	notifiers := []cringletest.Notifier{nameNotifier(outputNotifierName, output)}
+
+	// failures of every notifier but the output can be kept in the outbox and sent again
+	queue, err := getOutbox()
+	if err != nil {
+		return nil, err
+	}
+
+	urlNotifiers, err := getURLNotifiers(queue)
+	if err != nil {
+		return nil, err
+	}
+	notifiers = append(notifiers, urlNotifiers...)
 	if len(targetAddress) != 0 {
-		mailNotifier, err := sgnotifier.New(targetAddress)
+		mailNotifier, target, err := getMailNotifier(targetAddress)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, nameNotifier(emailNotifierName, mailNotifier).queueTo(queue, localeTarget(target)))
+	}
+
+	// each webhook is a notifier of its own so that only those which fail are kept in the outbox
+	for i, hook := range webhooks {
+		hookNotifier, err := webhooknotifier.New([]string{hook})
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, nameNotifier(webhookName(webhooks, i), hookNotifier).queueTo(queue, "webhook+"+hook))
+	}
+
+	chatURL := chatWebhook
+	if len(chatURL) == 0 {
+		chatURL = envy.Get(cringletest.ChatWebhookEnvVar, "")
+	}
+	if len(chatURL) != 0 {
+		chat, err := chatnotifier.New(chatURL)
 		if err != nil {
 			return nil, err
 		}
-		notifiers = append(notifiers, mailNotifier)
+		notifiers = append(notifiers, nameNotifier(chatNotifierName, chat).queueTo(queue, "chat+"+chatURL))
 	}
 
+	if path := getAuditLog(); len(path) != 0 {
+		audit, err := auditnotifier.New(path)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, nameNotifier(auditNotifierName, audit).queueTo(queue, auditTarget(path)))
+	}
+
+	return notifiers, checkRequired(notifiers)
+}
+
+// checkRequired checks that every name passed to --required names one of notifiers, so that a
+// typo cannot quietly leave a notifier optional
+func checkRequired(notifiers []cringletest.Notifier) error {
+	names := []string{}
+	for _, notifier := range notifiers {
+		if named, ok := notifier.(*namedNotifier); ok {
+			names = append(names, named.name)
+		}
+	}
+
+	for _, required := range requiredNotifiers {
+		found := false
+		for _, name := range names {
+			found = found || name == required
+		}
+		if !found {
+			return fmt.Errorf("--required %s is not one of the notifiers, expected one of %s", required, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
+// getURLNotifiers builds a notifier for each URL passed to --notify and then each URL in the
+// config file. Each is named by the fragment of its URL, or its scheme if it has none, followed by
+// its position when another URL has the same name, and keeps its failures in queue
+func getURLNotifiers(queue *outbox.Outbox) ([]cringletest.Notifier, error) {
+	config, path, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	urls := append(append([]string{}, notifyURLs...), config.Notify...)
+	names := []string{}
+	for _, raw := range urls {
+		names = append(names, cringletest.NotifierName(raw))
+	}
+
+	notifiers := []cringletest.Notifier{}
+	for i, raw := range urls {
+		notifier, err := cringletest.NewNotifier(raw)
+		if err != nil {
+			if i >= len(notifyURLs) {
+				return nil, errors.Wrapf(err, "in %s", path)
+			}
+			return nil, err
+		}
+		notifiers = append(notifiers, nameNotifier(uniqueName(names, i), notifier).queueTo(queue, raw))
+	}
 	return notifiers, nil
 }
 
 func getTargetDate() (date time.Time, err error) {
-	if len(targetDate) != 0 {
-		date, err = time.Parse("2006-02-01", targetDate)
-	}
-	return date, err
+	return parseDate(targetDate)
 }
 
 func getRates(ctx context.Context, config *requestConfig) (rateMap cringletest.RateMap, err error) {
@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTargetDateParsesYearMonthDay(t *testing.T) {
+	r := require.New(t)
+	defer func(date string) { targetDate = date }(targetDate)
+
+	targetDate = "2018-05-25"
+	date, err := getTargetDate()
+	r.NoError(err)
+	r.Equal(time.Date(2018, 5, 25, 0, 0, 0, 0, time.UTC), date)
+}
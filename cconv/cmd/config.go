@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gobuffalo/envy"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+)
+
+// fileConfig is the layout of the cconv config file
+type fileConfig struct {
+	// Notify lists the URLs of notifiers which every command sends its results to
+	Notify []string `json:"notify"`
+	// Templates is the directory of templates which override the built in ones. A relative path is
+	// relative to the directory of the config file
+	Templates string `json:"templates"`
+	// Locale is the locale numbers and dates are formatted for, such as de-DE
+	Locale string `json:"locale"`
+	// Fees are named fee profiles which cconv value can charge with --fee-profile, such as wise
+	Fees map[string]*feeProfile `json:"fees"`
+}
+
+// getConfigPath returns the path of the config file and whether it was asked for explicitly,
+// in which case it must exist
+func getConfigPath() (string, bool) {
+	if len(configPath) != 0 {
+		return configPath, true
+	}
+
+	if path := envy.Get(cringletest.ConfigEnvVar, ""); len(path) != 0 {
+		return path, true
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		// with nowhere to look for a config file there is simply no config
+		return "", false
+	}
+	return filepath.Join(dir, "cconv", "config.json"), false
+}
+
+// loadConfig reads the config file. A config file which was not asked for explicitly and does
+// not exist is treated as empty
+func loadConfig() (*fileConfig, string, error) {
+	path, explicit := getConfigPath()
+	if len(path) == 0 {
+		return &fileConfig{}, path, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !explicit {
+		return &fileConfig{}, path, nil
+	}
+	if err != nil {
+		return nil, path, errors.Wrap(err, "could not read config")
+	}
+
+	config := &fileConfig{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(config); err != nil {
+		return nil, path, errors.Wrapf(err, "could not parse config %s", path)
+	}
+	return config, path, nil
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest/batch"
+	"github.com/spf13/cobra"
+)
+
+var (
+	convertTo          string
+	convertOut         string
+	convertFormat      string
+	convertInFormat    string
+	convertAmountCol   string
+	convertCurrencyCol string
+	convertDateCol     string
+)
+
+// convertFileCmd represents the convert-file command
+var convertFileCmd = &cobra.Command{
+	Use:   "convert-file [input file] --to [currency] [--out file] [--format csv|jsonl] [--input-format csv|jsonl] [--amount-col amount] [--currency-col currency] [--date-col date]",
+	Short: "Convert every row of a CSV or JSONL file into a single currency",
+	Long: `
+cconv convert-file reads a CSV file with a header row, or a JSONL file with one object per line, and converts
+the amount on each row from the currency on that row into the --to currency at the rate on the date on that row.
+Rows without a date are converted at the live rate. Each distinct date and currency is only looked up once.
+
+Every row is written out with converted, rate and error columns added. A row which cannot be converted, for
+example because its currency is unknown, has the reason in its error column and the rest of the file is still
+converted.
+
+Files ending in .jsonl or .ndjson are read as JSONL and anything else as CSV, unless --input-format is given.
+Use - to read from stdin, which is read as JSONL if it starts with { and as CSV otherwise. The output is written
+in the same format as the input unless --format is given, to stdout unless --out is given.
+
+For example:
+
+cconv convert-file export.csv --to GBP --out converted.csv --amount-col total
+
+would convert the total column of every row of export.csv into GBP and write the result to converted.csv`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("convert-file needs exactly one input file")
+		}
+
+		if len(convertTo) == 0 {
+			return errors.New("--to is required")
+		}
+
+		for _, format := range []string{convertFormat, convertInFormat} {
+			if len(format) != 0 && format != batch.CSV && format != batch.JSONL {
+				return fmt.Errorf("unknown format %s, expected %s or %s", format, batch.CSV, batch.JSONL)
+			}
+		}
+
+		return nil
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := getClient()
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		file, err := openInput(args[0])
+		if err != nil {
+			errorResult(err)
+			return
+		}
+		defer file.Close()
+		in := bufio.NewReader(file)
+
+		out, err := createOutput(convertOut)
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		inFormat, outFormat := chooseFormats(args[0], in)
+
+		columns := batch.Columns{
+			Amount:   convertAmountCol,
+			Currency: convertCurrencyCol,
+			Date:     convertDateCol,
+		}
+		converter := batch.New(client, strings.ToUpper(convertTo), columns)
+
+		summary, err := converter.Convert(context.Background(), in, inFormat, out, outFormat)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		fmt.Fprintf(os.Stderr, "Converted %d rows with %d errors using %d rate lookups\n", summary.Rows, summary.Errors, summary.Lookups)
+	},
+}
+
+func init() {
+	convertFileCmd.Flags().StringVar(&convertTo, "to", "", "The currency to convert every row into")
+	convertFileCmd.Flags().StringVar(&convertOut, "out", "", "The file to write the converted rows to (default stdout)")
+	convertFileCmd.Flags().StringVar(&convertFormat, "format", "", "The format to write, csv or jsonl (default the input format)")
+	convertFileCmd.Flags().StringVar(&convertInFormat, "input-format", "", "The format to read, csv or jsonl (default from the file extension, or the contents of stdin)")
+	convertFileCmd.Flags().StringVar(&convertAmountCol, "amount-col", batch.DefaultColumns.Amount, "The column holding the amount")
+	convertFileCmd.Flags().StringVar(&convertCurrencyCol, "currency-col", batch.DefaultColumns.Currency, "The column holding the currency of the amount")
+	convertFileCmd.Flags().StringVar(&convertDateCol, "date-col", batch.DefaultColumns.Date, "The column holding the date of the rate to use")
+	rootCmd.AddCommand(convertFileCmd)
+}
+
+// chooseFormats returns the format the input at path is read in and the format the output is written
+// in. Without --input-format a file is read in the format its extension suggests and stdin as JSONL
+// if it starts with { and as CSV otherwise
+func chooseFormats(path string, in *bufio.Reader) (inFormat, outFormat string) {
+	inFormat = convertInFormat
+	if len(inFormat) == 0 {
+		if path == "-" {
+			inFormat = sniffFormat(in)
+		} else {
+			inFormat = batch.FormatFromPath(path)
+		}
+	}
+
+	outFormat = convertFormat
+	if len(outFormat) == 0 {
+		outFormat = inFormat
+	}
+	return inFormat, outFormat
+}
+
+// sniffFormat peeks past any leading whitespace of in, without consuming it, to tell JSONL from
+// CSV. It keeps reading until it finds something else, so a pipe which is slow to send the first
+// line is not mistaken for CSV
+func sniffFormat(in *bufio.Reader) string {
+	for n := 1; ; n++ {
+		start, _ := in.Peek(n)
+		if len(start) < n {
+			// the input ended, or filled the buffer, with nothing but whitespace
+			return batch.CSV
+		}
+		switch start[n-1] {
+		case ' ', '\t', '\r', '\n':
+		case '{':
+			return batch.JSONL
+		default:
+			return batch.CSV
+		}
+	}
+}
+
+// nopCloser stops stdin and stdout from being closed
+type nopCloser struct {
+	*os.File
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return nopCloser{os.Stdin}, nil
+	}
+	return os.Open(path)
+}
+
+func createOutput(path string) (io.WriteCloser, error) {
+	if len(path) == 0 || path == "-" {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(path)
+}
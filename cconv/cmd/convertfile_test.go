@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/robotlovesyou/cringletest/batch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChooseFormatsSniffsStdin(t *testing.T) {
+	r := require.New(t)
+
+	in := bufio.NewReader(strings.NewReader("\n{\"amount\": \"1\", \"currency\": \"GBP\"}\n"))
+	inFormat, outFormat := chooseFormats("-", in)
+	r.Equal(batch.JSONL, inFormat)
+	r.Equal(batch.JSONL, outFormat)
+
+	// sniffing does not consume the input
+	contents, err := ioutil.ReadAll(in)
+	r.NoError(err)
+	r.Equal("\n{\"amount\": \"1\", \"currency\": \"GBP\"}\n", string(contents))
+
+	inFormat, _ = chooseFormats("-", bufio.NewReader(strings.NewReader("amount,currency\n1,GBP\n")))
+	r.Equal(batch.CSV, inFormat)
+
+	inFormat, _ = chooseFormats("-", bufio.NewReader(strings.NewReader("")))
+	r.Equal(batch.CSV, inFormat)
+}
+
+func TestSniffFormatReadsPastAShortFirstRead(t *testing.T) {
+	r := require.New(t)
+
+	// a pipe can deliver the leading blank line on its own before the rest of the stream
+	in := bufio.NewReader(io.MultiReader(strings.NewReader("\n"), strings.NewReader(" \n{\"amount\": \"1\"}\n")))
+	r.Equal(batch.JSONL, sniffFormat(in))
+
+	contents, err := ioutil.ReadAll(in)
+	r.NoError(err)
+	r.Equal("\n \n{\"amount\": \"1\"}\n", string(contents))
+
+	in = bufio.NewReader(io.MultiReader(strings.NewReader("\n"), strings.NewReader("amount,currency\n")))
+	r.Equal(batch.CSV, sniffFormat(in))
+
+	r.Equal(batch.CSV, sniffFormat(bufio.NewReader(strings.NewReader(" \n\t"))))
+}
+
+func TestChooseFormatsPrefersFlags(t *testing.T) {
+	r := require.New(t)
+	defer func(in, out, to string) {
+		convertInFormat, convertFormat, convertTo = in, out, to
+	}(convertInFormat, convertFormat, convertTo)
+
+	csv := bufio.NewReader(strings.NewReader("amount,currency\n"))
+
+	inFormat, outFormat := chooseFormats("export.ndjson", csv)
+	r.Equal(batch.JSONL, inFormat)
+	r.Equal(batch.JSONL, outFormat)
+
+	convertInFormat, convertFormat = batch.JSONL, batch.CSV
+	inFormat, outFormat = chooseFormats("-", csv)
+	r.Equal(batch.JSONL, inFormat)
+	r.Equal(batch.CSV, outFormat)
+
+	convertInFormat, convertTo = "xml", "GBP"
+	r.EqualError(convertFileCmd.Args(convertFileCmd, []string{"-"}), "unknown format xml, expected csv or jsonl")
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest/currency"
+	"github.com/robotlovesyou/cringletest/formatnotifier"
+	"github.com/spf13/cobra"
+)
+
+var currencySearch string
+
+// currenciesCmd represents the currencies command
+var currenciesCmd = &cobra.Command{
+	Use:   "currencies [--search text]",
+	Short: "List the currencies cconv knows about",
+	Long: `
+cconv currencies lists the ISO 4217 currencies along with their numeric codes, the decimal places amounts of
+them are written with and their symbols. It also lists currencies which have been withdrawn but still have
+rates for the days before they were replaced, and a few without an ISO 4217 code which providers quote.
+rate, value, best and history reject any other currency before asking a provider for it.
+
+--search only lists the currencies whose code, name, numeric code or symbol contains the text.
+Use --output json to list them as JSON.
+
+For example:
+
+cconv currencies --search dollar
+
+would list every dollar`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 {
+			return errors.New("currencies takes no arguments, use --search to find a currency")
+		}
+
+		switch outputFormat {
+		case textOutput, formatnotifier.JSON:
+		default:
+			return fmt.Errorf("currencies cannot write %s, expected %s or %s", outputFormat, textOutput, formatnotifier.JSON)
+		}
+		return nil
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := listCurrencies(os.Stdout, currency.Search(currencySearch), outputFormat); err != nil {
+			failResult("list currencies", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(currenciesCmd)
+	currenciesCmd.Flags().StringVar(&currencySearch, "search", "", "Only list currencies whose code, name, numeric code or symbol contains this")
+}
+
+// listCurrencies writes currencies to out in format
+func listCurrencies(out io.Writer, currencies []*currency.Currency, format string) error {
+	if format == formatnotifier.JSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(currencies)
+	}
+
+	if len(currencies) == 0 {
+		_, err := fmt.Fprintln(out, "No currencies found")
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Code\tNumber\tDecimals\tSymbol\tName")
+	for _, c := range currencies {
+		decimals := "-"
+		if c.MinorUnits != currency.NoMinorUnits {
+			decimals = strconv.Itoa(c.MinorUnits)
+		}
+
+		name := c.Name
+		switch {
+		case c.Withdrawn:
+			name += " (withdrawn)"
+		case c.Unofficial:
+			name += " (not ISO 4217)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.Code, c.NumericCode(), decimals, c.Symbol, name)
+	}
+	return w.Flush()
+}
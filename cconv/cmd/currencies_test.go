@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/robotlovesyou/cringletest/currency"
+	"github.com/robotlovesyou/cringletest/formatnotifier"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrencyArgsAreValidated(t *testing.T) {
+	r := require.New(t)
+
+	from, to, err := parseCurrencyArgs("rate", []string{"gbp", "to", "eur", "jpy"})
+	r.NoError(err)
+	r.Equal("GBP", from)
+	r.Equal([]string{"EUR", "JPY"}, to)
+
+	_, _, err = parseCurrencyArgs("rate", []string{"GPB", "to", "EUR"})
+	r.EqualError(err, "unknown currency GPB — did you mean GBP?")
+
+	_, _, err = parseCurrencyArgs("value", []string{"GBP", "to", "EUR", "UDS"})
+	r.EqualError(err, "unknown currency UDS — did you mean USD or UZS?")
+}
+
+func TestListCurrencies(t *testing.T) {
+	r := require.New(t)
+
+	out := bytes.NewBuffer(nil)
+	r.NoError(listCurrencies(out, currency.Search("pound sterling"), textOutput))
+	r.Equal("Code  Number  Decimals  Symbol  Name\nGBP   826     2         £       Pound Sterling\n", out.String())
+
+	out.Reset()
+	r.NoError(listCurrencies(out, currency.Search("kuna"), textOutput))
+	r.Contains(out.String(), "HRK   191     2                 Kuna (withdrawn)\n")
+
+	out.Reset()
+	r.NoError(listCurrencies(out, currency.Search("nothing like this"), textOutput))
+	r.Equal("No currencies found\n", out.String())
+
+	out.Reset()
+	r.NoError(listCurrencies(out, currency.Search("JPY"), formatnotifier.JSON))
+	listed := []*currency.Currency{}
+	r.NoError(json.Unmarshal(out.Bytes(), &listed))
+	r.Len(listed, 1)
+	r.Equal(0, listed[0].MinorUnits)
+	r.Equal(392, listed[0].Numeric)
+}
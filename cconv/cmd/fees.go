@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/robotlovesyou/cringletest"
+)
+
+// feeProfile is a named set of fees in the config file, such as what a particular bank charges
+type feeProfile struct {
+	// Percent is charged on the amount converted, such as 0.5 for half a percent
+	Percent json.Number `json:"percent"`
+	// Fixed is charged on every conversion, such as "2.50 GBP"
+	Fixed string `json:"fixed"`
+	// Spread is how far below the mid-market rate the rate given is, in basis points
+	Spread json.Number `json:"spread"`
+}
+
+// feeOptions are the fees asked for by flags or query parameters. Any which are set override
+// those of the named profile
+type feeOptions struct {
+	Profile string
+	Percent string
+	Fixed   string
+	Spread  string
+}
+
+// parseDecimal parses the value of the fee option name
+func parseDecimal(name, s string) (*decimal.Big, error) {
+	d, ok := new(decimal.Big).SetString(strings.TrimSpace(s))
+	if !ok || !d.IsFinite() {
+		return nil, fmt.Errorf("%s %s cannot be formatted as a number", name, s)
+	}
+	return d, nil
+}
+
+// parseFixedFee parses an amount of money such as "2.50 GBP" or "2.50GBP"
+func parseFixedFee(s string) (*cringletest.Money, error) {
+	s = strings.TrimSpace(s)
+	split := strings.LastIndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) }) + 1
+	amount, code := strings.TrimSpace(s[:split]), strings.ToUpper(s[split:])
+	if len(amount) == 0 || len(code) == 0 {
+		return nil, fmt.Errorf("fixed fee %s must be an amount and a currency, such as 2.50 GBP", s)
+	}
+
+	d, err := parseDecimal("fixed fee", amount)
+	if err != nil {
+		return nil, err
+	}
+	return cringletest.NewMoney(d, code), nil
+}
+
+// getFeeProfile returns the fee profile called name from the config file
+func getFeeProfile(name string) (*feeProfile, error) {
+	config, _, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	profile, ok := config.Fees[name]
+	if !ok {
+		names := []string{}
+		for name := range config.Fees {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("unknown fee profile %s, the config file has none", name)
+		}
+		return nil, fmt.Errorf("unknown fee profile %s, expected one of %s", name, strings.Join(names, ", "))
+	}
+	return profile, nil
+}
+
+// getFees returns the fees of options, or nil if no fees were asked for
+func getFees(options *feeOptions) (*cringletest.Fees, error) {
+	percent, fixed, spread := options.Percent, options.Fixed, options.Spread
+	fees := &cringletest.Fees{}
+	if len(options.Profile) != 0 {
+		profile, err := getFeeProfile(options.Profile)
+		if err != nil {
+			return nil, err
+		}
+		fees.Name = options.Profile
+		if len(percent) == 0 {
+			percent = profile.Percent.String()
+		}
+		if len(fixed) == 0 {
+			fixed = profile.Fixed
+		}
+		if len(spread) == 0 {
+			spread = profile.Spread.String()
+		}
+	} else if len(percent) == 0 && len(fixed) == 0 && len(spread) == 0 {
+		return nil, nil
+	}
+
+	var err error
+	if len(percent) != 0 {
+		if fees.Percent, err = parseDecimal("fee", percent); err != nil {
+			return nil, err
+		}
+	}
+	if len(fixed) != 0 {
+		if fees.Fixed, err = parseFixedFee(fixed); err != nil {
+			return nil, err
+		}
+	}
+	if len(spread) != 0 {
+		if fees.Spread, err = parseDecimal("spread", spread); err != nil {
+			return nil, err
+		}
+	}
+	return fees, fees.Validate()
+}
+
+// validateFixedFee checks that a fixed fee is in a currency of the conversion, so that it can
+// be charged
+func validateFixedFee(fees *cringletest.Fees, from string, to []string) error {
+	if fees == nil || fees.Fixed == nil {
+		return nil
+	}
+	for _, code := range append([]string{from}, to...) {
+		if strings.EqualFold(code, fees.Fixed.Currency) {
+			return nil
+		}
+	}
+	return fmt.Errorf("fixed fee %s must be in %s or one of the currencies converted to", fees.Fixed, from)
+}
+
+// validateNeedFixedFee checks that a fixed fee can be charged on every conversion of a need
+// query. Each source is converted on its own, so the fee must be in the target currency or in
+// every source currency
+func validateNeedFixedFee(fees *cringletest.Fees, target string, sources []string) error {
+	if fees == nil || fees.Fixed == nil || strings.EqualFold(target, fees.Fixed.Currency) {
+		return nil
+	}
+	for _, code := range sources {
+		if !strings.EqualFold(code, fees.Fixed.Currency) {
+			return fmt.Errorf("fixed fee %s must be in %s or in every currency converted from", fees.Fixed, target)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFixedFee(t *testing.T) {
+	r := require.New(t)
+
+	for _, s := range []string{"2.50 GBP", "2.50GBP", " 2.50 gbp "} {
+		fee, err := parseFixedFee(s)
+		r.NoError(err, s)
+		r.Equal("2.50 GBP", fee.String(), s)
+	}
+
+	for _, s := range []string{"2.50", "GBP", "lots GBP", "2.50 GBP EUR"} {
+		_, err := parseFixedFee(s)
+		r.Error(err, s)
+	}
+}
+
+func TestFeesComeFromProfileAndFlags(t *testing.T) {
+	r := require.New(t)
+
+	defer withConfig(r, `{"fees": {"wise": {"percent": 0.41, "fixed": "0.26 GBP"}, "barclays": {"spread": "275"}}}`)()
+
+	fees, err := getFees(&feeOptions{})
+	r.NoError(err)
+	r.Nil(fees)
+
+	fees, err = getFees(&feeOptions{Profile: "wise"})
+	r.NoError(err)
+	r.Equal("wise", fees.Name)
+	r.Equal("0.41% + 0.26 GBP", fees.String())
+
+	fees, err = getFees(&feeOptions{Profile: "wise", Percent: "1", Spread: "50"})
+	r.NoError(err)
+	r.Equal("1% + 0.26 GBP + 50 bps spread", fees.String())
+
+	fees, err = getFees(&feeOptions{Profile: "barclays"})
+	r.NoError(err)
+	r.Equal("275 bps spread", fees.String())
+
+	_, err = getFees(&feeOptions{Profile: "monzo"})
+	r.EqualError(err, "unknown fee profile monzo, expected one of barclays, wise")
+
+	_, err = getFees(&feeOptions{Spread: "-1"})
+	r.Error(err)
+}
+
+func TestFixedFeeMustBeInAConvertedCurrency(t *testing.T) {
+	r := require.New(t)
+
+	fees, err := getFees(&feeOptions{Fixed: "1 EUR"})
+	r.NoError(err)
+	r.NoError(validateFixedFee(fees, "GBP", []string{"USD", "EUR"}))
+	r.NoError(validateFixedFee(nil, "GBP", []string{"USD"}))
+	r.EqualError(validateFixedFee(fees, "GBP", []string{"USD"}), "fixed fee 1 EUR must be in GBP or one of the currencies converted to")
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/outbox"
+	"github.com/robotlovesyou/cringletest/rangeclient"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyFrom     string
+	historyTo       string
+	historyInterval string
+)
+
+const defaultHistoryDays = 30
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history [from currency] to [to currency]... [--from 2006-01-02] [--to 2006-01-02] [--interval day|week|month] [--address someone@example.com]",
+	Short: "Show how one or more exchange rates moved over a period",
+	Long: `
+cconv history fetches the exchange rates between one currency and one or more others for every day
+in a period and shows them as a table with the change from one row to the next, along with a sparkline.
+--interval week or month shows only the last rate of each week or month.
+if an email address is supplied the result will be emailed in additon to being reported on the command line.
+
+For example:
+
+cconv history GBP to EUR USD --from 2018-01-01 --to 2018-05-25 --interval week
+
+would show the weekly exchange rates between GBP and both EUR and USD from the 1st of January to the 25th of May 2018.
+Without --to the period ends today and without --from it starts 30 days before it ends.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if _, _, err := parseCurrencyArgs("history", args); err != nil {
+			return err
+		}
+
+		return validateInterval(historyInterval)
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		from, to, _ := parseCurrencyArgs("history", args)
+
+		client, err := getClient()
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		notifiers, err := getNotifiers()
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		start, end, err := getHistoryPeriod(historyFrom, historyTo)
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		err = fetchHistory(context.Background(), &requestConfig{
+			From:      from,
+			To:        to,
+			Start:     start,
+			Date:      end,
+			Interval:  historyInterval,
+			Client:    client,
+			Notifiers: notifiers,
+		})
+		if err != nil {
+			errorResult(err)
+		}
+	},
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historyFrom, "from", "", "The first day of the history")
+	historyCmd.Flags().StringVar(&historyTo, "to", "", "The last day of the history (default today)")
+	historyCmd.Flags().StringVar(&historyInterval, "interval", cringletest.IntervalDay, "Show one rate per day, week or month")
+	rootCmd.AddCommand(historyCmd)
+}
+
+// getHistoryPeriod parses the first and last days of a history. Without a last day the history
+// ends today and without a first day it starts defaultHistoryDays before it ends
+func getHistoryPeriod(first, last string) (start, end time.Time, err error) {
+	if end, err = parseDate(last); err != nil {
+		return start, end, err
+	}
+	if end.IsZero() {
+		end = rangeclient.Day(time.Now())
+	}
+
+	if start, err = parseDate(first); err != nil {
+		return start, end, err
+	}
+	if start.IsZero() {
+		start = end.AddDate(0, 0, -defaultHistoryDays)
+	}
+
+	return start, end, validatePeriod(start, end)
+}
+
+// intervalKey returns a key which is the same for every date in the same interval
+func intervalKey(date time.Time, interval string) string {
+	switch interval {
+	case cringletest.IntervalWeek:
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case cringletest.IntervalMonth:
+		return date.Format("2006-01")
+	}
+	return date.Format("2006-01-02")
+}
+
+// sampleSeries keeps the last entry of series in each interval
+func sampleSeries(series cringletest.RateSeries, interval string) cringletest.RateSeries {
+	sampled := cringletest.RateSeries{}
+	lastKey := ""
+	for _, day := range series {
+		key := intervalKey(day.Date, interval)
+		if key == lastKey {
+			sampled[len(sampled)-1] = day
+			continue
+		}
+		sampled = append(sampled, day)
+		lastKey = key
+	}
+	return sampled
+}
+
+func fetchHistory(ctx context.Context, config *requestConfig) error {
+	if config.Date.IsZero() {
+		config.Date = rangeclient.Day(time.Now())
+	}
+	if config.Start.IsZero() {
+		config.Start = config.Date.AddDate(0, 0, -defaultHistoryDays)
+	}
+	if len(config.Interval) == 0 {
+		config.Interval = cringletest.IntervalDay
+	}
+
+	series, err := rangeclient.New(config.Client, cringletest.RangeConcurrency).GetRange(ctx, config.Start, config.Date, config.From, config.To...)
+	if err != nil {
+		return errors.Wrap(err, "could not get history")
+	}
+
+	if len(series) == 0 {
+		return cringletest.ErrBadCurrencies
+	}
+
+	history := &cringletest.RateHistory{
+		From:     config.From,
+		To:       config.To,
+		Start:    config.Start,
+		End:      config.Date,
+		Interval: config.Interval,
+		Series:   sampleSeries(series, config.Interval),
+	}
+
+	return notifyAll(ctx, config.Notifiers, outbox.History(history))
+}
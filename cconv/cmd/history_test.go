@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/stretchr/testify/require"
+)
+
+func getFetchHistoryArgs(cl cringletest.RateClient, notifiers []cringletest.Notifier) *requestConfig {
+	end := time.Date(2018, 5, 25, 0, 0, 0, 0, time.UTC)
+	return &requestConfig{
+		From:      "ABC",
+		To:        []string{"DEF", "GHI"},
+		Start:     end.AddDate(0, 0, -60),
+		Date:      end,
+		Interval:  cringletest.IntervalDay,
+		Client:    cl,
+		Notifiers: notifiers,
+	}
+}
+
+func TestFetchHistory(t *testing.T) {
+	r := require.New(t)
+	cl, n1, n2 := getClientAndNotifiers(nil, nil, nil)
+
+	err := fetchHistory(context.Background(), getFetchHistoryArgs(cl, []cringletest.Notifier{n1, n2}))
+	r.NoError(err)
+}
+
+func TestFetchHistoryReturnsCorrectClientError(t *testing.T) {
+	r := require.New(t)
+	cl, n1, n2 := getClientAndNotifiers(cringletest.ErrBadAuth, nil, nil)
+
+	err := fetchHistory(context.Background(), getFetchHistoryArgs(cl, []cringletest.Notifier{n1, n2}))
+	r.EqualError(errors.Cause(err), cringletest.ErrBadAuth.Error())
+}
+
+func TestFetchHistoryReturnsCorrectNotifierError(t *testing.T) {
+	r := require.New(t)
+	cl, n1, n2 := getClientAndNotifiers(nil, nil, cringletest.ErrBadAuth)
+
+	err := fetchHistory(context.Background(), getFetchHistoryArgs(cl, []cringletest.Notifier{n1, n2}))
+	r.EqualError(errors.Cause(err), cringletest.ErrBadAuth.Error())
+}
+
+func TestSampleSeriesKeepsLastDayOfEachInterval(t *testing.T) {
+	r := require.New(t)
+
+	series := cringletest.RateSeries{}
+	start := time.Date(2018, 4, 30, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 14; i++ {
+		series = append(series, &cringletest.DatedRates{Date: start.AddDate(0, 0, i)})
+	}
+
+	r.Len(sampleSeries(series, cringletest.IntervalDay), 14)
+
+	weekly := sampleSeries(series, cringletest.IntervalWeek)
+	r.Len(weekly, 2)
+	r.Equal(time.Date(2018, 5, 6, 0, 0, 0, 0, time.UTC), weekly[0].Date)
+	r.Equal(time.Date(2018, 5, 13, 0, 0, 0, 0, time.UTC), weekly[1].Date)
+
+	monthly := sampleSeries(series, cringletest.IntervalMonth)
+	r.Len(monthly, 2)
+	r.Equal(time.Date(2018, 4, 30, 0, 0, 0, 0, time.UTC), monthly[0].Date)
+	r.Equal(time.Date(2018, 5, 13, 0, 0, 0, 0, time.UTC), monthly[1].Date)
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"net/url"
+
+	"github.com/gobuffalo/envy"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/locale"
+)
+
+// getLocale returns the locale from --locale, CCONV_LOCALE or the config file, which is
+// locale.Plain if none of them is set
+func getLocale() (*locale.Locale, error) {
+	tag := localeFlag
+	if len(tag) == 0 {
+		tag = envy.Get(cringletest.LocaleEnvVar, "")
+	}
+	if len(tag) == 0 {
+		config, _, err := loadConfig()
+		if err != nil {
+			return nil, err
+		}
+		tag = config.Locale
+	}
+	return locale.Parse(tag)
+}
+
+// loadLocale makes the notifiers format for the chosen locale
+func loadLocale() error {
+	l, err := getLocale()
+	if err != nil {
+		return err
+	}
+	locale.SetDefault(l)
+	return nil
+}
+
+// localeTarget adds the default locale to the notifier URL target, so that a notification kept
+// in the outbox is formatted the same way whenever it is sent
+func localeTarget(target string) string {
+	l := locale.Default()
+	if l.IsPlain() || len(target) == 0 {
+		return target
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+	q := u.Query()
+	q.Set("locale", l.String())
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/envy"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/locale"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocaleComesFromFlagEnvOrConfig(t *testing.T) {
+	r := require.New(t)
+
+	defer withConfig(r, `{"locale": "fr-FR"}`)()
+
+	l, err := getLocale()
+	r.NoError(err)
+	r.Equal("fr-FR", l.String())
+
+	envy.Temp(func() {
+		envy.Set(cringletest.LocaleEnvVar, "ja-JP")
+		l, err = getLocale()
+		r.NoError(err)
+		r.Equal("ja-JP", l.String())
+	})
+
+	localeFlag = "de"
+	defer func() { localeFlag = "" }()
+	l, err = getLocale()
+	r.NoError(err)
+	r.Equal("de", l.String())
+
+	localeFlag = "pt"
+	_, err = getLocale()
+	r.Error(err)
+}
+
+func TestLocaleTargetKeepsLocale(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("sendgrid:?to=someone%40example.com", localeTarget("sendgrid:?to=someone%40example.com"))
+
+	de, err := locale.Parse("de-DE")
+	r.NoError(err)
+	locale.SetDefault(de)
+	defer locale.SetDefault(locale.Plain)
+
+	target := localeTarget("sendgrid:?to=someone%40example.com")
+	r.Equal("sendgrid:?locale=de-DE&to=someone%40example.com", target)
+	r.Equal("", localeTarget(""))
+}
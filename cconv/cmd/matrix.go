@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/outbox"
+	"github.com/spf13/cobra"
+)
+
+// matrixCmd represents the matrix command
+var matrixCmd = &cobra.Command{
+	Use:   "matrix [currency] [currency]... [--date 2006-01-02] [--address someone@example.com]",
+	Short: "Get the exchange rate between every pair of a basket of currencies",
+	Long: `
+cconv matrix fetches the exchange rate between every pair of two or more currencies, optionally on a
+specific date, and shows them as a table with a row for each currency converted from and a column for
+each currency converted to.
+
+For example:
+
+cconv matrix EUR USD GBP JPY --date 2018-05-25
+
+would get the rates between EUR, USD, GBP and JPY on the 25th of May 2018.
+
+The rates from the first currency to each of the others are fetched in a single call to the provider,
+and every other rate is derived from them, so each rate in the table agrees with the others.
+	`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		_, err := parseMatrixArgs(args)
+		return err
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		currencies, _ := parseMatrixArgs(args)
+
+		client, err := getClient()
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		notifiers, err := getNotifiers()
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		date, err := getTargetDate()
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		err = fetchMatrix(context.Background(), &requestConfig{
+			From:      currencies[0],
+			To:        currencies[1:],
+			Date:      date,
+			Client:    client,
+			Notifiers: notifiers,
+		})
+		if err != nil {
+			errorResult(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(matrixCmd)
+}
+
+// fetchMatrix fetches the rates from config.From to each of config.To and notifies the rates
+// between every pair of them
+func fetchMatrix(ctx context.Context, config *requestConfig) error {
+	rates, err := getRates(ctx, config)
+	if err != nil {
+		return errors.Wrap(err, "could not get rates")
+	}
+
+	matrix, err := cringletest.NewRateMatrix(append([]string{config.From}, config.To...), rates)
+	if err != nil {
+		return errors.Wrap(err, "could not derive the cross rates")
+	}
+
+	return notifyAll(ctx, config.Notifiers, outbox.Matrix(matrix))
+}
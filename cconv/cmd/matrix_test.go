@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/report"
+	"github.com/robotlovesyou/cringletest/testclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMatrixArgs(t *testing.T) {
+	r := require.New(t)
+
+	currencies, err := parseMatrixArgs([]string{"eur", "usd", "EUR", "gbp"})
+	r.NoError(err)
+	r.Equal([]string{"EUR", "USD", "GBP"}, currencies)
+
+	_, err = parseMatrixArgs([]string{"EUR", "eur"})
+	r.EqualError(err, "a matrix needs at least two different currencies")
+	_, err = parseMatrixArgs([]string{"EUR", "GPB"})
+	r.Error(err)
+}
+
+func TestFetchMatrixNotifiesEveryCrossRate(t *testing.T) {
+	r := require.New(t)
+
+	capture := &captureNotifier{}
+	err := fetchMatrix(context.Background(), &requestConfig{
+		From:      "ABC",
+		To:        []string{"DEF", "GHI"},
+		Client:    testclient.New(nil),
+		Notifiers: []cringletest.Notifier{capture},
+	})
+	r.NoError(err)
+
+	r.Equal(report.KindMatrix, capture.doc.Kind)
+	r.Equal([]string{"ABC", "DEF", "GHI"}, capture.doc.Matrix.Currencies)
+	r.Len(capture.doc.Matrix.Rates, 3)
+	r.Len(capture.doc.Rows, 6)
+	r.Equal("DEF", capture.doc.Rows[2].From)
+	r.Equal("ABC", capture.doc.Rows[2].To)
+}
+
+func TestFetchMatrixReturnsCorrectClientError(t *testing.T) {
+	r := require.New(t)
+	cl, n1, n2 := getClientAndNotifiers(cringletest.ErrBadAuth, nil, nil)
+
+	err := fetchMatrix(context.Background(), &requestConfig{
+		From:      "ABC",
+		To:        []string{"DEF"},
+		Client:    cl,
+		Notifiers: []cringletest.Notifier{n1, n2},
+	})
+	r.EqualError(errors.Cause(err), cringletest.ErrBadAuth.Error())
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/outbox"
+	"github.com/spf13/cobra"
+)
+
+var needFlags = &conversionFlags{}
+
+// needCmd represents the need command
+var needCmd = &cobra.Command{
+	Use:   "need 500 [target currency] from [source currency]... [--date 2006-01-02] [--rounding half-even] [--places 2] [--cash] [--fee 0.5] [--fixed-fee 2GBP] [--spread 150] [--fee-profile wise] [--address someone@example.com]",
+	Short: "Get the amount of one or more currencies which must be converted to receive the given amount",
+	Long: `
+cconv need is the reverse of cconv value. It fetches the least amount of each source currency which must be
+converted to receive at least the given amount of the target currency, optionally on a specific date.
+
+For example:
+
+cconv need 500 EUR from GBP USD
+
+would get how much GBP and how much USD must be converted to receive 500 EUR.
+
+The amounts are found by converting them exactly as cconv value would, so they take the same --rounding,
+--places and --cash options into account, along with any fees from --fee, --fixed-fee, --spread and
+--fee-profile. Each amount is the least which can be paid in its currency whose converted amount, once
+rounded and after every fee, is no less than the target.
+
+cconv need 500 EUR from GBP --fee-profile wise
+	`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 4 {
+			return errors.New("not enough args to need")
+		}
+
+		target, sources, err := parseNeedArgs(args[1:])
+		if err != nil {
+			return err
+		}
+
+		_, fees, err := needFlags.parse(target, sources)
+		if err != nil {
+			return err
+		}
+		if err := validateNeedFixedFee(fees, target, sources); err != nil {
+			return err
+		}
+
+		_, err = parseValue(args[0])
+		return err
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		value, _ := parseValue(args[0])
+		target, sources, _ := parseNeedArgs(args[1:])
+		rounding, fees, _ := needFlags.parse(target, sources)
+
+		client, err := getClient()
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		notifiers, err := getNotifiers()
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		date, err := getTargetDate()
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		err = fetchNeed(context.Background(), &requestConfig{
+			From:      target,
+			To:        sources,
+			Date:      date,
+			Client:    client,
+			Notifiers: notifiers,
+			Value:     value,
+			Rounding:  rounding,
+			Fees:      fees,
+		})
+		if err != nil {
+			errorResult(err)
+		}
+	},
+}
+
+func init() {
+	needFlags.add(needCmd)
+	rootCmd.AddCommand(needCmd)
+}
+
+// fetchNeed finds the amount of each of config.To needed to receive config.Value of
+// config.From. The rates are fetched from the target currency, as for every other query, and
+// inverted
+func fetchNeed(ctx context.Context, config *requestConfig) error {
+	rates, err := getRates(ctx, config)
+	if err != nil {
+		return errors.Wrap(err, "could not get rates")
+	}
+
+	inverted := []*cringletest.ExchangeRate{}
+	for _, rate := range rateMapToSlice(rates) {
+		rate, err := rate.Invert()
+		if err != nil {
+			return err
+		}
+		inverted = append(inverted, rate)
+	}
+
+	rounding := config.Rounding
+	if rounding == nil {
+		rounding = cringletest.DefaultRounding
+	}
+	requirements, err := cringletest.Need(cringletest.NewMoney(config.Value, config.From), inverted, rounding, config.Fees)
+	if err != nil {
+		return errors.Wrap(err, "could not work out the amounts needed")
+	}
+
+	return notifyAll(ctx, config.Notifiers, outbox.Need(requirements))
+}
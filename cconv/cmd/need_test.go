@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/report"
+	"github.com/robotlovesyou/cringletest/testclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNeedArgs(t *testing.T) {
+	r := require.New(t)
+
+	target, sources, err := parseNeedArgs([]string{"eur", "from", "gbp", "usd"})
+	r.NoError(err)
+	r.Equal("EUR", target)
+	r.Equal([]string{"GBP", "USD"}, sources)
+
+	_, _, err = parseNeedArgs([]string{"EUR", "to", "GBP"})
+	r.Error(err)
+	_, _, err = parseNeedArgs([]string{"EUR", "from"})
+	r.Error(err)
+	_, _, err = parseNeedArgs([]string{"EUR", "from", "GPB"})
+	r.Error(err)
+}
+
+func TestNeedFixedFeeMustBeChargeableOnEverySource(t *testing.T) {
+	r := require.New(t)
+	defer func(fees feeOptions) { needFlags.fees = fees }(needFlags.fees)
+
+	needFlags.fees = feeOptions{Fixed: "2 GBP"}
+	err := needCmd.Args(needCmd, []string{"500", "EUR", "from", "GBP", "USD"})
+	r.EqualError(err, "fixed fee 2 GBP must be in EUR or in every currency converted from")
+	r.NoError(needCmd.Args(needCmd, []string{"500", "EUR", "from", "GBP"}))
+
+	needFlags.fees = feeOptions{Fixed: "2 EUR"}
+	r.NoError(needCmd.Args(needCmd, []string{"500", "EUR", "from", "GBP", "USD"}))
+}
+
+func TestFetchNeedNotifiesAmountsNeeded(t *testing.T) {
+	r := require.New(t)
+
+	capture := &captureNotifier{}
+	err := fetchNeed(context.Background(), &requestConfig{
+		From:      "ABC",
+		To:        []string{"DEF", "GHI"},
+		Client:    testclient.New(nil),
+		Notifiers: []cringletest.Notifier{capture},
+		Value:     decimal.New(99, 0),
+		Fees:      &cringletest.Fees{Percent: decimal.New(1, 0)},
+	})
+	r.NoError(err)
+
+	r.Equal(report.KindNeed, capture.doc.Kind)
+	r.Equal(&report.Need{Target: "99", Currency: "ABC"}, capture.doc.Need)
+	r.Len(capture.doc.Rows, 2)
+	r.Equal("DEF", capture.doc.Rows[0].From)
+	r.Equal("ABC", capture.doc.Rows[0].To)
+	r.Equal("100.00", capture.doc.Rows[0].Amount)
+	r.Equal("99.00", capture.doc.Rows[0].Converted)
+}
+
+func TestFetchNeedReturnsCorrectClientError(t *testing.T) {
+	r := require.New(t)
+	cl, n1, n2 := getClientAndNotifiers(cringletest.ErrBadAuth, nil, nil)
+
+	err := fetchNeed(context.Background(), &requestConfig{
+		From:      "ABC",
+		To:        []string{"DEF"},
+		Client:    cl,
+		Notifiers: []cringletest.Notifier{n1, n2},
+		Value:     decimal.New(2, 0),
+	})
+	r.EqualError(errors.Cause(err), cringletest.ErrBadAuth.Error())
+}
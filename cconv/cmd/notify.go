@@ -2,43 +2,206 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
-	"github.com/ericlagergren/decimal"
+	"github.com/pkg/errors"
 	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/outbox"
 )
 
-func notify(ctx context.Context, notifier cringletest.Notifier, value *decimal.Big, rates []*cringletest.ExchangeRate, nfunc notifyFunc) chan error {
-	ch := make(chan error)
-	go func() {
-		ch <- nfunc(ctx, notifier, value, rates)
-	}()
-	return ch
+// namedNotifier is a cringletest.Notifier along with the name it is reported by, whether the
+// command fails when it fails and how long it is allowed to take. If it has a queue then its
+// failures are kept there, along with target, the notifier URL it can be built again from
+type namedNotifier struct {
+	cringletest.Notifier
+	name     string
+	required bool
+	timeout  time.Duration
+	queue    *outbox.Outbox
+	target   string
+}
+
+func newNamedNotifier(name string, notifier cringletest.Notifier, required bool) *namedNotifier {
+	return &namedNotifier{
+		Notifier: notifier,
+		name:     name,
+		required: required,
+		timeout:  cringletest.NotifyTimeout,
+	}
+}
+
+// queueTo keeps the failures of n in queue so that they can be sent again to the notifier built
+// from target. Nothing is kept if queue is nil
+func (n *namedNotifier) queueTo(queue *outbox.Outbox, target string) *namedNotifier {
+	n.queue, n.target = queue, target
+	return n
+}
+
+// describeNotifier returns the name, whether it is required and the timeout of the notifier at
+// index i. Notifiers which have not been named are required
+func describeNotifier(i int, notifier cringletest.Notifier) (string, bool, time.Duration) {
+	if named, ok := notifier.(*namedNotifier); ok {
+		return named.name, named.required, named.timeout
+	}
+	return fmt.Sprintf("notifier %d", i+1), true, cringletest.NotifyTimeout
+}
+
+// notifyFailure is the failure of a single notifier. queued is the outbox entry it was kept
+// in, if it was, and queueErr why it could not be kept
+type notifyFailure struct {
+	name     string
+	required bool
+	err      error
+	queued   *outbox.Entry
+	queueErr error
+}
+
+func (f *notifyFailure) String() string {
+	s := fmt.Sprintf("%s: %v", f.name, f.err)
+	if f.queued != nil {
+		s += fmt.Sprintf(" (kept in the outbox as %s)", shortID(f.queued.ID))
+	}
+	if f.queueErr != nil {
+		s += fmt.Sprintf(" (could not keep it in the outbox: %v)", f.queueErr)
+	}
+	return s
+}
+
+// notifyError collects the failures of every notifier which failed
+type notifyError struct {
+	failures []*notifyFailure
+}
+
+func (e *notifyError) Error() string {
+	parts := []string{}
+	for _, failure := range e.failures {
+		parts = append(parts, failure.String())
+	}
+
+	if len(parts) == 1 {
+		return "notifier " + parts[0]
+	}
+	return fmt.Sprintf("%d notifiers failed: %s", len(parts), strings.Join(parts, "; "))
+}
+
+// Cause returns the error of the first required notifier to fail, or the first notifier to fail
+// if none of them were required, so that errors.Cause finds the underlying error
+func (e *notifyError) Cause() error {
+	for _, failure := range e.failures {
+		if failure.required {
+			return failure.err
+		}
+	}
+	return e.failures[0].err
 }
 
-func notifyAll(ctx context.Context, notifiers []cringletest.Notifier, value *decimal.Big, rates []*cringletest.ExchangeRate, nfunc notifyFunc) error {
-	_, ok := ctx.Deadline()
-	var cancel context.CancelFunc
-	if !ok {
-		ctx, cancel = context.WithTimeout(ctx, cringletest.NotifyTimeout)
+// required reports whether any of the notifiers which failed were required
+func (e *notifyError) required() bool {
+	for _, failure := range e.failures {
+		if failure.required {
+			return true
+		}
 	}
-	defer cancel()
+	return false
+}
 
-	channels := []chan error{}
-	for _, notifier := range notifiers {
-		channels = append(channels, notify(ctx, notifier, value, rates, nfunc))
+// requiredFailure returns err unless it only describes the failure of optional notifiers
+func requiredFailure(err error) error {
+	if nerr, ok := err.(*notifyError); ok && !nerr.required() {
+		return nil
 	}
+	return err
+}
 
-	for _, ch := range channels {
+// notify sends notification to notifier in its own goroutine with its own timeout. The channel is
+// buffered so that the goroutine can always exit, even once nobody is waiting for it
+func notify(ctx context.Context, notifier cringletest.Notifier, timeout time.Duration, notification *outbox.Notification) (context.Context, context.CancelFunc, chan error) {
+	cx, cancel := context.WithTimeout(ctx, timeout)
+	ch := make(chan error, 1)
+	go func() {
+		ch <- notification.Send(cx, notifier)
+	}()
+	return cx, cancel, ch
+}
+
+// wait returns the result of a notifier, or the reason its context finished if it does not
+// return in time
+func wait(cx context.Context, ch chan error) error {
+	select {
+	case err := <-ch:
+		return err
+	case <-cx.Done():
+		// prefer a result which arrived at the same moment as the timeout
 		select {
-		case <-ctx.Done():
-			return ctx.Err()
 		case err := <-ch:
-			if err != nil {
-				return err
-			}
+			return err
+		default:
+			return errors.Wrap(cx.Err(), "gave up waiting")
 		}
 	}
-	return nil
 }
 
-type notifyFunc func(context.Context, cringletest.Notifier, *decimal.Big, []*cringletest.ExchangeRate) error
+// retryable reports whether a notifier which failed with err might succeed if it tried again
+func retryable(err error) bool {
+	switch errors.Cause(err) {
+	case cringletest.ErrBadAuth, cringletest.ErrNoAuth, cringletest.ErrNoRates, cringletest.ErrNoRecipient:
+		return false
+	}
+	return true
+}
+
+// keep stores the failure of an optional notifier in its queue, if it has one, so that it can be
+// sent again later. Required notifiers are not kept since their failure fails the command
+func keep(notifier cringletest.Notifier, key string, notification *outbox.Notification, failure *notifyFailure) {
+	named, ok := notifier.(*namedNotifier)
+	if !ok || named.queue == nil || len(named.target) == 0 || failure.required || !retryable(failure.err) {
+		return
+	}
+	failure.queued, failure.queueErr = named.queue.Add(key, named.target, named.name, notification, failure.err)
+}
+
+// notifyAll sends notification to every one of notifiers concurrently and waits for all of them
+// to finish or time out. Each notifier is given its own idempotency key. It returns a
+// *notifyError describing every notifier which failed, or nil if none did
+func notifyAll(ctx context.Context, notifiers []cringletest.Notifier, notification *outbox.Notification) error {
+	type pending struct {
+		cx       context.Context
+		ch       chan error
+		name     string
+		required bool
+		key      string
+	}
+
+	keys := []string{}
+	for range notifiers {
+		key, err := outbox.NewKey()
+		if err != nil {
+			return errors.Wrap(err, "could not create idempotency key")
+		}
+		keys = append(keys, key)
+	}
+
+	waiting := []*pending{}
+	for i, notifier := range notifiers {
+		name, required, timeout := describeNotifier(i, notifier)
+		cx, cancel, ch := notify(cringletest.WithIdempotencyKey(ctx, keys[i]), notifier, timeout, notification)
+		defer cancel()
+		waiting = append(waiting, &pending{cx: cx, ch: ch, name: name, required: required, key: keys[i]})
+	}
+
+	failures := []*notifyFailure{}
+	for i, p := range waiting {
+		if err := wait(p.cx, p.ch); err != nil {
+			failure := &notifyFailure{name: p.name, required: p.required, err: err}
+			keep(notifiers[i], p.key, notification, failure)
+			failures = append(failures, failure)
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	return &notifyError{failures: failures}
+}
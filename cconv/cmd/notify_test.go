@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/outbox"
+	"github.com/robotlovesyou/cringletest/testnotifier"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingNotifier is a cringletest.Notifier which ignores its context and does not return until released
+type blockingNotifier struct {
+	cringletest.Notifier
+	release chan struct{}
+}
+
+func (n *blockingNotifier) NotifyRates(ctx context.Context, rates []*cringletest.ExchangeRate) error {
+	<-n.release
+	return nil
+}
+
+func notifyRates(notifiers ...cringletest.Notifier) error {
+	return notifyAll(context.Background(), notifiers, outbox.Rates(nil))
+}
+
+func TestNotifyAllCollectsEveryFailure(t *testing.T) {
+	r := require.New(t)
+
+	sendFailed := errors.Wrap(cringletest.ErrSendFailed, "mail server said no")
+	err := notifyRates(
+		newNamedNotifier("output", testnotifier.New(nil), true),
+		newNamedNotifier("email", testnotifier.New(sendFailed), false),
+		newNamedNotifier("webhook", testnotifier.New(cringletest.ErrBadAuth), true),
+	)
+	r.Error(err)
+	r.Equal("2 notifiers failed: email: mail server said no: notification failed to send; webhook: client authentication error", err.Error())
+
+	// the cause is the first required failure
+	r.Equal(cringletest.ErrBadAuth, errors.Cause(err))
+	r.Equal(err, requiredFailure(err))
+}
+
+func TestNotifyAllOptionalFailuresAreNotRequired(t *testing.T) {
+	r := require.New(t)
+
+	err := notifyRates(
+		newNamedNotifier("output", testnotifier.New(nil), true),
+		newNamedNotifier("email", testnotifier.New(cringletest.ErrSendFailed), false),
+	)
+	r.EqualError(err, "notifier email: notification failed to send")
+	r.NoError(requiredFailure(err))
+	r.NoError(requiredFailure(nil))
+}
+
+func TestNotifyAllGivesUpOnSlowNotifiers(t *testing.T) {
+	r := require.New(t)
+
+	slow := &blockingNotifier{release: make(chan struct{})}
+	defer close(slow.release)
+
+	named := newNamedNotifier("slow", slow, true)
+	named.timeout = 50 * time.Millisecond
+
+	start := time.Now()
+	err := notifyRates(newNamedNotifier("output", testnotifier.New(nil), true), named)
+	r.True(time.Since(start) < time.Second)
+	r.EqualError(err, "notifier slow: gave up waiting: context deadline exceeded")
+	r.Equal(context.DeadlineExceeded, errors.Cause(err))
+}
+
+func TestNotifyAllKeepsOptionalFailuresInTheOutbox(t *testing.T) {
+	r := require.New(t)
+
+	dir, err := ioutil.TempDir("", "cconv-outbox")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+	queue := outbox.New(dir)
+
+	notifyErr := notifyRates(
+		newNamedNotifier("output", testnotifier.New(nil), true),
+		newNamedNotifier("email", testnotifier.New(cringletest.ErrSendFailed), false).queueTo(queue, "sendgrid:?to=someone%40example.com"),
+		newNamedNotifier("chat", testnotifier.New(cringletest.ErrBadAuth), false).queueTo(queue, "chat+https://chat.example.com/hook"),
+		newNamedNotifier("webhook", testnotifier.New(cringletest.ErrSendFailed), true).queueTo(queue, "webhook+https://example.com/hook"),
+	)
+	r.Error(notifyErr)
+
+	// only the optional failure which might succeed next time is kept
+	entries, err := queue.List()
+	r.NoError(err)
+	r.Len(entries, 1)
+	r.Equal("email", entries[0].Name)
+	r.Equal("sendgrid:?to=someone%40example.com", entries[0].Notifier)
+	r.Contains(notifyErr.Error(), "email: notification failed to send (kept in the outbox as "+shortID(entries[0].ID)+")")
+}
+
+func TestCheckRequiredRejectsUnknownNames(t *testing.T) {
+	r := require.New(t)
+	defer func() { requiredNotifiers = []string{outputNotifierName} }()
+
+	notifiers := []cringletest.Notifier{
+		newNamedNotifier("output", testnotifier.New(nil), true),
+		newNamedNotifier("email", testnotifier.New(nil), false),
+	}
+
+	requiredNotifiers = []string{"output", "email"}
+	r.NoError(checkRequired(notifiers))
+
+	requiredNotifiers = []string{"output", "emial"}
+	r.EqualError(checkRequired(notifiers), "--required emial is not one of the notifiers, expected one of output, email")
+}
+
+func TestWebhooksAreNamedByHost(t *testing.T) {
+	r := require.New(t)
+
+	hooks := []string{"https://a.example.com/hook", "https://b.example.com/one", "https://b.example.com/two"}
+	r.Equal("webhook:a.example.com", webhookName(hooks, 0))
+	r.Equal("webhook:b.example.com#2", webhookName(hooks, 1))
+	r.Equal("webhook:b.example.com#3", webhookName(hooks, 2))
+}
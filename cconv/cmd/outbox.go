@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/gobuffalo/envy"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/outbox"
+	"github.com/spf13/cobra"
+)
+
+var outboxAll bool
+
+// outboxCmd represents the outbox command
+var outboxCmd = &cobra.Command{
+	Use:   "outbox list|flush|drop [id...] [--all]",
+	Short: "List, send or drop notifications which could not be sent",
+	Long: `
+When an optional notifier, such as email with --address, fails with an error which might go away, the
+notification is kept in the outbox so that it is not lost. The outbox is the outbox directory in the cache
+directory, or --outbox-dir or $` + cringletest.OutboxDirEnvVar + `. Use --no-outbox to turn it off.
+
+cconv outbox list shows the notifications waiting in the outbox.
+
+cconv outbox flush tries to send each notification which is due. A notification which fails again waits
+twice as long as last time, from a minute up to six hours, before it is due again. Use --all to try every
+notification now. A notification is sent with the same idempotency key every time, which webhooks receive
+as their delivery id and emails as their Message-ID, so that receivers can spot repeats. Once a notification
+has been sent it is never sent again.
+
+cconv outbox drop removes the notifications with the given ids, or the start of them, without sending them.
+Use --all to drop every notification.
+
+For example:
+
+cconv outbox flush
+
+could be run every few minutes from cron to send anything which is waiting`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("outbox requires one of list, flush or drop")
+		}
+
+		switch args[0] {
+		case "list", "flush":
+			if len(args) != 1 {
+				return fmt.Errorf("outbox %s takes no ids", args[0])
+			}
+		case "drop":
+			if len(args) == 1 && !outboxAll {
+				return errors.New("outbox drop requires the ids to drop or --all")
+			}
+			if len(args) > 1 && outboxAll {
+				return errors.New("outbox drop takes either ids or --all")
+			}
+		default:
+			return fmt.Errorf("unknown outbox operation %s", args[0])
+		}
+
+		return nil
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		queue, err := getOutbox()
+		if err != nil {
+			errorResult(err)
+			return
+		}
+		if queue == nil {
+			fmt.Println("The outbox is turned off by --no-outbox")
+			return
+		}
+
+		switch args[0] {
+		case "list":
+			err = listOutbox(queue)
+		case "flush":
+			// notifications are rendered when they are sent so they use the current templates
+			if err = loadTemplates(); err == nil {
+				err = loadLocale()
+			}
+			if err == nil {
+				err = flushOutbox(context.Background(), queue)
+			}
+		case "drop":
+			err = dropOutbox(queue, args[1:])
+		}
+		if err != nil {
+			fmt.Printf("Cannot %s outbox: %v\n", args[0], err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(outboxCmd)
+	outboxCmd.Flags().BoolVar(&outboxAll, "all", false, "With flush, send every notification whether it is due or not. With drop, drop every notification")
+}
+
+// getOutbox returns the outbox failed notifications are kept in, or nil if --no-outbox is set
+func getOutbox() (*outbox.Outbox, error) {
+	if noOutbox {
+		return nil, nil
+	}
+
+	if len(outboxDir) != 0 {
+		return outbox.New(outboxDir), nil
+	}
+
+	if dir := envy.Get(cringletest.OutboxDirEnvVar, ""); len(dir) != 0 {
+		return outbox.New(dir), nil
+	}
+
+	dir, err := getCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return outbox.New(filepath.Join(dir, "outbox")), nil
+}
+
+// shortID returns enough of an outbox entry id to pick it out
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// redact returns a notifier URL with any password hidden
+func redact(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "[bad url]"
+	}
+	u.Fragment = ""
+	return u.Redacted()
+}
+
+func listOutbox(queue *outbox.Outbox) error {
+	entries, err := queue.List()
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("The outbox is empty")
+		return nil
+	}
+
+	for _, entry := range entries {
+		fmt.Printf("%s  %s for %s (%s)\n", shortID(entry.ID), entry.Notification.Kind, entry.Name, redact(entry.Notifier))
+		fmt.Printf("          created %s, %d attempts, next after %s\n", entry.Created.Local().Format(time.RFC3339), entry.Attempts, entry.NextAttempt.Local().Format(time.RFC3339))
+		if len(entry.LastError) != 0 {
+			fmt.Printf("          last error: %s\n", entry.LastError)
+		}
+	}
+	return nil
+}
+
+// sendEntry sends the notification of entry to the notifier it is for
+func sendEntry(ctx context.Context, entry *outbox.Entry) error {
+	notifier, err := cringletest.NewNotifier(entry.Notifier)
+	if err != nil {
+		return err
+	}
+
+	cx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	return entry.Notification.Send(cx, notifier)
+}
+
+func flushOutbox(ctx context.Context, queue *outbox.Outbox) error {
+	result, err := queue.Flush(ctx, outboxAll, sendEntry)
+	if result != nil {
+		if len(result.Sent)+len(result.Failed)+len(result.Waiting) == 0 {
+			fmt.Println("The outbox is empty")
+		}
+		for _, entry := range result.Sent {
+			fmt.Printf("Sent %s %s to %s\n", shortID(entry.ID), entry.Notification.Kind, entry.Name)
+		}
+		for _, entry := range result.Failed {
+			fmt.Printf("Failed to send %s %s to %s: %s\n", shortID(entry.ID), entry.Notification.Kind, entry.Name, entry.LastError)
+		}
+		if len(result.Waiting) != 0 {
+			fmt.Printf("%d notifications are not due yet, use --all to send them now\n", len(result.Waiting))
+		}
+	}
+	return err
+}
+
+func dropOutbox(queue *outbox.Outbox, ids []string) error {
+	dropped, err := queue.Drop(ids...)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range dropped {
+		fmt.Printf("Dropped %s %s for %s\n", shortID(entry.ID), entry.Notification.Kind, entry.Name)
+	}
+	return nil
+}
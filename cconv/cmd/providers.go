@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/gobuffalo/envy"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/cacheclient"
+	"github.com/robotlovesyou/cringletest/clclient"
+	"github.com/robotlovesyou/cringletest/consensusclient"
+	"github.com/robotlovesyou/cringletest/ecbclient"
+	"github.com/robotlovesyou/cringletest/failoverclient"
+)
+
+// providers holds a constructor for every RateClient which can be selected with --provider
+var providers = map[string]func() (cringletest.RateClient, error){
+	clclient.Name: clclient.New,
+	ecbclient.Name: func() (cringletest.RateClient, error) {
+		return ecbclient.New(), nil
+	},
+}
+
+const defaultProvider = clclient.Name
+
+func providerNames() []string {
+	names := []string{}
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getProviderNames returns the providers to use in priority order from the --provider
+// flag, falling back to the environment and then the default provider
+func getProviderNames() ([]string, error) {
+	names := providerFlag
+	if len(names) == 0 {
+		names = strings.Split(envy.Get(cringletest.ProviderEnvVar, defaultProvider), ",")
+	}
+
+	selected := []string{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 0 {
+			continue
+		}
+		if _, ok := providers[name]; !ok {
+			return nil, fmt.Errorf("unknown provider %s, expected one of %s", name, strings.Join(providerNames(), ", "))
+		}
+		selected = append(selected, name)
+	}
+
+	if len(selected) == 0 {
+		return nil, errors.New("no rate provider selected")
+	}
+	return selected, nil
+}
+
+// getProvider constructs the named provider, wrapped in the cache unless --no-cache is set.
+// Each provider gets its own cache so that rates from different providers are never mixed
+func getProvider(name string) (*cringletest.RateProvider, error) {
+	client, err := providers[name]()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not create provider %s", name)
+	}
+
+	if !noCache {
+		dir, err := getCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		client = cacheclient.New(client, filepath.Join(dir, name), cacheTTL)
+	}
+
+	return &cringletest.RateProvider{Name: name, Client: client}, nil
+}
+
+// getProviders constructs the selected providers in priority order. A provider which cannot be
+// constructed because it has no credentials is skipped as long as another provider is available
+func getProviders() ([]*cringletest.RateProvider, error) {
+	names, err := getProviderNames()
+	if err != nil {
+		return nil, err
+	}
+
+	selected := []*cringletest.RateProvider{}
+	var firstErr error
+	for _, name := range names {
+		provider, err := getProvider(name)
+		if err != nil {
+			if errors.Cause(err) != cringletest.ErrNoAuth {
+				return nil, err
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		selected = append(selected, provider)
+	}
+
+	if len(selected) == 0 {
+		return nil, firstErr
+	}
+	return selected, nil
+}
+
+func getClient() (cringletest.RateClient, error) {
+	selected, err := getProviders()
+	if err != nil {
+		return nil, err
+	}
+
+	if consensus {
+		return consensusclient.New(new(decimal.Big).SetFloat64(maxSpread), selected...), nil
+	}
+
+	if len(selected) == 1 {
+		return selected[0].Client, nil
+	}
+	return failoverclient.New(selected...), nil
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gobuffalo/envy"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/testclient"
+	"github.com/stretchr/testify/require"
+)
+
+// withTestProviders replaces the providers with test clients named abc, def and ghi, where ghi
+// has no credentials, and returns a function which restores them
+func withTestProviders() func() {
+	saved, savedFlag, savedNoCache, savedConsensus := providers, providerFlag, noCache, consensus
+	providers = map[string]func() (cringletest.RateClient, error){
+		"abc": func() (cringletest.RateClient, error) { return testclient.New(nil), nil },
+		"def": func() (cringletest.RateClient, error) { return testclient.New(nil), nil },
+		"ghi": func() (cringletest.RateClient, error) { return nil, cringletest.ErrNoAuth },
+	}
+	noCache = true
+	return func() {
+		providers, providerFlag, noCache, consensus = saved, savedFlag, savedNoCache, savedConsensus
+	}
+}
+
+func TestProviderFlagTakesPriorityOverEnv(t *testing.T) {
+	r := require.New(t)
+
+	envy.Temp(func() {
+		envy.Set(cringletest.ProviderEnvVar, "ecb")
+
+		names, err := getProviderNames()
+		r.NoError(err)
+		r.Equal([]string{"ecb"}, names)
+
+		providerFlag = []string{"ECB", " currencylayer"}
+		defer func() { providerFlag = nil }()
+
+		names, err = getProviderNames()
+		r.NoError(err)
+		r.Equal([]string{"ecb", "currencylayer"}, names)
+	})
+}
+
+func TestProviderDefaultsToCurrencyLayer(t *testing.T) {
+	r := require.New(t)
+
+	envy.Temp(func() {
+		// Map is envy's own copy of the environment while in Temp
+		delete(envy.Map(), cringletest.ProviderEnvVar)
+
+		names, err := getProviderNames()
+		r.NoError(err)
+		r.Equal([]string{defaultProvider}, names)
+		r.Equal("currencylayer", defaultProvider)
+	})
+}
+
+func TestUnknownProviderIsRejected(t *testing.T) {
+	r := require.New(t)
+
+	providerFlag = []string{"ecb", "nosuch"}
+	defer func() { providerFlag = nil }()
+
+	_, err := getProviderNames()
+	r.EqualError(err, "unknown provider nosuch, expected one of currencylayer, ecb")
+}
+
+func TestFailoverKeepsTheGivenOrder(t *testing.T) {
+	r := require.New(t)
+	defer withTestProviders()()
+
+	for _, order := range [][]string{{"abc", "def"}, {"def", "abc"}, {"ghi", "def", "abc"}} {
+		providerFlag = order
+
+		client, err := getClient()
+		r.NoError(err)
+		rates, err := client.Get(context.Background(), "ABC", "DEF")
+		r.NoError(err)
+
+		// the first provider with credentials answers
+		want := order[0]
+		if want == "ghi" {
+			want = order[1]
+		}
+		r.Equal(want, rates["DEF"].Provider)
+	}
+
+	providerFlag = []string{"ghi"}
+	_, err := getClient()
+	r.Equal(cringletest.ErrNoAuth, errors.Cause(err))
+}
+
+func TestConsensusWrapsTheSelectedProviders(t *testing.T) {
+	r := require.New(t)
+	defer withTestProviders()()
+
+	providerFlag = []string{"def", "ghi", "abc"}
+	consensus = true
+
+	client, err := getClient()
+	r.NoError(err)
+	rates, err := client.Get(context.Background(), "ABC", "DEF")
+	r.NoError(err)
+	r.Equal("median of abc, def", rates["DEF"].Provider)
+}
@@ -3,7 +3,10 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/robotlovesyou/cringletest"
 	"github.com/spf13/cobra"
 )
 
@@ -11,24 +14,72 @@ import (
 var (
 	targetDate    string
 	targetAddress string
+	noCache       bool
+	cacheDir      string
+	cacheTTL      time.Duration
+	providerFlag  []string
+	consensus     bool
+	maxSpread     float64
+	outputFormat  string
+	mailerFlag    string
+	webhooks      []string
+	chatWebhook   string
+	notifyURLs    []string
+	configPath    string
+	auditLog      string
+	outboxDir     string
+	noOutbox      bool
+	templateDir   string
+	localeFlag    string
+	// requiredNotifiers are the names of the notifiers which must succeed for a command to succeed
+	requiredNotifiers []string
+	notifyTimeout     time.Duration
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "cconv",
 	Short: "A tool for fetching currency rates and performing currency conversions",
 	Long: `
-cconv has 3 modes of operation.
+cconv fetches currency rates and performs currency conversions. Its main commands are:
+
 1) Returning the exchange rate of a given base currency into one or more target currencies.
 
 > cconv rate EUR to USD GBP CAD
 
 2) Returning a conversion between a value of a given currency and a target currency
 
-> cconv value 123.45 GPB to EUR USD CAD
+> cconv value 123.45 GBP to EUR USD CAD
+
+3) Returning how much of each of several currencies is needed to receive an amount of another
+
+> cconv need 500 EUR from GBP USD
+
+4) Returning the best or worst exchange rate over a window of days, the last 7 by default
+
+> cconv best CAD to EUR --days 30 --until 2018-05-25
+
+5) Showing how exchange rates moved over a period
+
+> cconv history GBP to EUR USD --from 2018-01-01 --to 2018-05-25
+
+6) Tabulating the cross rates between every pair of a basket of currencies
+
+> cconv matrix EUR USD GBP JPY
+
+7) Converting every row of a CSV or JSONL file into a single currency
+
+> cconv convert-file export.csv --to GBP
+
+8) Watching rates and sending an alert when one of a set of rules matches
+
+> cconv watch --rules rules.json
+
+9) Answering the same queries over HTTP
 
-3) Returning the best exchange rate of the last 7 days
+> cconv serve --listen :8080
 
-> cconv best CAD to EUR
+Run cconv help <command> for the details of any command, including those for the cache, outbox,
+audit log, templates and the list of currencies.
 	`,
 }
 
@@ -44,4 +95,23 @@ func Execute() {
 func init() {
 	rootCmd.PersistentFlags().StringVar(&targetAddress, "address", "", "The address to email results to")
 	rootCmd.PersistentFlags().StringVar(&targetDate, "date", "", "Target date for rates and conversions")
+	rootCmd.PersistentFlags().StringSliceVar(&providerFlag, "provider", nil, "The rate providers to use in priority order, one or more of "+strings.Join(providerNames(), ", ")+" (default $"+cringletest.ProviderEnvVar+" or "+defaultProvider+")")
+	rootCmd.PersistentFlags().BoolVar(&consensus, "consensus", false, "Ask every selected provider and use the median rate instead of falling back between them")
+	rootCmd.PersistentFlags().Float64Var(&maxSpread, "max-spread", cringletest.ConsensusThreshold, "With --consensus, warn when providers disagree by more than this many basis points")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Always fetch rates from the provider instead of the local cache")
+	rootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", "", "The directory rates are cached in (default $"+cringletest.CacheDirEnvVar+" or the user cache directory)")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", textOutput, "The format results are written in, one of "+strings.Join(outputFormats(), ", "))
+	rootCmd.PersistentFlags().StringVar(&mailerFlag, "mailer", "", "The service used to email results, sendgrid or smtp (default $"+cringletest.MailerEnvVar+" or sendgrid)")
+	rootCmd.PersistentFlags().StringArrayVar(&webhooks, "webhook", nil, "A URL to POST signed JSON results to. May be repeated")
+	rootCmd.PersistentFlags().StringVar(&chatWebhook, "chat", "", "A Slack or Mattermost incoming webhook URL to post results to (default $"+cringletest.ChatWebhookEnvVar+")")
+	rootCmd.PersistentFlags().StringArrayVar(&notifyURLs, "notify", nil, "A URL of a notifier to send results to, such as smtp://mail.example.com?to=someone@example.com, using one of the schemes "+strings.Join(cringletest.NotifierSchemes(), ", ")+". May be repeated")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "The config file listing notifier URLs (default $"+cringletest.ConfigEnvVar+" or cconv/config.json in the user config directory)")
+	rootCmd.PersistentFlags().StringVar(&auditLog, "audit-log", "", "The JSONL file a record of every result and the rates it used is appended to (default $"+cringletest.AuditLogEnvVar+")")
+	rootCmd.PersistentFlags().StringVar(&outboxDir, "outbox-dir", "", "The directory notifications which could not be sent are kept in (default $"+cringletest.OutboxDirEnvVar+" or outbox in the cache directory)")
+	rootCmd.PersistentFlags().BoolVar(&noOutbox, "no-outbox", false, "Do not keep notifications which could not be sent in the outbox")
+	rootCmd.PersistentFlags().StringVar(&localeFlag, "locale", "", "The locale numbers, money and dates are formatted for, such as de-DE or ja-JP (default $"+cringletest.LocaleEnvVar+" or locale in the config file)")
+	rootCmd.PersistentFlags().StringVar(&templateDir, "template-dir", "", "The directory of templates which override the built in email and console templates (default $"+cringletest.TemplateDirEnvVar+" or templates in the config file)")
+	rootCmd.PersistentFlags().StringSliceVar(&requiredNotifiers, "required", []string{outputNotifierName}, "The notifiers which must succeed, any of output, email, webhook:<host> for a --webhook, chat, audit or the name of a --notify URL, followed by #<position> when names are shared. Failures of the others are reported as warnings")
+	rootCmd.PersistentFlags().DurationVar(&notifyTimeout, "notify-timeout", cringletest.NotifyTimeout, "How long each notifier is given to send the results")
+	rootCmd.PersistentFlags().DurationVar(&cacheTTL, "cache-ttl", cringletest.CacheTTL, "How long live rates are cached for")
 }
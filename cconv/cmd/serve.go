@@ -0,0 +1,384 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/report"
+	"github.com/spf13/cobra"
+)
+
+var serveAddress string
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve [--listen :8080]",
+	Short: "Serve rates, values, best rates and histories over an HTTP JSON API",
+	Long: `
+cconv serve answers the same queries as the rate, value, need, matrix, best and history commands over
+HTTP, using the providers and cache selected by the global flags. Every endpoint accepts GET requests and
+returns a cconv.report/v1 JSON document, the same as --output json, with decimals encoded as strings.
+
+GET /rates?from=GBP&to=EUR,USD[&date=2018-05-25]
+GET /value?value=200&from=GBP&to=EUR&to=CAD[&date=2018-05-25][&rounding=half-up][&places=2][&cash=true][&fee=0.5][&fixed_fee=2GBP][&spread=150][&fee_profile=wise]
+GET /need?value=500&to=EUR&from=GBP,USD[&date=2018-05-25] and the rounding and fee parameters of /value
+GET /matrix?currencies=EUR,USD,GBP,JPY[&date=2018-05-25]
+GET /best?from=GBP&to=EUR[&days=7][&until=2018-05-25][&worst=true][&stats=true]
+GET /history?from=GBP&to=EUR,USD[&start=2018-01-01][&end=2018-05-25][&interval=day|week|month]
+GET /healthz
+
+to may be repeated or comma separated. Errors are returned as {"error": "..."} with a 400 status for a bad
+request or unknown currency, 502 when the rate provider rejects our credentials and 504 when it is too slow.
+The server finishes any requests in flight before stopping on SIGINT or SIGTERM.`,
+	Args: cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := getClient()
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		if err := serve(serveAddress, client); err != nil {
+			errorResult(err)
+		}
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddress, "listen", cringletest.ServeAddress, "The address to listen on")
+	rootCmd.AddCommand(serveCmd)
+}
+
+// serve serves the API on address until the process is interrupted
+func serve(address string, client cringletest.RateClient) error {
+	srv := &http.Server{Addr: address, Handler: newServeHandler(client)}
+
+	done := make(chan error, 1)
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		<-stop
+
+		ctx, cancel := context.WithTimeout(context.Background(), cringletest.ServeShutdownTimeout)
+		defer cancel()
+		done <- srv.Shutdown(ctx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", address)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return <-done
+}
+
+// captureNotifier is a cringletest.Notifier which keeps the report of the last notification
+type captureNotifier struct {
+	doc *report.Document
+}
+
+func (n *captureNotifier) capture(doc *report.Document) error {
+	if len(doc.Rows) == 0 {
+		return cringletest.ErrNoRates
+	}
+	n.doc = doc
+	return nil
+}
+
+func (n *captureNotifier) NotifyRates(ctx context.Context, rates []*cringletest.ExchangeRate) error {
+	return n.capture(report.FromRates(rates))
+}
+
+func (n *captureNotifier) NotifyValue(ctx context.Context, conversions []*cringletest.Conversion) error {
+	return n.capture(report.FromValue(conversions))
+}
+
+func (n *captureNotifier) NotifyBest(ctx context.Context, best *cringletest.BestRate) error {
+	return n.capture(report.FromBest(best))
+}
+
+func (n *captureNotifier) NotifyHistory(ctx context.Context, history *cringletest.RateHistory) error {
+	return n.capture(report.FromHistory(history))
+}
+
+func (n *captureNotifier) NotifyAlert(ctx context.Context, alert *cringletest.Alert) error {
+	return n.capture(report.FromAlert(alert))
+}
+
+func (n *captureNotifier) NotifyNeed(ctx context.Context, requirements []*cringletest.Requirement) error {
+	return n.capture(report.FromNeed(requirements))
+}
+
+func (n *captureNotifier) NotifyMatrix(ctx context.Context, matrix *cringletest.RateMatrix) error {
+	return n.capture(report.FromMatrix(matrix))
+}
+
+// fetchFunc runs a query and notifies the configured notifiers of the result
+type fetchFunc func(context.Context, *requestConfig) error
+
+// parseFunc builds the configuration for a query from the parameters of a request
+type parseFunc func(query queryParams) (*requestConfig, error)
+
+func newServeHandler(client cringletest.RateClient) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.Handle("/rates", queryHandler(client, parseRatesQuery, fetchAndShow))
+	mux.Handle("/value", queryHandler(client, parseValueQuery, fetchAndConvert))
+	mux.Handle("/need", queryHandler(client, parseNeedQuery, fetchNeed))
+	mux.Handle("/matrix", queryHandler(client, parseMatrixQuery, fetchMatrix))
+	mux.Handle("/best", queryHandler(client, parseBestQuery, fetchBest))
+	mux.Handle("/history", queryHandler(client, parseHistoryQuery, fetchHistory))
+	return mux
+}
+
+func queryHandler(client cringletest.RateClient, parse parseFunc, fetch fetchFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeError(w, http.StatusMethodNotAllowed, errors.New("only GET is supported"))
+			return
+		}
+
+		config, err := parse(queryParams(r.URL.Query()))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), cringletest.ServeRequestTimeout)
+		defer cancel()
+
+		capture := &captureNotifier{}
+		config.Client = client
+		config.Notifiers = []cringletest.Notifier{capture}
+		if err := fetch(ctx, config); err != nil {
+			writeError(w, errorStatus(err), err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, capture.doc)
+	})
+}
+
+// errorStatus maps an error from a query onto an http status code
+func errorStatus(err error) int {
+	switch errors.Cause(err) {
+	case cringletest.ErrBadFromCurrency, cringletest.ErrNoToCurrencies, cringletest.ErrBadCurrencies, cringletest.ErrNoRates:
+		return http.StatusBadRequest
+	case cringletest.ErrBadAuth:
+		return http.StatusBadGateway
+	case context.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// queryParams are the parameters of a request
+type queryParams map[string][]string
+
+func (q queryParams) get(name string) string {
+	if values := q[name]; len(values) != 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+// list returns every value of a parameter which may be repeated or comma separated
+func (q queryParams) list(name string) []string {
+	list := []string{}
+	for _, value := range q[name] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); len(item) != 0 {
+				list = append(list, strings.ToUpper(item))
+			}
+		}
+	}
+	return list
+}
+
+func (q queryParams) bool(name string) (bool, error) {
+	value := q.get(name)
+	if len(value) == 0 {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", name)
+	}
+	return b, nil
+}
+
+func (q queryParams) int(name string, fallback int) (int, error) {
+	value := q.get(name)
+	if len(value) == 0 {
+		return fallback, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a whole number", name)
+	}
+	return i, nil
+}
+
+// currencies returns the from and to currencies of a request
+func (q queryParams) currencies() (*requestConfig, error) {
+	config := &requestConfig{From: strings.ToUpper(q.get("from")), To: q.list("to")}
+	return config, validateCurrencies(config.From, config.To)
+}
+
+func parseRatesQuery(q queryParams) (*requestConfig, error) {
+	config, err := q.currencies()
+	if err != nil {
+		return nil, err
+	}
+
+	config.Date, err = parseDate(q.get("date"))
+	return config, err
+}
+
+func parseValueQuery(q queryParams) (*requestConfig, error) {
+	config, err := parseRatesQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.conversion(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// parseNeedQuery parses a query for the amounts of the from currencies needed to receive value of
+// the to currency. Rates are fetched from the target currency, so it is the From of the config
+func parseNeedQuery(q queryParams) (*requestConfig, error) {
+	config := &requestConfig{From: strings.ToUpper(q.get("to")), To: q.list("from")}
+	if len(config.From) == 0 {
+		return nil, errors.New("no \"to\" currency")
+	}
+	if len(config.To) == 0 {
+		return nil, errors.New("no \"from\" currencies")
+	}
+
+	var err error
+	if config.Date, err = parseDate(q.get("date")); err != nil {
+		return nil, err
+	}
+	if err := q.conversion(config); err != nil {
+		return nil, err
+	}
+	return config, validateNeedFixedFee(config.Fees, config.From, config.To)
+}
+
+// parseMatrixQuery parses a query for the rates between every pair of a basket of currencies,
+// which may be repeated or comma separated
+func parseMatrixQuery(q queryParams) (*requestConfig, error) {
+	currencies, err := parseBasket(q.list("currencies"))
+	if err != nil {
+		return nil, err
+	}
+
+	config := &requestConfig{From: currencies[0], To: currencies[1:]}
+	config.Date, err = parseDate(q.get("date"))
+	return config, err
+}
+
+// conversion parses the amount, rounding and fees of a value or need query into config
+func (q queryParams) conversion(config *requestConfig) error {
+	var err error
+	if config.Value, err = parseValue(q.get("value")); err != nil {
+		return err
+	}
+
+	places, err := q.int("places", cringletest.MinorUnits)
+	if err != nil {
+		return err
+	}
+	cash, err := q.bool("cash")
+	if err != nil {
+		return err
+	}
+	if config.Rounding, err = cringletest.ParseRounding(q.get("rounding"), places, cash); err != nil {
+		return err
+	}
+
+	config.Fees, err = getFees(&feeOptions{
+		Profile: q.get("fee_profile"),
+		Percent: q.get("fee"),
+		Fixed:   q.get("fixed_fee"),
+		Spread:  q.get("spread"),
+	})
+	if err != nil {
+		return err
+	}
+	return validateFixedFee(config.Fees, config.From, config.To)
+}
+
+func parseBestQuery(q queryParams) (*requestConfig, error) {
+	config, err := q.currencies()
+	if err != nil {
+		return nil, err
+	}
+	if len(config.To) != 1 {
+		return nil, errBestTargets
+	}
+
+	until := q.get("until")
+	if len(until) == 0 {
+		until = q.get("date")
+	}
+	if config.Date, err = parseDate(until); err != nil {
+		return nil, err
+	}
+
+	if config.Days, err = q.int("days", defaultBestDays); err != nil {
+		return nil, err
+	}
+	if err = validateDays(config.Days); err != nil {
+		return nil, err
+	}
+
+	if config.Worst, err = q.bool("worst"); err != nil {
+		return nil, err
+	}
+	config.Stats, err = q.bool("stats")
+	return config, err
+}
+
+func parseHistoryQuery(q queryParams) (*requestConfig, error) {
+	config, err := q.currencies()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Start, config.Date, err = getHistoryPeriod(q.get("start"), q.get("end")); err != nil {
+		return nil, err
+	}
+
+	config.Interval = q.get("interval")
+	if len(config.Interval) == 0 {
+		config.Interval = cringletest.IntervalDay
+	}
+	return config, validateInterval(config.Interval)
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/report"
+	"github.com/robotlovesyou/cringletest/testclient"
+	"github.com/stretchr/testify/require"
+)
+
+func serveTestRequest(t *testing.T, clientErr error, method, target string) (int, map[string]interface{}) {
+	rec := httptest.NewRecorder()
+	newServeHandler(testclient.New(clientErr)).ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+
+	body := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return rec.Code, body
+}
+
+func TestServeHealthz(t *testing.T) {
+	status, body := serveTestRequest(t, nil, http.MethodGet, "/healthz")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "ok", body["status"])
+}
+
+func TestServeValue(t *testing.T) {
+	r := require.New(t)
+
+	status, body := serveTestRequest(t, nil, http.MethodGet, "/value?value=2.50&from=abc&to=DEF,ghi&to=JKL&date=2018-05-25")
+	r.Equal(http.StatusOK, status)
+	r.Equal(report.Schema, body["schema"])
+	r.Equal(report.KindValue, body["kind"])
+
+	rates := body["rates"].([]interface{})
+	r.Len(rates, 3)
+	first := rates[0].(map[string]interface{})
+	r.Equal("ABC", first["from"])
+	r.Equal("DEF", first["to"])
+	r.Equal("2018-05-25", first["date"])
+	r.Equal("2.50", first["converted"])
+
+	status, body = serveTestRequest(t, nil, http.MethodGet, "/value?value=2.50&from=ABC&to=DEF&rounding=down&places=0")
+	r.Equal(http.StatusOK, status)
+	r.Equal("2", body["rates"].([]interface{})[0].(map[string]interface{})["converted"])
+
+	status, body = serveTestRequest(t, nil, http.MethodGet, "/value?value=2.50&from=ABC&to=DEF&fee=1&fixed_fee=0.50ABC&spread=100")
+	r.Equal(http.StatusOK, status)
+	r.Equal("1% + 0.50 ABC + 100 bps spread", body["fees"])
+	first = body["rates"].([]interface{})[0].(map[string]interface{})
+	r.Equal("2.50", first["gross"])
+	r.Equal("1.96", first["converted"])
+}
+
+func TestServeNeed(t *testing.T) {
+	r := require.New(t)
+
+	status, body := serveTestRequest(t, nil, http.MethodGet, "/need?value=99&to=abc&from=DEF,GHI&fee=1")
+	r.Equal(http.StatusOK, status)
+	r.Equal(report.KindNeed, body["kind"])
+	r.Equal(map[string]interface{}{"target": "99", "currency": "ABC"}, body["need"])
+
+	first := body["rates"].([]interface{})[0].(map[string]interface{})
+	r.Equal("DEF", first["from"])
+	r.Equal("100.00", first["amount"])
+	r.Equal("99.00", first["converted"])
+}
+
+func TestServeMatrix(t *testing.T) {
+	r := require.New(t)
+
+	status, body := serveTestRequest(t, nil, http.MethodGet, "/matrix?currencies=abc,DEF&currencies=GHI,abc")
+	r.Equal(http.StatusOK, status)
+	r.Equal(report.KindMatrix, body["kind"])
+	r.Equal([]interface{}{"ABC", "DEF", "GHI"}, body["matrix"].(map[string]interface{})["currencies"])
+	r.Len(body["rates"], 6)
+}
+
+func TestServeEndpointsAnswer(t *testing.T) {
+	for _, target := range []string{
+		"/rates?from=ABC&to=DEF",
+		"/best?from=ABC&to=DEF&days=3&until=2018-05-25&worst=true&stats=true",
+		"/history?from=ABC&to=DEF,GHI&start=2018-05-01&end=2018-05-25&interval=week",
+	} {
+		status, body := serveTestRequest(t, nil, http.MethodGet, target)
+		require.Equal(t, http.StatusOK, status, target)
+		require.NotEmpty(t, body["rates"], target)
+	}
+}
+
+func TestServeRejectsBadRequests(t *testing.T) {
+	for _, target := range []string{
+		"/rates?from=ABC",
+		"/rates?to=DEF",
+		"/rates?from=ABC&to=DEF&date=25/05/2018",
+		"/value?value=lots&from=ABC&to=DEF",
+		"/value?value=1&from=ABC&to=DEF&rounding=sideways",
+		"/value?value=1&from=ABC&to=DEF&places=two",
+		"/value?value=1&from=ABC&to=DEF&fee=100",
+		"/value?value=1&from=ABC&to=DEF&fixed_fee=2GBP",
+		"/value?value=1&from=ABC&to=DEF&fee_profile=wise",
+		"/need?value=1&from=DEF",
+		"/need?value=1&to=ABC",
+		"/need?value=none&to=ABC&from=DEF",
+		"/need?value=1&to=ABC&from=DEF,GHI&fixed_fee=1DEF",
+		"/matrix?currencies=ABC",
+		"/matrix?currencies=ABC,abc",
+		"/best?from=ABC&to=DEF,GHI",
+		"/best?from=ABC&to=DEF&days=0",
+		"/best?from=ABC&to=DEF&worst=maybe",
+		"/history?from=ABC&to=DEF&interval=year",
+		"/history?from=ABC&to=DEF&start=2018-05-25&end=2018-05-01",
+	} {
+		status, body := serveTestRequest(t, nil, http.MethodGet, target)
+		require.Equal(t, http.StatusBadRequest, status, target)
+		require.NotEmpty(t, body["error"], target)
+	}
+}
+
+func TestServeMapsClientErrors(t *testing.T) {
+	r := require.New(t)
+
+	status, _ := serveTestRequest(t, cringletest.ErrBadFromCurrency, http.MethodGet, "/rates?from=ABC&to=DEF")
+	r.Equal(http.StatusBadRequest, status)
+
+	status, _ = serveTestRequest(t, cringletest.ErrNoToCurrencies, http.MethodGet, "/rates?from=ABC&to=DEF")
+	r.Equal(http.StatusBadRequest, status)
+
+	status, body := serveTestRequest(t, cringletest.ErrBadAuth, http.MethodGet, "/value?value=1&from=ABC&to=DEF")
+	r.Equal(http.StatusBadGateway, status)
+	r.Contains(body["error"], cringletest.ErrBadAuth.Error())
+}
+
+func TestServeOnlyAllowsGet(t *testing.T) {
+	status, _ := serveTestRequest(t, nil, http.MethodPost, "/rates?from=ABC&to=DEF")
+	require.Equal(t, http.StatusMethodNotAllowed, status)
+}
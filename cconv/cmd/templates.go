@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gobuffalo/envy"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/templates"
+	"github.com/spf13/cobra"
+)
+
+// getTemplateDir returns the template directory from --template-dir, CCONV_TEMPLATE_DIR or the
+// config file, or an empty string if there is none
+func getTemplateDir() (string, error) {
+	if len(templateDir) != 0 {
+		return templateDir, nil
+	}
+
+	if dir := envy.Get(cringletest.TemplateDirEnvVar, ""); len(dir) != 0 {
+		return dir, nil
+	}
+
+	config, path, err := loadConfig()
+	if err != nil {
+		return "", err
+	}
+	if len(config.Templates) == 0 || filepath.IsAbs(config.Templates) {
+		return config.Templates, nil
+	}
+	return filepath.Join(filepath.Dir(path), config.Templates), nil
+}
+
+// getTemplates returns the built in templates overridden by those in the template directory
+func getTemplates() (*templates.Set, error) {
+	dir, err := getTemplateDir()
+	if err != nil {
+		return nil, err
+	}
+	if len(dir) == 0 {
+		return templates.Builtin(), nil
+	}
+	return templates.Load(dir)
+}
+
+// loadTemplates makes the notifiers render with the templates in the template directory
+func loadTemplates() error {
+	set, err := getTemplates()
+	if err != nil {
+		return err
+	}
+	templates.SetDefault(set)
+	return nil
+}
+
+// templatesCmd represents the templates command
+var templatesCmd = &cobra.Command{
+	Use:   "templates check [--template-dir dir]",
+	Short: "Check the email and console templates",
+	Long: `
+Emails and console output are rendered from templates. The built in templates can be overridden by
+putting files in a template directory, given by --template-dir, $` + cringletest.TemplateDirEnvVar + ` or templates
+in the config file. Each file is named after the kind of notification (rates, value, best, history or
+alert) and the part it renders (subject, html, text or console), such as best.subject.tmpl. See the
+README for the data each kind is rendered with.
+
+cconv templates check renders every template against sample data and reports each one which fails, so
+that mistakes are caught before a notification is sent.
+
+For example:
+
+cconv templates check --template-dir ~/cconv-templates`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 || args[0] != "check" {
+			return errors.New("templates requires exactly one operation, check")
+		}
+		return nil
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		set, err := getTemplates()
+		if err != nil {
+			failResult("load templates", err)
+		}
+		if err := checkTemplates(os.Stdout, set); err != nil {
+			failResult("check templates", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(templatesCmd)
+}
+
+// checkTemplates writes which templates of set are overridden and any which fail to render to
+// out. It returns an error if any do
+func checkTemplates(out io.Writer, set *templates.Set) error {
+	if len(set.Dir()) == 0 {
+		fmt.Fprintln(out, "Using the built in templates")
+	} else {
+		overridden := set.Overridden()
+		fmt.Fprintf(out, "%s overridden by %s\n", countTemplates(len(overridden)), set.Dir())
+		for _, name := range overridden {
+			fmt.Fprintf(out, "  %s\n", name)
+		}
+	}
+
+	problems := set.Check()
+	for _, problem := range problems {
+		fmt.Fprintf(out, "%v\n", problem)
+	}
+	if len(problems) != 0 {
+		return fmt.Errorf("%s failed", countTemplates(len(problems)))
+	}
+
+	fmt.Fprintln(out, "Every template rendered")
+	return nil
+}
+
+func countTemplates(n int) string {
+	if n == 1 {
+		return "1 template"
+	}
+	return fmt.Sprintf("%d templates", n)
+}
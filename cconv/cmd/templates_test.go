@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robotlovesyou/cringletest/templates"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateDirIsRelativeToConfig(t *testing.T) {
+	r := require.New(t)
+
+	defer withConfig(r, `{"templates": "mine"}`)()
+
+	dir, err := getTemplateDir()
+	r.NoError(err)
+	r.Equal(filepath.Join(filepath.Dir(configPath), "mine"), dir)
+
+	templateDir = "elsewhere"
+	defer func() { templateDir = "" }()
+
+	dir, err = getTemplateDir()
+	r.NoError(err)
+	r.Equal("elsewhere", dir)
+}
+
+func TestCheckTemplates(t *testing.T) {
+	r := require.New(t)
+
+	out := bytes.NewBuffer(nil)
+	r.NoError(checkTemplates(out, templates.Builtin()))
+	r.Equal("Using the built in templates\nEvery template rendered\n", out.String())
+
+	dir, err := ioutil.TempDir("", "cconv-templates")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+	r.NoError(ioutil.WriteFile(filepath.Join(dir, "rates.console.tmpl"), []byte("{{.missing}}"), 0644))
+
+	set, err := templates.Load(dir)
+	r.NoError(err)
+
+	out.Reset()
+	err = checkTemplates(out, set)
+	r.EqualError(err, "1 template failed")
+	r.Contains(out.String(), "1 template overridden by "+dir+"\n  rates.console.tmpl\n")
+	r.Contains(out.String(), "could not render rates.console.tmpl")
+}
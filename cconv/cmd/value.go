@@ -2,19 +2,52 @@ package cmd
 
 import (
 	"context"
-	"fmt"
 	"strings"
 
-	"github.com/ericlagergren/decimal"
 	"github.com/pkg/errors"
 	"github.com/robotlovesyou/cringletest"
-	"github.com/robotlovesyou/cringletest/clclient"
+	"github.com/robotlovesyou/cringletest/outbox"
 	"github.com/spf13/cobra"
 )
 
+// conversionFlags are the rounding and fee flags of the commands which convert amounts
+type conversionFlags struct {
+	rounding string
+	places   int
+	cash     bool
+	fees     feeOptions
+}
+
+// add adds the flags to cmd
+func (f *conversionFlags) add(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.rounding, "rounding", "half-even", "How converted amounts are rounded, one of "+strings.Join(cringletest.RoundingModes(), ", "))
+	cmd.Flags().IntVar(&f.places, "places", cringletest.MinorUnits, "The number of decimal places converted amounts are rounded to, or -1 for the minor unit of each currency")
+	cmd.Flags().BoolVar(&f.cash, "cash", false, "Round converted amounts to the smallest coin of currencies which do not use their minor unit in cash, such as CHF 0.05")
+	cmd.Flags().StringVar(&f.fees.Percent, "fee", "", "A percentage of the amount charged as a fee, such as 0.5")
+	cmd.Flags().StringVar(&f.fees.Fixed, "fixed-fee", "", "A fixed fee charged on every conversion, such as \"2.50 GBP\"")
+	cmd.Flags().StringVar(&f.fees.Spread, "spread", "", "How far below the mid-market rate the rate given is, in basis points, such as 150")
+	cmd.Flags().StringVar(&f.fees.Profile, "fee-profile", "", "The name of a fee profile in the config file, such as wise")
+}
+
+// parse returns the rounding and fees asked for by the flags for converting from to each of to
+func (f *conversionFlags) parse(from string, to []string) (*cringletest.Rounding, *cringletest.Fees, error) {
+	rounding, err := cringletest.ParseRounding(f.rounding, f.places, f.cash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fees, err := getFees(&f.fees)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rounding, fees, validateFixedFee(fees, from, to)
+}
+
+var valueFlags = &conversionFlags{}
+
 // valueCmd represents the value command
 var valueCmd = &cobra.Command{
-	Use:   "value 1.234 [from currency] to [to currency]... [--date 2006-02-01] [--address someone@example.com]",
+	Use:   "value 1.234 [from currency] to [to currency]... [--date 2006-01-02] [--rounding half-even] [--places 2] [--cash] [--fee 0.5] [--fixed-fee 2GBP] [--spread 150] [--fee-profile wise] [--address someone@example.com]",
 	Short: "Get the value of the given amount when converted to one or more target currencies",
 	Long: `
 cconv value fetches the value of the given amount of one currency when converted to one or more currencies, optionally with a specific date.
@@ -24,32 +57,47 @@ For example:
 
 cconv value 200 GBP to EUR CAD --date 2018-05-25 --address someone@example.com
 
-would get result of converting 200 GPB to both EUR and CAD on the 25th of May 2018. It would mail the result to someone@example.com
+would get result of converting 200 GBP to both EUR and CAD on the 25th of May 2018. It would mail the result to someone@example.com
+
+Converted amounts are rounded once, half to even at the minor unit of their currency, so that every
+notifier reports exactly the same amounts. --rounding chooses how to round, --places rounds to a fixed
+number of decimal places instead and --cash rounds to the smallest coin of currencies such as CHF, whose
+cash amounts are multiples of 0.05.
+
+cconv value 1000 EUR to CHF --rounding half-up --cash
+
+Fees charged by a bank or money transfer service can be taken into account. --fee is a percentage of the
+amount, --fixed-fee is charged on every conversion in the currency converted from or to, and --spread is
+how far below the mid-market rate the rate given is, in basis points. --fee-profile uses a named set of
+fees from the config file, and any fee flags override it. Each target then shows the gross amount, the
+fees, the net amount received and the effective rate.
+
+cconv value 1000 GBP to EUR --fee 0.5 --fixed-fee "2 GBP" --spread 150
+cconv value 1000 GBP to EUR --fee-profile wise
 	`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		if len(args) < 4 {
 			return errors.New("not enough args to value")
 		}
 
-		if args[2] != "to" {
-			return errors.New("incorrect argument format")
+		from, to, err := parseCurrencyArgs("value", args[1:])
+		if err != nil {
+			return err
 		}
 
-		if _, ok := new(decimal.Big).SetString(args[0]); !ok {
-			return fmt.Errorf("%s cannot be formatted as a number", args[0])
+		if _, _, err := valueFlags.parse(from, to); err != nil {
+			return err
 		}
 
-		return nil
+		_, err = parseValue(args[0])
+		return err
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		value, _ := new(decimal.Big).SetString(args[0])
-		from := strings.ToUpper(args[1])
-		to := []string{}
-		for _, cur := range args[3:] {
-			to = append(to, strings.ToUpper(cur))
-		}
+		value, _ := parseValue(args[0])
+		from, to, _ := parseCurrencyArgs("value", args[1:])
+		rounding, fees, _ := valueFlags.parse(from, to)
 
-		client, err := clclient.New()
+		client, err := getClient()
 		if err != nil {
 			errorResult(err)
 			return
@@ -74,6 +122,8 @@ would get result of converting 200 GPB to both EUR and CAD on the 25th of May 20
 			Client:    client,
 			Notifiers: notifiers,
 			Value:     value,
+			Rounding:  rounding,
+			Fees:      fees,
 		})
 		if err != nil {
 			errorResult(err)
@@ -82,6 +132,7 @@ would get result of converting 200 GPB to both EUR and CAD on the 25th of May 20
 }
 
 func init() {
+	valueFlags.add(valueCmd)
 	rootCmd.AddCommand(valueCmd)
 }
 
@@ -91,9 +142,14 @@ func fetchAndConvert(ctx context.Context, config *requestConfig) error {
 		return errors.Wrap(err, "could not get values")
 	}
 
-	nfunc := func(cx context.Context, n cringletest.Notifier, value *decimal.Big, rates []*cringletest.ExchangeRate) error {
-		return n.NotifyValue(cx, value, rates)
+	rounding := config.Rounding
+	if rounding == nil {
+		rounding = cringletest.DefaultRounding
+	}
+	conversions, err := cringletest.ConvertWithFees(cringletest.NewMoney(config.Value, config.From), rateMapToSlice(rates), rounding, config.Fees)
+	if err != nil {
+		return errors.Wrap(err, "could not convert values")
 	}
 
-	return notifyAll(ctx, config.Notifiers, config.Value, rateMapToSlice(rates), nfunc)
+	return notifyAll(ctx, config.Notifiers, outbox.Value(conversions))
 }
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/outbox"
+	"github.com/robotlovesyou/cringletest/watch"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchRules    string
+	watchInterval time.Duration
+	watchState    string
+	watchOnce     bool
+)
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch --rules rules.json [--interval 5m] [--state file] [--once] [--address someone@example.com]",
+	Short: "Watch exchange rates and send an alert when they match a set of rules",
+	Long: `
+cconv watch checks the current exchange rates against a set of rules every --interval and sends an alert
+through the usual notifications whenever a rule matches. The rules are read from a JSON file like this:
+
+{
+  "rules": [
+    {"name": "gbp-eur-high", "from": "GBP", "to": "EUR", "above": 1.15, "cooldown": "6h"},
+    {"name": "gbp-eur-low", "from": "GBP", "to": "EUR", "below": 1.10},
+    {"name": "usd-jpy-move", "from": "USD", "to": "JPY", "change_percent": 1},
+    {"name": "eur-cad-high", "from": "EUR", "to": "CAD", "high_days": 30},
+    {"name": "eur-cad-low", "from": "EUR", "to": "CAD", "low_days": 30}
+  ]
+}
+
+Each rule has exactly one of above, below, change_percent (a move in either direction since yesterday),
+high_days or low_days (higher or lower than on any of that many previous days). Once a rule has fired it
+waits for its cooldown, an hour unless given, before it can fire again. When each rule last fired is kept
+in the --state file so that cooldowns survive a restart.
+
+For example:
+
+cconv watch --rules rules.json --interval 10m --address someone@example.com
+
+would check the rules every 10 minutes and mail any alerts to someone@example.com`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(watchRules) == 0 {
+			return errors.New("--rules is required")
+		}
+
+		if watchInterval < time.Second {
+			return errors.New("--interval must be at least 1s")
+		}
+
+		return nil
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		rules, err := watch.LoadRules(watchRules)
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		client, err := getClient()
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		notifiers, err := getNotifiers()
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		statePath, err := getWatchStatePath()
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		w, err := watch.New(client, rules, statePath, alertNotifier(notifiers))
+		if err != nil {
+			errorResult(err)
+			return
+		}
+
+		if watchOnce {
+			if _, err := w.Check(context.Background()); err != nil {
+				errorResult(err)
+			}
+			return
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			stop := make(chan os.Signal, 1)
+			signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+			<-stop
+			cancel()
+		}()
+
+		if err := w.Run(ctx, watchInterval); err != nil {
+			errorResult(err)
+		}
+	},
+}
+
+func init() {
+	watchCmd.Flags().StringVar(&watchRules, "rules", "", "The JSON file containing the rules to watch")
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", cringletest.WatchInterval, "The time between checks of the rules")
+	watchCmd.Flags().StringVar(&watchState, "state", "", "The file the time each rule last fired is kept in (default watch-state.json in the cache directory)")
+	watchCmd.Flags().BoolVar(&watchOnce, "once", false, "Check the rules once and exit")
+	rootCmd.AddCommand(watchCmd)
+}
+
+func getWatchStatePath() (string, error) {
+	if len(watchState) != 0 {
+		return watchState, nil
+	}
+
+	dir, err := getCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "watch-state.json"), nil
+}
+
+// alertNotifier returns a watch.NotifyFunc which sends alerts to every one of notifiers
+func alertNotifier(notifiers []cringletest.Notifier) watch.NotifyFunc {
+	return func(ctx context.Context, alert *cringletest.Alert) error {
+		err := notifyAll(ctx, notifiers, outbox.Alert(alert))
+		if err != nil && requiredFailure(err) == nil {
+			// the alert was delivered everywhere it had to be so it should not be sent again
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		return requiredFailure(err)
+	}
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestAlert() *cringletest.Alert {
+	return &cringletest.Alert{
+		Rule:    "high",
+		Message: "ABC to DEF is 1.1600, above 1.15",
+		Rate:    &cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: time.Now(), Value: decimal.New(116, 2)},
+		Fired:   time.Now(),
+	}
+}
+
+func TestAlertNotifier(t *testing.T) {
+	_, n1, n2 := getClientAndNotifiers(nil, nil, nil)
+
+	err := alertNotifier([]cringletest.Notifier{n1, n2})(context.Background(), getTestAlert())
+	require.NoError(t, err)
+}
+
+func TestAlertNotifierReturnsCorrectNotifierError(t *testing.T) {
+	_, n1, n2 := getClientAndNotifiers(nil, nil, cringletest.ErrBadAuth)
+
+	err := alertNotifier([]cringletest.Notifier{n1, n2})(context.Background(), getTestAlert())
+	require.EqualError(t, errors.Cause(err), cringletest.ErrBadAuth.Error())
+}
@@ -0,0 +1,354 @@
+// Package chatnotifier implements the cringletest.Notifier interface by posting messages to a
+// Slack or Mattermost compatible incoming webhook
+package chatnotifier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/locale"
+	"github.com/robotlovesyou/cringletest/templates"
+	"gopkg.in/resty.v1"
+)
+
+const dateFormat = "Mon 02 Jan 2006"
+
+const alertTimeFormat = "Mon 02 Jan 2006 15:04 MST"
+
+const (
+	// sectionFields is the most fields a section block may hold
+	sectionFields = 10
+	// messageFields is the most fields sent in a single message. It keeps each message well inside
+	// the limits of 50 blocks and 40,000 characters of text
+	messageFields = 50
+	// headerLength is the most characters a header block may hold
+	headerLength = 150
+)
+
+// text is a Block Kit text object
+type text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func plain(s string) *text {
+	return &text{Type: "plain_text", Text: s}
+}
+
+func markdown(s string) *text {
+	return &text{Type: "mrkdwn", Text: s}
+}
+
+// block is a Block Kit header, section or context block
+type block struct {
+	Type     string  `json:"type"`
+	Text     *text   `json:"text,omitempty"`
+	Fields   []*text `json:"fields,omitempty"`
+	Elements []*text `json:"elements,omitempty"`
+}
+
+// message is the payload of an incoming webhook. Text is shown by clients which do not
+// understand blocks and in notifications
+type message struct {
+	Text   string   `json:"text"`
+	Blocks []*block `json:"blocks"`
+}
+
+// field is a single entry in the grid of a message
+type field struct {
+	title string
+	value string
+}
+
+// content is everything to be posted for one notification, before it is split into messages
+type content struct {
+	header  string
+	summary string
+	fields  []*field
+	context string
+}
+
+type notifier struct {
+	url       string
+	maxFields int
+}
+
+// New returns a new cringletest.Notifier which will post to the incoming webhook at webhookURL
+func New(webhookURL string) (cringletest.Notifier, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "bad chat webhook url %s", webhookURL)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return nil, fmt.Errorf("chat webhook url %s must be an absolute http or https url", u.Redacted())
+	}
+	return &notifier{url: u.String(), maxFields: messageFields}, nil
+}
+
+func init() {
+	// chat+https://hooks.slack.com/services/... posts to https://hooks.slack.com/services/...
+	factory := func(u *url.URL) (cringletest.Notifier, error) {
+		target := *u
+		target.Scheme = strings.TrimPrefix(u.Scheme, "chat+")
+		return New(target.String())
+	}
+	cringletest.RegisterNotifier("chat+http", factory)
+	cringletest.RegisterNotifier("chat+https", factory)
+}
+
+func truncate(s string, length int) string {
+	runes := []rune(s)
+	if len(runes) <= length {
+		return s
+	}
+	return string(runes[:length-1]) + "…"
+}
+
+// messages splits c into as many messages as it takes to stay within the limits of a single
+// message. Every message repeats the header, numbered if there is more than one, and the context
+func (n *notifier) messages(c *content) []*message {
+	chunks := [][]*field{}
+	for start := 0; start < len(c.fields); start += n.maxFields {
+		end := start + n.maxFields
+		if end > len(c.fields) {
+			end = len(c.fields)
+		}
+		chunks = append(chunks, c.fields[start:end])
+	}
+	if len(chunks) == 0 {
+		chunks = append(chunks, nil)
+	}
+
+	messages := []*message{}
+	for i, chunk := range chunks {
+		header := c.header
+		if len(chunks) > 1 {
+			header = fmt.Sprintf("%s (%d/%d)", header, i+1, len(chunks))
+		}
+
+		msg := &message{Blocks: []*block{{Type: "header", Text: plain(truncate(header, headerLength))}}}
+		lines := []string{header}
+		if len(c.summary) != 0 && i == 0 {
+			msg.Blocks = append(msg.Blocks, &block{Type: "section", Text: markdown(c.summary)})
+			lines = append(lines, c.summary)
+		}
+
+		for start := 0; start < len(chunk); start += sectionFields {
+			end := start + sectionFields
+			if end > len(chunk) {
+				end = len(chunk)
+			}
+
+			section := &block{Type: "section"}
+			for _, f := range chunk[start:end] {
+				section.Fields = append(section.Fields, markdown(fmt.Sprintf("*%s*\n%s", f.title, f.value)))
+				lines = append(lines, fmt.Sprintf("%s: %s", f.title, strings.Replace(f.value, "\n", " ", -1)))
+			}
+			msg.Blocks = append(msg.Blocks, section)
+		}
+
+		if len(c.context) != 0 {
+			msg.Blocks = append(msg.Blocks, &block{Type: "context", Elements: []*text{markdown(c.context)}})
+			lines = append(lines, c.context)
+		}
+
+		msg.Text = strings.Join(lines, "\n")
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+func (n *notifier) post(ctx context.Context, msg *message) error {
+	resp, err := resty.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(msg).
+		Post(n.url)
+	if err != nil {
+		return errors.Wrap(err, "could not post to chat")
+	}
+
+	switch code := resp.StatusCode(); {
+	case code >= 200 && code < 300:
+		return nil
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return cringletest.ErrBadAuth
+	}
+	return fmt.Errorf("chat rejected the message with %s: %s", resp.Status(), strings.TrimSpace(resp.String()))
+}
+
+// send posts each of the messages for c in turn so that they arrive in order
+func (n *notifier) send(ctx context.Context, c *content) error {
+	for _, msg := range n.messages(c) {
+		if err := n.post(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pair(rate *cringletest.ExchangeRate) string {
+	return fmt.Sprintf("%s → %s", rate.From, rate.To)
+}
+
+func rateField(original string, rate *cringletest.ExchangeRate, converted string) *field {
+	f := &field{
+		title: pair(rate),
+		value: fmt.Sprintf("%s %s = %s %s", original, rate.From, converted, rate.To),
+	}
+	if len(rate.Warning) != 0 {
+		f.value += fmt.Sprintf("\n:warning: %s", rate.Warning)
+	}
+	return f
+}
+
+// unitField is the field for what one unit buys at rate
+func unitField(rate *cringletest.ExchangeRate) *field {
+	return rateField("1.0000", rate, fmt.Sprintf("%.4f", rate.Value))
+}
+
+// money formats an amount with four decimal places, or more if it was rounded to more
+func money(m *cringletest.Money) string {
+	places := 4
+	if scale := m.Amount.Scale(); scale > places {
+		places = scale
+	}
+	return fmt.Sprintf("%.*f", places, m.Amount)
+}
+
+func providedBy(rate *cringletest.ExchangeRate) string {
+	if len(rate.Provider) == 0 {
+		return ""
+	}
+	return "Rates provided by " + rate.Provider
+}
+
+func (n *notifier) notifyList(ctx context.Context, header string, rates []*cringletest.ExchangeRate, fields []*field) error {
+	if len(rates) == 0 {
+		return cringletest.ErrNoRates
+	}
+
+	c := &content{
+		header:  fmt.Sprintf("%s on %s", header, rates[0].Date.Format(dateFormat)),
+		context: providedBy(rates[0]),
+		fields:  fields,
+	}
+	return n.send(ctx, c)
+}
+
+func (n *notifier) NotifyRates(ctx context.Context, rates []*cringletest.ExchangeRate) error {
+	fields := []*field{}
+	for _, rate := range rates {
+		fields = append(fields, unitField(rate))
+	}
+	return n.notifyList(ctx, "Exchange rates", rates, fields)
+}
+
+// conversionFields returns the rates of conversions and a field for each, with any fees paid
+func conversionFields(conversions []*cringletest.Conversion) ([]*cringletest.ExchangeRate, []*field) {
+	rates, fields := []*cringletest.ExchangeRate{}, []*field{}
+	for _, conversion := range conversions {
+		rates = append(rates, conversion.Rate)
+		f := rateField(money(conversion.Amount), conversion.Rate, money(conversion.Converted))
+		if charges := conversion.Charges; charges != nil {
+			f.value += fmt.Sprintf("\nGross %s %s, fees %s %s, effective rate %.4f",
+				money(charges.Gross), charges.Gross.Currency, money(charges.Charged), charges.Charged.Currency, charges.EffectiveRate)
+		}
+		fields = append(fields, f)
+	}
+	return rates, fields
+}
+
+func (n *notifier) NotifyValue(ctx context.Context, conversions []*cringletest.Conversion) error {
+	rates, fields := conversionFields(conversions)
+	return n.notifyList(ctx, "Currency conversions", rates, fields)
+}
+
+func (n *notifier) NotifyNeed(ctx context.Context, requirements []*cringletest.Requirement) error {
+	if len(requirements) == 0 {
+		return cringletest.ErrNoRates
+	}
+
+	conversions := []*cringletest.Conversion{}
+	for _, requirement := range requirements {
+		conversions = append(conversions, requirement.Conversion)
+	}
+	rates, fields := conversionFields(conversions)
+	target := requirements[0].Target
+	return n.notifyList(ctx, fmt.Sprintf("Amounts needed to receive %s %s", money(target), target.Currency), rates, fields)
+}
+
+func (n *notifier) NotifyMatrix(ctx context.Context, matrix *cringletest.RateMatrix) error {
+	rates := matrix.CrossRates()
+	fields := []*field{}
+	for _, rate := range rates {
+		fields = append(fields, unitField(rate))
+	}
+	return n.notifyList(ctx, "Cross rates of "+strings.Join(matrix.Currencies, ", "), rates, fields)
+}
+
+func (n *notifier) NotifyBest(ctx context.Context, best *cringletest.BestRate) error {
+	kind := "Best"
+	if best.Worst {
+		kind = "Worst"
+	}
+
+	c := &content{
+		header:  fmt.Sprintf("%s exchange rate in %s", kind, templates.DescribeWindow(locale.Plain, best)),
+		fields:  []*field{unitField(best.Rate)},
+		context: fmt.Sprintf("On %s", best.Rate.Date.Format(dateFormat)),
+	}
+	if provider := providedBy(best.Rate); len(provider) != 0 {
+		c.context += " · " + provider
+	}
+
+	if stats := best.Stats; stats != nil {
+		c.fields = append(c.fields,
+			&field{title: "Range", value: fmt.Sprintf("%.4f to %.4f", stats.Min, stats.Max)},
+			&field{title: "Mean / median", value: fmt.Sprintf("%.4f / %.4f", stats.Mean, stats.Median)},
+			&field{title: "Standard deviation", value: fmt.Sprintf("%.6f", stats.StdDev)},
+			&field{title: "Best/worst gap", value: fmt.Sprintf("%.2f%% over %d rates", stats.Gap, stats.Count)},
+		)
+	}
+	return n.send(ctx, c)
+}
+
+func (n *notifier) NotifyHistory(ctx context.Context, history *cringletest.RateHistory) error {
+	c := &content{
+		header:  fmt.Sprintf("Exchange rate history of %s", history.From),
+		context: fmt.Sprintf("%s to %s by %s", history.Start.Format(dateFormat), history.End.Format(dateFormat), history.Interval),
+	}
+
+	for _, currency := range history.To {
+		rates := history.Rates(currency)
+		if len(rates) == 0 {
+			continue
+		}
+
+		first, last := rates[0], rates[len(rates)-1]
+		value := fmt.Sprintf("%.4f → %.4f", first.Value, last.Value)
+		if percent := cringletest.PercentChange(first.Value, last.Value); percent != nil {
+			value += fmt.Sprintf(" (%+.2f%%)", percent)
+		}
+		c.fields = append(c.fields, &field{title: pair(last), value: value})
+	}
+
+	if len(c.fields) == 0 {
+		return cringletest.ErrNoRates
+	}
+	return n.send(ctx, c)
+}
+
+func (n *notifier) NotifyAlert(ctx context.Context, alert *cringletest.Alert) error {
+	return n.send(ctx, &content{
+		header:  fmt.Sprintf("Exchange rate alert: %s", alert.Rule),
+		summary: alert.Message,
+		fields:  []*field{unitField(alert.Rate)},
+		context: fmt.Sprintf("Fired at %s", alert.Fired.Format(alertTimeFormat)),
+	})
+}
@@ -0,0 +1,294 @@
+package chatnotifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/stretchr/testify/require"
+)
+
+var testDate = time.Date(2018, 5, 25, 0, 0, 0, 0, time.UTC)
+
+// testServer is a stand-in for an incoming webhook which records every message posted to it
+type testServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	messages []*message
+	status   int
+}
+
+func newTestServer(status int) *testServer {
+	s := &testServer{status: status}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		msg := &message{}
+		if err := json.NewDecoder(req.Body).Decode(msg); err != nil {
+			http.Error(w, "invalid_payload", http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		s.messages = append(s.messages, msg)
+		s.mu.Unlock()
+
+		w.WriteHeader(s.status)
+		fmt.Fprint(w, "ok")
+	}))
+	return s
+}
+
+func (s *testServer) received() []*message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*message{}, s.messages...)
+}
+
+func getTestNotifier(r *require.Assertions, server *testServer) cringletest.Notifier {
+	sender, err := New(server.URL)
+	r.NoError(err)
+	return sender
+}
+
+func testRates(count int) []*cringletest.ExchangeRate {
+	rates := []*cringletest.ExchangeRate{}
+	for i := 0; i < count; i++ {
+		rates = append(rates, &cringletest.ExchangeRate{
+			From:     "ABC",
+			To:       fmt.Sprintf("D%02d", i),
+			Date:     testDate,
+			Value:    decimal.New(1234, 3),
+			Provider: "ecb",
+		})
+	}
+	return rates
+}
+
+func TestNotifyRatesPostsBlocks(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusOK)
+	defer server.Close()
+
+	rates := testRates(2)
+	rates[1].Warning = "providers disagree"
+	r.NoError(getTestNotifier(r, server).NotifyRates(context.Background(), rates))
+
+	messages := server.received()
+	r.Len(messages, 1)
+
+	blocks := messages[0].Blocks
+	r.Len(blocks, 3)
+	r.Equal("header", blocks[0].Type)
+	r.Equal(plain("Exchange rates on Fri 25 May 2018"), blocks[0].Text)
+	r.Equal("section", blocks[1].Type)
+	r.Equal([]*text{
+		markdown("*ABC → D00*\n1.0000 ABC = 1.2340 D00"),
+		markdown("*ABC → D01*\n1.0000 ABC = 1.2340 D01\n:warning: providers disagree"),
+	}, blocks[1].Fields)
+	r.Equal("context", blocks[2].Type)
+	r.Equal([]*text{markdown("Rates provided by ecb")}, blocks[2].Elements)
+
+	r.Equal("Exchange rates on Fri 25 May 2018\n"+
+		"ABC → D00: 1.0000 ABC = 1.2340 D00\n"+
+		"ABC → D01: 1.0000 ABC = 1.2340 D01 :warning: providers disagree\n"+
+		"Rates provided by ecb", messages[0].Text)
+}
+
+func TestLongRateListsAreSplit(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusOK)
+	defer server.Close()
+
+	conversions, err := cringletest.Convert(cringletest.NewMoney(decimal.New(2, 0), "ABC"), testRates(messageFields+15), cringletest.DefaultRounding)
+	r.NoError(err)
+	r.NoError(getTestNotifier(r, server).NotifyValue(context.Background(), conversions))
+
+	messages := server.received()
+	r.Len(messages, 2)
+
+	first := messages[0].Blocks
+	r.Equal("Currency conversions on Fri 25 May 2018 (1/2)", first[0].Text.Text)
+	// a header, 5 full sections and the context
+	r.Len(first, 7)
+	for _, section := range first[1:6] {
+		r.Len(section.Fields, sectionFields)
+	}
+	r.Equal("*ABC → D00*\n2.0000 ABC = 2.4700 D00", first[1].Fields[0].Text)
+
+	second := messages[1].Blocks
+	r.Equal("Currency conversions on Fri 25 May 2018 (2/2)", second[0].Text.Text)
+	r.Len(second, 4)
+	r.Len(second[1].Fields, 10)
+	r.Len(second[2].Fields, 5)
+	r.Equal("*ABC → D64*\n2.0000 ABC = 2.4700 D64", second[2].Fields[4].Text)
+	r.Equal("context", second[3].Type)
+}
+
+func TestNotifyBestHasDateContext(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusOK)
+	defer server.Close()
+
+	rates := []*cringletest.ExchangeRate{
+		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: testDate, Value: decimal.New(2, 0), Provider: "ecb"},
+		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: testDate.AddDate(0, 0, -1), Value: decimal.New(1, 0), Provider: "ecb"},
+	}
+	best := &cringletest.BestRate{Rate: rates[0], Days: 7, Stats: cringletest.NewRateStats(rates)}
+	r.NoError(getTestNotifier(r, server).NotifyBest(context.Background(), best))
+
+	messages := server.received()
+	r.Len(messages, 1)
+
+	blocks := messages[0].Blocks
+	r.Equal("Best exchange rate in the last 7 days", blocks[0].Text.Text)
+	r.Len(blocks[1].Fields, 5)
+	r.Equal("*ABC → DEF*\n1.0000 ABC = 2.0000 DEF", blocks[1].Fields[0].Text)
+	r.Equal("*Best/worst gap*\n100.00% over 2 rates", blocks[1].Fields[4].Text)
+	r.Equal([]*text{markdown("On Fri 25 May 2018 · Rates provided by ecb")}, blocks[2].Elements)
+}
+
+func TestNotifyBestDescribesWindowUntilDate(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusOK)
+	defer server.Close()
+
+	rate := &cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: testDate, Value: decimal.New(2, 0)}
+	best := &cringletest.BestRate{Rate: rate, Days: 7, Until: testDate}
+	r.NoError(getTestNotifier(r, server).NotifyBest(context.Background(), best))
+
+	r.Equal("Best exchange rate in the 7 days to Fri 25 May 2018", server.received()[0].Blocks[0].Text.Text)
+}
+
+func TestNotifyHistorySummarisesEachCurrency(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusOK)
+	defer server.Close()
+
+	history := &cringletest.RateHistory{From: "ABC", To: []string{"DEF"}, Start: testDate, End: testDate.AddDate(0, 0, 1), Interval: cringletest.IntervalDay}
+	for i, value := range []int64{100, 110} {
+		date := testDate.AddDate(0, 0, i)
+		history.Series = append(history.Series, &cringletest.DatedRates{
+			Date:  date,
+			Rates: cringletest.RateMap{"DEF": &cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: date, Value: decimal.New(value, 2)}},
+		})
+	}
+	r.NoError(getTestNotifier(r, server).NotifyHistory(context.Background(), history))
+
+	blocks := server.received()[0].Blocks
+	r.Equal("Exchange rate history of ABC", blocks[0].Text.Text)
+	r.Equal("*ABC → DEF*\n1.0000 → 1.1000 (+10.00%)", blocks[1].Fields[0].Text)
+	r.Equal("Fri 25 May 2018 to Sat 26 May 2018 by day", blocks[2].Elements[0].Text)
+}
+
+func TestNotifyAlertIncludesMessage(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusOK)
+	defer server.Close()
+
+	fired := time.Date(2018, 5, 25, 9, 30, 0, 0, time.UTC)
+	err := getTestNotifier(r, server).NotifyAlert(context.Background(), &cringletest.Alert{
+		Rule:    "abc-def-high",
+		Message: "ABC to DEF is 1.1600, above 1.15",
+		Rate:    &cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: fired, Value: decimal.New(116, 2)},
+		Fired:   fired,
+	})
+	r.NoError(err)
+
+	blocks := server.received()[0].Blocks
+	r.Equal("Exchange rate alert: abc-def-high", blocks[0].Text.Text)
+	r.Equal(markdown("ABC to DEF is 1.1600, above 1.15"), blocks[1].Text)
+	r.Equal("*ABC → DEF*\n1.0000 ABC = 1.1600 DEF", blocks[2].Fields[0].Text)
+	r.Equal("Fired at Fri 25 May 2018 09:30 UTC", blocks[3].Elements[0].Text)
+}
+
+func TestNotifyNeedNamesTarget(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusOK)
+	defer server.Close()
+
+	rates := []*cringletest.ExchangeRate{{From: "DEF", To: "ABC", Date: testDate, Value: decimal.New(2, 0)}}
+	requirements, err := cringletest.Need(cringletest.NewMoney(decimal.New(10, 0), "ABC"), rates, cringletest.DefaultRounding, nil)
+	r.NoError(err)
+	r.NoError(getTestNotifier(r, server).NotifyNeed(context.Background(), requirements))
+
+	blocks := server.received()[0].Blocks
+	r.Equal("Amounts needed to receive 10.0000 ABC on Fri 25 May 2018", blocks[0].Text.Text)
+	r.Equal("*DEF → ABC*\n5.0000 DEF = 10.0000 ABC", blocks[1].Fields[0].Text)
+}
+
+func TestNotifyMatrixSendsEachCrossRate(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusOK)
+	defer server.Close()
+
+	matrix, err := cringletest.NewRateMatrix([]string{"ABC", "DEF"}, cringletest.RateMap{
+		"DEF": {From: "ABC", To: "DEF", Date: testDate, Value: decimal.New(4, 0)},
+	})
+	r.NoError(err)
+	r.NoError(getTestNotifier(r, server).NotifyMatrix(context.Background(), matrix))
+
+	blocks := server.received()[0].Blocks
+	r.Equal("Cross rates of ABC, DEF on Fri 25 May 2018", blocks[0].Text.Text)
+	r.Equal("*ABC → DEF*\n1.0000 ABC = 4.0000 DEF", blocks[1].Fields[0].Text)
+	r.Equal("*DEF → ABC*\n1.0000 DEF = 0.2500 ABC", blocks[1].Fields[1].Text)
+}
+
+func TestRejectedMessagesFail(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusNotFound)
+	defer server.Close()
+
+	err := getTestNotifier(r, server).NotifyRates(context.Background(), testRates(1))
+	r.EqualError(err, "chat rejected the message with 404 Not Found: ok")
+
+	forbidden := newTestServer(http.StatusForbidden)
+	defer forbidden.Close()
+
+	err = getTestNotifier(r, forbidden).NotifyRates(context.Background(), testRates(1))
+	r.Equal(cringletest.ErrBadAuth, err)
+}
+
+func TestNotifyRatesFailsWithNoRates(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusOK)
+	defer server.Close()
+
+	r.Equal(cringletest.ErrNoRates, getTestNotifier(r, server).NotifyRates(context.Background(), nil))
+	r.Equal(cringletest.ErrNoRates, getTestNotifier(r, server).NotifyHistory(context.Background(), &cringletest.RateHistory{To: []string{"DEF"}}))
+	r.Empty(server.received())
+}
+
+func TestNewRejectsBadURLs(t *testing.T) {
+	r := require.New(t)
+
+	_, err := New("hooks.slack.com/services/abc")
+	r.EqualError(err, "chat webhook url hooks.slack.com/services/abc must be an absolute http or https url")
+}
+
+func TestRegisteredSchemePostsToTheURL(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusOK)
+	defer server.Close()
+
+	sender, err := cringletest.NewNotifier("chat+" + server.URL)
+	r.NoError(err)
+	r.NoError(sender.NotifyRates(context.Background(), testRates(1)))
+	r.Len(server.received(), 1)
+}
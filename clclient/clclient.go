@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -16,14 +17,23 @@ import (
 	"gopkg.in/resty.v1"
 )
 
+// Name is the provider name reported on the rates returned by the client
+const Name = "currencylayer"
+
 const (
 	currencyLayerEndpoint = "http://apilayer.net/api/"
 	liveMethod            = "live"
 	historicalMethod      = "historical"
+	timeframeMethod       = "timeframe"
 	accessKeyName         = "access_key"
 	currenciesName        = "currencies"
 	invalidAccessKey      = "invalid_access_key"
 	dateName              = "date"
+	startDateName         = "start_date"
+	endDateName           = "end_date"
+	dateFormat            = "2006-01-02"
+	// currencylayer limits a timeframe request to 365 days
+	maxTimeframeDays = 365
 )
 
 type rateClient struct {
@@ -55,7 +65,15 @@ type clResult struct {
 	Quotes  map[string]*jsonBig `json:"quotes"`
 }
 
-// New returns a new RateClient
+// clTimeframeResult holds the quotes for each date in a timeframe request
+type clTimeframeResult struct {
+	Success bool                           `json:"success"`
+	Error   *clError                       `json:"error,omitempty"`
+	Source  string                         `json:"source"`
+	Quotes  map[string]map[string]*jsonBig `json:"quotes"`
+}
+
+// New returns a new RateClient. The client also implements cringletest.RangeRateClient
 func New() (cringletest.RateClient, error) {
 	apiKey, err := envy.MustGet(cringletest.CurrencylayerAPIEnvVar)
 	if err != nil {
@@ -65,16 +83,25 @@ func New() (cringletest.RateClient, error) {
 	return &rateClient{apiKey}, nil
 }
 
+// resultError converts an error returned by the currencylayer api into an appropriate error
+func resultError(clErr *clError) error {
+	if clErr == nil {
+		return errors.New("currencylayer api error")
+	}
+
+	if clErr.Type == invalidAccessKey {
+		return cringletest.ErrBadAuth
+	}
+
+	return fmt.Errorf("currencylayer api error %s", clErr.Type)
+}
+
 func parse(from string, result *clResult) (cringletest.RateMap, error) {
 	// if the result.Success is not true then return an appropriate error
 	// if the result.Success is true then iterate through the returned currencies and create the
 	// ExchangeRate results
 	if !result.Success {
-		if result.Error.Type == invalidAccessKey {
-			return nil, cringletest.ErrBadAuth
-		}
-
-		return nil, fmt.Errorf("currencylayer api error %s", result.Error.Type)
+		return nil, resultError(result.Error)
 	}
 
 	var date time.Time
@@ -82,7 +109,7 @@ func parse(from string, result *clResult) (cringletest.RateMap, error) {
 	if len(result.Date) == 0 {
 		date = time.Now()
 	} else {
-		date, err = time.Parse("2006-01-02", result.Date)
+		date, err = time.Parse(dateFormat, result.Date)
 		if err != nil {
 			return nil, errors.Wrap(err, "bad date returned by currencylayer api")
 		}
@@ -98,10 +125,12 @@ func parse(from string, result *clResult) (cringletest.RateMap, error) {
 
 	for name, rate := range result.Quotes {
 		er := &cringletest.ExchangeRate{
-			From:  from,
-			To:    name[len(result.Source):],
-			Date:  date,
-			Value: new(decimal.Big).Quo(rate.Big, fromRate.Big),
+			From:     from,
+			To:       name[len(result.Source):],
+			Date:     date,
+			Value:    cringletest.RateContext.Quo(new(decimal.Big), rate.Big, fromRate.Big),
+			Provider: Name,
+			Fetched:  time.Now(),
 		}
 		rates[er.To] = er
 	}
@@ -109,8 +138,7 @@ func parse(from string, result *clResult) (cringletest.RateMap, error) {
 	return rates, nil
 }
 
-func (rc *rateClient) apiRequest(ctx context.Context, method string, params map[string]string) (*clResult, error) {
-	result := new(clResult)
+func (rc *rateClient) apiRequest(ctx context.Context, method string, params map[string]string, result interface{}) error {
 	params[accessKeyName] = rc.apiKey
 
 	// if the context already has a deadline set dont set a new one, otherwise use the CurrencyLayerTimeout
@@ -128,14 +156,14 @@ func (rc *rateClient) apiRequest(ctx context.Context, method string, params map[
 		Get(fmt.Sprintf("%s%s", currencyLayerEndpoint, method))
 
 	if err != nil {
-		return nil, errors.Wrap(err, "could not make currencylayer api request")
+		return errors.Wrap(err, "could not make currencylayer api request")
 	}
 
 	if resp.StatusCode() != http.StatusOK {
-		return nil, errors.New("unexpected status code returned from currencylayer api")
+		return errors.New("unexpected status code returned from currencylayer api")
 	}
 
-	return result, nil
+	return nil
 }
 
 // Implements cringletest.RateClient.Get using the currencylayer api.
@@ -149,7 +177,8 @@ func (rc *rateClient) Get(ctx context.Context, from string, to ...string) (cring
 		currenciesName: strings.Join(currencies, ","),
 	}
 
-	result, err := rc.apiRequest(ctx, liveMethod, params)
+	result := new(clResult)
+	err := rc.apiRequest(ctx, liveMethod, params, result)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not get live currencies")
 	}
@@ -164,13 +193,92 @@ func (rc *rateClient) GetOn(ctx context.Context, date time.Time, from string, to
 
 	currencies := append(to, from)
 	params := map[string]string{
-		dateName:       date.Format("2006-01-02"),
+		dateName:       date.Format(dateFormat),
 		currenciesName: strings.Join(currencies, ","),
 	}
 
-	result, err := rc.apiRequest(ctx, historicalMethod, params)
+	result := new(clResult)
+	err := rc.apiRequest(ctx, historicalMethod, params, result)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not get live currencies")
 	}
 	return parse(from, result)
 }
+
+// parseTimeframe splits a timeframe result into a result per date so that each can be parsed
+// in the same way as a historical result
+func parseTimeframe(from string, result *clTimeframeResult) (cringletest.RateSeries, error) {
+	dates := []string{}
+	for date := range result.Quotes {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	series := cringletest.RateSeries{}
+	for _, date := range dates {
+		rates, err := parse(from, &clResult{
+			Success: result.Success,
+			Error:   result.Error,
+			Date:    date,
+			Source:  result.Source,
+			Quotes:  result.Quotes[date],
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		day, _ := time.Parse(dateFormat, date)
+		series = append(series, &cringletest.DatedRates{Date: day, Rates: rates})
+	}
+
+	return series, nil
+}
+
+func (rc *rateClient) getTimeframe(ctx context.Context, start, end time.Time, currencies []string, from string) (cringletest.RateSeries, error) {
+	params := map[string]string{
+		startDateName:  start.Format(dateFormat),
+		endDateName:    end.Format(dateFormat),
+		currenciesName: strings.Join(currencies, ","),
+	}
+
+	result := new(clTimeframeResult)
+	err := rc.apiRequest(ctx, timeframeMethod, params, result)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get currency timeframe")
+	}
+
+	if !result.Success {
+		return nil, resultError(result.Error)
+	}
+
+	return parseTimeframe(from, result)
+}
+
+// Implements cringletest.RangeRateClient.GetRange using the currencylayer timeframe endpoint. Ranges
+// longer than currencylayer allows are split into several requests.
+func (rc *rateClient) GetRange(ctx context.Context, start, end time.Time, from string, to ...string) (cringletest.RateSeries, error) {
+	if len(to) == 0 {
+		return nil, cringletest.ErrNoToCurrencies
+	}
+
+	if end.Before(start) {
+		return nil, errors.New("range ends before it starts")
+	}
+
+	currencies := append(to, from)
+	series := cringletest.RateSeries{}
+	for chunkStart := start; !chunkStart.After(end); chunkStart = chunkStart.AddDate(0, 0, maxTimeframeDays) {
+		chunkEnd := chunkStart.AddDate(0, 0, maxTimeframeDays-1)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+
+		chunk, err := rc.getTimeframe(ctx, chunkStart, chunkEnd, currencies, from)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, chunk...)
+	}
+
+	return series, nil
+}
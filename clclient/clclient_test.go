@@ -169,3 +169,25 @@ func TestGetOnReturnsCorrectErrorWhenNoToCurrenciesSupplied(t *testing.T) {
 	r.Error(err)
 	r.EqualError(err, cringletest.ErrNoToCurrencies.Error())
 }
+
+func TestGetRangeReturnsRequestedRates(t *testing.T) {
+	r := require.New(t)
+
+	client, err := New()
+	r.NoError(err)
+
+	start, err := time.Parse("2006-01-02", "2016-05-10")
+	r.NoError(err)
+	end, err := time.Parse("2006-01-02", "2016-05-12")
+	r.NoError(err)
+
+	series, err := client.(cringletest.RangeRateClient).GetRange(context.Background(), start, end, "GBP", "EUR", "CAD")
+	r.NoError(err)
+	r.Len(series, 3)
+	r.Equal(end, series[2].Date)
+	r.Contains(series[2].Rates, "EUR")
+	r.Contains(series[2].Rates, "CAD")
+
+	expectedVal, _ := new(decimal.Big).SetString("1.269817652318747")
+	r.Equal(0, series[2].Rates["EUR"].Value.Cmp(expectedVal))
+}
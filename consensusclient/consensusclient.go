@@ -0,0 +1,241 @@
+// Package consensusclient implements a cringletest.RateClient which asks several providers
+// for each rate and returns the median of their answers
+package consensusclient
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/rangeclient"
+)
+
+type rateClient struct {
+	threshold *decimal.Big
+	providers []*cringletest.RateProvider
+}
+
+// answer is a single provider's rate for one currency
+type answer struct {
+	provider string
+	rate     *cringletest.ExchangeRate
+}
+
+// New returns a cringletest.RangeRateClient which queries every one of providers concurrently and
+// returns the median rate for each currency. When the providers disagree about a rate by more
+// than threshold basis points a warning is set on the returned ExchangeRate
+func New(threshold *decimal.Big, providers ...*cringletest.RateProvider) cringletest.RangeRateClient {
+	return &rateClient{threshold: threshold, providers: providers}
+}
+
+// query calls fetch with every provider concurrently and returns the error from each, in provider order
+func (rc *rateClient) query(fetch func(int, *cringletest.RateProvider) error) []error {
+	channels := []chan error{}
+	for i, provider := range rc.providers {
+		// buffer the channel so that the goroutine can always exit
+		ch := make(chan error, 1)
+		go func(i int, provider *cringletest.RateProvider) {
+			ch <- fetch(i, provider)
+		}(i, provider)
+		channels = append(channels, ch)
+	}
+
+	errs := []error{}
+	for _, ch := range channels {
+		errs = append(errs, <-ch)
+	}
+	return errs
+}
+
+// allFailed reports whether every provider failed, along with the first error in errs
+func (rc *rateClient) allFailed(errs []error) (bool, error) {
+	var first error
+	failed := 0
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		failed++
+		if first == nil {
+			first = errors.Wrapf(err, "provider %s failed", rc.providers[i].Name)
+		}
+	}
+	return failed == len(errs), first
+}
+
+// addAnswers adds each of the rates returned by provider to answers
+func addAnswers(answers map[string][]*answer, provider *cringletest.RateProvider, rates cringletest.RateMap) {
+	for currency, rate := range rates {
+		answers[currency] = append(answers[currency], &answer{provider: provider.Name, rate: rate})
+	}
+}
+
+// median returns the median of the sorted answers. With an even number of answers it is
+// the mean of the middle two
+func median(answers []*answer) *decimal.Big {
+	mid := len(answers) / 2
+	if len(answers)%2 == 1 {
+		return new(decimal.Big).Copy(answers[mid].rate.Value)
+	}
+
+	sum := new(decimal.Big).Add(answers[mid-1].rate.Value, answers[mid].rate.Value)
+	return sum.Quo(sum, decimal.New(2, 0))
+}
+
+// spread returns the difference between the highest and lowest of the sorted answers in basis
+// points of the median
+func spread(answers []*answer, med *decimal.Big) *decimal.Big {
+	if med.Sign() == 0 {
+		return new(decimal.Big)
+	}
+
+	diff := new(decimal.Big).Sub(answers[len(answers)-1].rate.Value, answers[0].rate.Value)
+	diff.Mul(diff, decimal.New(10000, 0))
+	return diff.Quo(diff, med)
+}
+
+func describe(answers []*answer) string {
+	parts := []string{}
+	for _, a := range answers {
+		parts = append(parts, fmt.Sprintf("%s %.6f", a.provider, a.rate.Value))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (rc *rateClient) combine(currency string, answers []*answer) *cringletest.ExchangeRate {
+	sort.Slice(answers, func(i, j int) bool {
+		return answers[i].rate.Value.Cmp(answers[j].rate.Value) < 0
+	})
+
+	med := median(answers)
+	names := []string{}
+	for _, a := range answers {
+		names = append(names, a.provider)
+	}
+	sort.Strings(names)
+
+	rate := &cringletest.ExchangeRate{
+		From:     answers[0].rate.From,
+		To:       currency,
+		Date:     answers[(len(answers)-1)/2].rate.Date,
+		Value:    med,
+		Provider: "median of " + strings.Join(names, ", "),
+		Fetched:  answers[(len(answers)-1)/2].rate.Fetched,
+	}
+
+	warnings := []string{}
+	if len(answers) < len(rc.providers) {
+		warnings = append(warnings, fmt.Sprintf("only %d of %d providers returned a rate", len(answers), len(rc.providers)))
+	}
+
+	if bps := spread(answers, med); bps.Cmp(rc.threshold) > 0 {
+		warnings = append(warnings, fmt.Sprintf("providers disagree by %.1f basis points (%s)", bps, describe(answers)))
+	}
+
+	rate.Warning = strings.Join(warnings, "; ")
+	return rate
+}
+
+func (rc *rateClient) combineAll(answers map[string][]*answer) cringletest.RateMap {
+	rates := cringletest.RateMap{}
+	for currency, currencyAnswers := range answers {
+		rates[currency] = rc.combine(currency, currencyAnswers)
+	}
+	return rates
+}
+
+func (rc *rateClient) get(fetch func(cringletest.RateClient) (cringletest.RateMap, error)) (cringletest.RateMap, error) {
+	if len(rc.providers) == 0 {
+		return nil, errors.New("no rate providers configured")
+	}
+
+	results := make([]cringletest.RateMap, len(rc.providers))
+	errs := rc.query(func(i int, provider *cringletest.RateProvider) error {
+		var err error
+		results[i], err = fetch(provider.Client)
+		return err
+	})
+	if all, err := rc.allFailed(errs); all {
+		return nil, err
+	}
+
+	answers := map[string][]*answer{}
+	for i, rates := range results {
+		addAnswers(answers, rc.providers[i], rates)
+	}
+
+	return rc.combineAll(answers), nil
+}
+
+// Implements cringletest.RateClient.Get using the median of the live rates of every provider
+func (rc *rateClient) Get(ctx context.Context, from string, to ...string) (cringletest.RateMap, error) {
+	if len(to) == 0 {
+		return nil, cringletest.ErrNoToCurrencies
+	}
+
+	return rc.get(func(client cringletest.RateClient) (cringletest.RateMap, error) {
+		return client.Get(ctx, from, to...)
+	})
+}
+
+// Implements cringletest.RateClient.GetOn using the median of the historical rates of every provider
+func (rc *rateClient) GetOn(ctx context.Context, date time.Time, from string, to ...string) (cringletest.RateMap, error) {
+	if len(to) == 0 {
+		return nil, cringletest.ErrNoToCurrencies
+	}
+
+	return rc.get(func(client cringletest.RateClient) (cringletest.RateMap, error) {
+		return client.GetOn(ctx, date, from, to...)
+	})
+}
+
+// Implements cringletest.RangeRateClient.GetRange using the median of the series returned by
+// every provider for each date
+func (rc *rateClient) GetRange(ctx context.Context, start, end time.Time, from string, to ...string) (cringletest.RateSeries, error) {
+	if len(to) == 0 {
+		return nil, cringletest.ErrNoToCurrencies
+	}
+
+	if len(rc.providers) == 0 {
+		return nil, errors.New("no rate providers configured")
+	}
+
+	results := make([]cringletest.RateSeries, len(rc.providers))
+	errs := rc.query(func(i int, provider *cringletest.RateProvider) error {
+		var err error
+		results[i], err = rangeclient.New(provider.Client, cringletest.RangeConcurrency).GetRange(ctx, start, end, from, to...)
+		return err
+	})
+	if all, err := rc.allFailed(errs); all {
+		return nil, err
+	}
+
+	// gather the answers for each date across every provider
+	dates := []time.Time{}
+	answers := map[time.Time]map[string][]*answer{}
+	for i, series := range results {
+		for _, day := range series {
+			date := rangeclient.Day(day.Date)
+			if _, ok := answers[date]; !ok {
+				answers[date] = map[string][]*answer{}
+				dates = append(dates, date)
+			}
+			addAnswers(answers[date], rc.providers[i], day.Rates)
+		}
+	}
+
+	sort.Slice(dates, func(i, j int) bool {
+		return dates[i].Before(dates[j])
+	})
+
+	series := cringletest.RateSeries{}
+	for _, date := range dates {
+		series = append(series, &cringletest.DatedRates{Date: date, Rates: rc.combineAll(answers[date])})
+	}
+	return series, nil
+}
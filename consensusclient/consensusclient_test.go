@@ -0,0 +1,122 @@
+package consensusclient
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/testclient"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedClient is a cringletest.RateClient which returns value for every currency
+type fixedClient struct {
+	value string
+}
+
+func (c *fixedClient) GetOn(ctx context.Context, date time.Time, from string, to ...string) (cringletest.RateMap, error) {
+	value, _ := new(decimal.Big).SetString(c.value)
+	rates := cringletest.RateMap{}
+	for _, currency := range to {
+		rates[currency] = &cringletest.ExchangeRate{From: from, To: currency, Date: date, Value: value}
+	}
+	return rates, nil
+}
+
+func (c *fixedClient) Get(ctx context.Context, from string, to ...string) (cringletest.RateMap, error) {
+	return c.GetOn(ctx, time.Now(), from, to...)
+}
+
+func getTestClient(threshold int64, clients ...cringletest.RateClient) cringletest.RateClient {
+	providers := []*cringletest.RateProvider{}
+	for i, client := range clients {
+		providers = append(providers, &cringletest.RateProvider{Name: string('a' + rune(i)), Client: client})
+	}
+	return New(decimal.New(threshold, 0), providers...)
+}
+
+func mustParseDecimal(value string) *decimal.Big {
+	d, _ := new(decimal.Big).SetString(value)
+	return d
+}
+
+func TestGetReturnsMedianOfOddNumberOfProviders(t *testing.T) {
+	r := require.New(t)
+	client := getTestClient(2000, &fixedClient{"1.10"}, &fixedClient{"1.30"}, &fixedClient{"1.20"})
+
+	rates, err := client.Get(context.Background(), "GBP", "EUR")
+	r.NoError(err)
+	r.Equal(0, rates["EUR"].Value.Cmp(mustParseDecimal("1.20")))
+	r.Equal("median of a, b, c", rates["EUR"].Provider)
+	r.Empty(rates["EUR"].Warning)
+}
+
+func TestGetOnReturnsMedianOfEvenNumberOfProviders(t *testing.T) {
+	r := require.New(t)
+	client := getTestClient(1000, &fixedClient{"1.10"}, &fixedClient{"1.20"})
+
+	rates, err := client.GetOn(context.Background(), time.Now(), "GBP", "EUR")
+	r.NoError(err)
+	r.Equal(0, rates["EUR"].Value.Cmp(mustParseDecimal("1.15")))
+}
+
+func TestGetWarnsWhenProvidersDisagree(t *testing.T) {
+	r := require.New(t)
+	// 1.00 and 1.02 are 200 basis points apart around a median of 1.01
+	client := getTestClient(100, &fixedClient{"1.00"}, &fixedClient{"1.02"})
+
+	rates, err := client.Get(context.Background(), "GBP", "EUR")
+	r.NoError(err)
+	r.True(strings.HasPrefix(rates["EUR"].Warning, "providers disagree by 198.0 basis points"), rates["EUR"].Warning)
+}
+
+func TestGetIgnoresFailingProviders(t *testing.T) {
+	r := require.New(t)
+	client := getTestClient(100, &fixedClient{"1.00"}, testclient.New(cringletest.ErrBadAuth))
+
+	rates, err := client.Get(context.Background(), "GBP", "EUR")
+	r.NoError(err)
+	r.Equal(0, rates["EUR"].Value.Cmp(mustParseDecimal("1.00")))
+	r.Equal("only 1 of 2 providers returned a rate", rates["EUR"].Warning)
+}
+
+func TestGetKeepsEveryWarning(t *testing.T) {
+	r := require.New(t)
+	client := getTestClient(100, &fixedClient{"1.00"}, &fixedClient{"1.02"}, testclient.New(cringletest.ErrBadAuth))
+
+	rates, err := client.Get(context.Background(), "GBP", "EUR")
+	r.NoError(err)
+	r.True(strings.HasPrefix(rates["EUR"].Warning, "only 2 of 3 providers returned a rate; providers disagree by 198.0 basis points"), rates["EUR"].Warning)
+}
+
+func TestGetReturnsErrorWhenAllProvidersFail(t *testing.T) {
+	r := require.New(t)
+	client := getTestClient(100, testclient.New(cringletest.ErrBadAuth), testclient.New(cringletest.ErrNoAuth))
+
+	_, err := client.Get(context.Background(), "GBP", "EUR")
+	r.Equal(cringletest.ErrBadAuth, errors.Cause(err))
+}
+
+func TestGetReturnsCorrectErrorWhenNoToCurrenciesSupplied(t *testing.T) {
+	r := require.New(t)
+	client := getTestClient(100, &fixedClient{"1.00"})
+
+	_, err := client.Get(context.Background(), "GBP")
+	r.EqualError(err, cringletest.ErrNoToCurrencies.Error())
+}
+
+func TestGetRangeReturnsMedianForEachDate(t *testing.T) {
+	r := require.New(t)
+	client := getTestClient(2000, &fixedClient{"1.10"}, &fixedClient{"1.30"}, &fixedClient{"1.20"}).(cringletest.RangeRateClient)
+
+	series, err := client.GetRange(context.Background(), time.Now().AddDate(0, 0, -3), time.Now(), "GBP", "EUR")
+	r.NoError(err)
+	r.Len(series, 4)
+	for _, day := range series {
+		r.Equal(0, day.Rates["EUR"].Value.Cmp(mustParseDecimal("1.20")))
+	}
+}
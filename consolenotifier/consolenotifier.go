@@ -5,71 +5,378 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 
 	"github.com/ericlagergren/decimal"
+	"github.com/pkg/errors"
 	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/locale"
+	"github.com/robotlovesyou/cringletest/report"
+	"github.com/robotlovesyou/cringletest/templates"
 )
 
-const dateFormat = "Mon 02 Jan 2006"
-
 type notifier struct {
 	// set the io.Writer as a member of the notifier so that it can be
 	// modified during testing
 	out io.Writer
+	// templates are the templates to render with, or nil for templates.Default()
+	templates *templates.Set
+	// locale formats numbers and dates, or nil for locale.Default()
+	locale *locale.Locale
 }
 
 const (
-	ratesTitle = "Exchange Rate Results on %s:"
-	valueTitle = "Currency Conversion Results on %s:"
-	bestTitle  = "Best Exchange Rate in the last 7 days is:"
+	historyTitle  = "Exchange Rate History for %s to %s from %s to %s by %s:"
+	historyHeader = "%16s %16s %16s %10s\n"
+	historyLine   = "%16s %16s %16s %10s\n"
+)
+
+// sparkChars are the bars used to draw a sparkline, lowest first
+var sparkChars = []rune("▁▂▃▄▅▆▇█")
+
+const warningLine = "       Warning: %s\n"
+
+// feesLine shows the gross amount, fees, net amount and effective rate of a conversion
+const feesLine = "       Gross %s, fees %s, net %s at an effective rate of %s\n"
+
+const (
+	ratesTitle  = "Exchange Rate Results on %s:"
+	valueTitle  = "Currency Conversion Results on %s:"
+	bestTitle   = "%s Exchange Rate in %s is:"
+	statsTitle  = "Statistics for %d rates:"
+	statsLine   = "%16s %16s\n"
+	alertTitle  = "Alert from rule %s at %s:"
+	needTitle   = "Amounts Needed to Receive %s on %s:"
+	matrixTitle = "Cross Rates on %s:"
 )
 
+// matrixCell is the width of each column of the matrix table
+const matrixCell = "%16s"
+
 // New Returns a cringletest.Notifier which sends notifications to the console
 func New() cringletest.Notifier {
-	return &notifier{os.Stdout}
+	return &notifier{out: os.Stdout}
 }
 
-func (n *notifier) writeRateLine(value *decimal.Big, rate *cringletest.ExchangeRate) error {
-	_, err := fmt.Fprintf(n.out,
-		"%16.4f %6s Buys %16.4f %6s\n",
-		value,
-		rate.From,
-		new(decimal.Big).Mul(value, rate.Value),
-		rate.To,
-	)
+// NewWithWriter returns a cringletest.Notifier which writes notifications as text to out
+func NewWithWriter(out io.Writer) cringletest.Notifier {
+	return &notifier{out: out}
+}
+
+func init() {
+	// console:// and console://stdout write to stdout, console://stderr to stderr. Either can
+	// take locale=de-DE to format for that locale
+	cringletest.RegisterNotifier("console", func(u *url.URL) (cringletest.Notifier, error) {
+		var out io.Writer
+		switch u.Host + u.Opaque {
+		case "", "stdout":
+			out = os.Stdout
+		case "stderr":
+			out = os.Stderr
+		default:
+			return nil, errors.New("expected console://stdout or console://stderr")
+		}
+
+		l, err := locale.Parse(u.Query().Get("locale"))
+		if err != nil {
+			return nil, err
+		}
+		n := &notifier{out: out}
+		if !l.IsPlain() {
+			n.locale = l
+		}
+		return n, nil
+	})
+}
+
+// formats returns the locale to format with
+func (n *notifier) formats() *locale.Locale {
+	if n.locale != nil {
+		return n.locale
+	}
+	return locale.Default()
+}
+
+// override writes the console template for kind in place of the built in layout if it has been
+// overridden, and reports whether it did. The built in layout is left to report data which
+// could not be built
+func (n *notifier) override(kind string, data templates.Data, err error) (bool, error) {
+	if err != nil {
+		return false, nil
+	}
+
+	set := n.templates
+	if set == nil {
+		set = templates.Default()
+	}
+
+	out, ok, err := set.Console(kind, data)
+	if !ok {
+		return false, nil
+	}
+	if err != nil {
+		return true, err
+	}
+	_, err = io.WriteString(n.out, out)
+	return true, err
+}
+
+// column formats an amount of currency, which is already formatted, for a column of the rate
+// table. The plain locale lines up the currency codes in a column of their own
+func column(l *locale.Locale, amount, currency string) string {
+	if l.IsPlain() {
+		return fmt.Sprintf("%16s %6s", amount, currency)
+	}
+	return fmt.Sprintf("%23s", l.Currency(amount, currency))
+}
+
+// writeRateLine writes what the amount of a rate buys, as the rate was formatted for the templates
+// so that the console shows exactly what every other notifier does
+func (n *notifier) writeRateLine(l *locale.Locale, rate *templates.Rate) error {
+	_, err := fmt.Fprintf(n.out, "%s Buys %s\n", column(l, rate.OriginalValue, rate.From), column(l, rate.ConvertedValue, rate.To))
+	if err != nil {
+		return err
+	}
+
+	if len(rate.Gross) != 0 {
+		if _, err := fmt.Fprintf(n.out, feesLine, rate.Gross, rate.Fees, rate.Converted, rate.EffectiveRate); err != nil {
+			return err
+		}
+	}
+
+	return n.writeWarning(rate.Warning)
+}
+
+func (n *notifier) writeWarning(warning string) error {
+	if len(warning) == 0 {
+		return nil
+	}
+
+	_, err := fmt.Fprintf(n.out, warningLine, warning)
 	return err
 }
 
-func (n *notifier) notifyList(title string, value *decimal.Big, rates []*cringletest.ExchangeRate) error {
-	if len(rates) == 0 {
-		return cringletest.ErrNoRates
+// describeDate returns the formatted date of a rate along with the provider which supplied it, if known
+func describeDate(date, provider string) string {
+	if len(provider) != 0 {
+		date = fmt.Sprintf("%s from %s", date, provider)
+	}
+	return date
+}
+
+// notifyList writes the list of rates in data under title, which is formatted with args followed
+// by the date of the rates
+func (n *notifier) notifyList(title string, data templates.Data, err error, args ...interface{}) error {
+	if err != nil {
+		return err
 	}
 
-	fmt.Fprintln(n.out, fmt.Sprintf(title, rates[0].Date.Format(dateFormat)))
+	l := n.formats()
+	rates := data["rates"].([]*templates.Rate)
+	args = append(args, describeDate(rates[0].Date, rates[0].Provider))
+	fmt.Fprintln(n.out, fmt.Sprintf(title, args...))
 	for _, rate := range rates {
-		n.writeRateLine(value, rate)
+		n.writeRateLine(l, rate)
+	}
+	if fees, _ := data["fees"].(string); len(fees) != 0 {
+		fmt.Fprintf(n.out, "Fees charged: %s\n", fees)
 	}
 
 	return nil
 }
 
 func (n *notifier) NotifyRates(ctx context.Context, rates []*cringletest.ExchangeRate) error {
-	return n.notifyList(ratesTitle, decimal.New(1, 0), rates)
+	data, err := templates.RatesData(n.formats(), rates)
+	if done, err := n.override(report.KindRates, data, err); done {
+		return err
+	}
+	return n.notifyList(ratesTitle, data, err)
+}
+
+func (n *notifier) NotifyValue(ctx context.Context, conversions []*cringletest.Conversion) error {
+	data, err := templates.ValueData(n.formats(), conversions)
+	if done, err := n.override(report.KindValue, data, err); done {
+		return err
+	}
+	return n.notifyList(valueTitle, data, err)
 }
 
-func (n *notifier) NotifyValue(ctx context.Context, value *decimal.Big, rates []*cringletest.ExchangeRate) error {
-	return n.notifyList(valueTitle, value, rates)
+func (n *notifier) NotifyNeed(ctx context.Context, requirements []*cringletest.Requirement) error {
+	data, err := templates.NeedData(n.formats(), requirements)
+	if done, err := n.override(report.KindNeed, data, err); done {
+		return err
+	}
+	return n.notifyList(needTitle, data, err, data["target"])
 }
 
-func (n *notifier) NotifyBest(ctx context.Context, rate *cringletest.ExchangeRate) error {
-	fmt.Fprintln(n.out, bestTitle)
+func (n *notifier) NotifyMatrix(ctx context.Context, matrix *cringletest.RateMatrix) error {
+	data, err := templates.MatrixData(n.formats(), matrix)
+	if done, err := n.override(report.KindMatrix, data, err); done {
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(n.out, fmt.Sprintf(matrixTitle, describeDate(data["date"].(string), data["provider"].(string))))
+	fmt.Fprintf(n.out, "%6s", "")
+	for _, currency := range matrix.Currencies {
+		fmt.Fprintf(n.out, " "+matrixCell, currency)
+	}
+	fmt.Fprintln(n.out)
+	for _, row := range data["rows"].([]*templates.MatrixRow) {
+		fmt.Fprintf(n.out, "%6s", row.From)
+		for _, rate := range row.Rates {
+			fmt.Fprintf(n.out, " "+matrixCell, rate)
+		}
+		fmt.Fprintln(n.out)
+	}
+	for _, warning := range data["warnings"].([]string) {
+		if err := n.writeWarning(warning); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *notifier) writeStats(l *locale.Locale, stats *cringletest.RateStats) {
+	fmt.Fprintln(n.out, fmt.Sprintf(statsTitle, stats.Count))
+	fmt.Fprintf(n.out, statsLine, "Min", l.Rate(stats.Min))
+	fmt.Fprintf(n.out, statsLine, "Max", l.Rate(stats.Max))
+	fmt.Fprintf(n.out, statsLine, "Mean", l.Rate(stats.Mean))
+	fmt.Fprintf(n.out, statsLine, "Median", l.Rate(stats.Median))
+	fmt.Fprintf(n.out, statsLine, "Std Dev", l.Number(stats.StdDev, 6))
+	fmt.Fprintf(n.out, statsLine, "Best/Worst Gap", l.Percent(l.Number(stats.Gap, 2)))
+}
+
+func (n *notifier) NotifyBest(ctx context.Context, best *cringletest.BestRate) error {
+	l := n.formats()
+	data, err := templates.BestData(l, best)
+	if done, err := n.override(report.KindBest, data, err); done {
+		return err
+	}
+
+	kind := "Best"
+	if best.Worst {
+		kind = "Worst"
+	}
+
+	rate := best.Rate
+	fmt.Fprintln(n.out, fmt.Sprintf(bestTitle, kind, templates.DescribeWindow(l, best)))
 	fmt.Fprintf(n.out,
-		"1.0000 %s to %.4f %s on %s\n",
-		rate.From,
-		rate.Value,
-		rate.To,
-		rate.Date.Format(dateFormat),
+		"%s to %s on %s\n",
+		l.Currency(l.Rate(decimal.New(1, 0)), rate.From),
+		l.Currency(l.Rate(rate.Value), rate.To),
+		describeDate(l.Date(rate.Date), rate.Provider),
 	)
+	if err := n.writeWarning(rate.Warning); err != nil {
+		return err
+	}
+
+	if best.Stats != nil {
+		n.writeStats(l, best.Stats)
+	}
+	return nil
+}
+
+// sparkline draws rates as a line of bars scaled between the lowest and highest rate
+func sparkline(rates []*cringletest.ExchangeRate) string {
+	values := []float64{}
+	for _, rate := range rates {
+		// the sparkline is only a picture so float precision is plenty
+		value, _ := rate.Value.Float64()
+		values = append(values, value)
+	}
+
+	low, high := values[0], values[0]
+	for _, value := range values {
+		if value < low {
+			low = value
+		}
+		if value > high {
+			high = value
+		}
+	}
+
+	line := []rune{}
+	for _, value := range values {
+		i := len(sparkChars) / 2
+		if high > low {
+			i = int((value - low) / (high - low) * float64(len(sparkChars)-1))
+		}
+		line = append(line, sparkChars[i])
+	}
+	return string(line)
+}
+
+// formatChange returns the change between two rates as an absolute and percentage change
+func formatChange(l *locale.Locale, previous, current *cringletest.ExchangeRate) (string, string) {
+	if previous == nil {
+		return "", ""
+	}
+
+	change := new(decimal.Big).Sub(current.Value, previous.Value)
+	percent := cringletest.PercentChange(previous.Value, current.Value)
+	if percent == nil {
+		percent = decimal.New(0, 2)
+	}
+	return l.Signed(change.Quantize(locale.RatePlaces), locale.RatePlaces), l.Percent(l.Signed(percent, 2))
+}
+
+func (n *notifier) writeHistoryTable(l *locale.Locale, history *cringletest.RateHistory, currency string, rates []*cringletest.ExchangeRate) {
+	fmt.Fprintln(n.out, fmt.Sprintf(historyTitle,
+		history.From,
+		currency,
+		l.Date(history.Start),
+		l.Date(history.End),
+		history.Interval,
+	))
+	fmt.Fprintf(n.out, historyHeader, "Date", "Rate", "Change", "Change %")
+
+	var previous *cringletest.ExchangeRate
+	for _, rate := range rates {
+		change, percent := formatChange(l, previous, rate)
+		fmt.Fprintf(n.out, historyLine, l.Date(rate.Date), l.Rate(rate.Value), change, percent)
+		n.writeWarning(rate.Warning)
+		previous = rate
+	}
+
+	fmt.Fprintf(n.out, "%16s %s\n\n", "", sparkline(rates))
+}
+
+func (n *notifier) NotifyHistory(ctx context.Context, history *cringletest.RateHistory) error {
+	l := n.formats()
+	data, err := templates.HistoryData(l, history)
+	if done, err := n.override(report.KindHistory, data, err); done {
+		return err
+	}
+
+	written := 0
+	for _, currency := range history.To {
+		rates := history.Rates(currency)
+		if len(rates) == 0 {
+			continue
+		}
+
+		n.writeHistoryTable(l, history, currency, rates)
+		written++
+	}
+
+	if written == 0 {
+		return cringletest.ErrNoRates
+	}
 	return nil
 }
+
+func (n *notifier) NotifyAlert(ctx context.Context, alert *cringletest.Alert) error {
+	l := n.formats()
+	data, err := templates.AlertData(l, alert)
+	if done, err := n.override(report.KindAlert, data, err); done {
+		return err
+	}
+
+	fmt.Fprintln(n.out, fmt.Sprintf(alertTitle, alert.Rule, l.DateTime(alert.Fired)))
+	fmt.Fprintf(n.out, "%s\n", alert.Message)
+	return n.writeRateLine(l, data["rate"].(*templates.Rate))
+}
@@ -5,11 +5,15 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/ericlagergren/decimal"
 	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/locale"
+	"github.com/robotlovesyou/cringletest/templates"
 	"github.com/stretchr/testify/require"
 )
 
@@ -29,8 +33,14 @@ func getTestOutput(buf *bytes.Buffer) (string, error) {
 	return string(outBytes), nil
 }
 
+func convert(r *require.Assertions, amount *decimal.Big, rates []*cringletest.ExchangeRate) []*cringletest.Conversion {
+	conversions, err := cringletest.Convert(cringletest.NewMoney(amount, "ABC"), rates, cringletest.DefaultRounding)
+	r.NoError(err)
+	return conversions
+}
+
 func getFormattedDate(date time.Time) string {
-	return date.Format(dateFormat)
+	return locale.Plain.Date(date)
 }
 
 func TestNotifyRatesSendsOK(t *testing.T) {
@@ -69,13 +79,81 @@ func TestNotifyValueSendsOK(t *testing.T) {
 		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: time.Now(), Value: decimal.New(1234, 3)},
 	}
 
-	err := sender.NotifyValue(context.Background(), decimal.New(2, 0), rates)
+	err := sender.NotifyValue(context.Background(), convert(r, decimal.New(2, 0), rates))
+	r.NoError(err)
+
+	out, err := getTestOutput(buf)
+	r.NoError(err)
+
+	expected := fmt.Sprintf("Currency Conversion Results on %s:\n          2.0000    ABC Buys           2.4700    DEF\n", getFormattedDate(time.Now()))
+	r.Equal(expected, out)
+}
+
+func TestNotifyValueShowsFees(t *testing.T) {
+	r := require.New(t)
+
+	sender, buf := getTestNotifier()
+
+	rates := []*cringletest.ExchangeRate{
+		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: time.Now(), Value: decimal.New(2, 0)},
+	}
+	fees := &cringletest.Fees{Percent: decimal.New(1, 0), Spread: decimal.New(100, 0)}
+	conversions, err := cringletest.ConvertWithFees(cringletest.NewMoney(decimal.New(100, 0), "ABC"), rates, cringletest.DefaultRounding, fees)
+	r.NoError(err)
+
+	r.NoError(sender.NotifyValue(context.Background(), conversions))
+
+	out, err := getTestOutput(buf)
 	r.NoError(err)
 
+	expected := fmt.Sprintf("Currency Conversion Results on %s:\n"+
+		"        100.0000    ABC Buys         196.0200    DEF\n"+
+		"       Gross 200.0000 DEF, fees 3.9800 DEF, net 196.0200 DEF at an effective rate of 1.9602\n"+
+		"Fees charged: 1%% + 100 bps spread\n", getFormattedDate(time.Now()))
+	r.Equal(expected, out)
+}
+
+func TestNotifyNeedSendsOK(t *testing.T) {
+	r := require.New(t)
+
+	sender, buf := getTestNotifier()
+
+	rates := []*cringletest.ExchangeRate{
+		&cringletest.ExchangeRate{From: "DEF", To: "ABC", Date: time.Now(), Value: decimal.New(2, 0), Provider: "ecb"},
+	}
+	requirements, err := cringletest.Need(cringletest.NewMoney(decimal.New(10, 0), "ABC"), rates, cringletest.DefaultRounding, nil)
+	r.NoError(err)
+
+	r.NoError(sender.NotifyNeed(context.Background(), requirements))
+
 	out, err := getTestOutput(buf)
 	r.NoError(err)
 
-	expected := fmt.Sprintf("Currency Conversion Results on %s:\n          2.0000    ABC Buys           2.4680    DEF\n", getFormattedDate(time.Now()))
+	expected := fmt.Sprintf("Amounts Needed to Receive 10.0000 ABC on %s from ecb:\n          5.0000    DEF Buys          10.0000    ABC\n", getFormattedDate(time.Now()))
+	r.Equal(expected, out)
+}
+
+func TestNotifyMatrixWritesTable(t *testing.T) {
+	r := require.New(t)
+
+	sender, buf := getTestNotifier()
+
+	now := time.Now()
+	matrix, err := cringletest.NewRateMatrix([]string{"ABC", "DEF"}, cringletest.RateMap{
+		"DEF": {From: "ABC", To: "DEF", Date: now, Value: decimal.New(4, 0), Provider: "ecb", Warning: "stale"},
+	})
+	r.NoError(err)
+	r.NoError(sender.NotifyMatrix(context.Background(), matrix))
+
+	out, err := getTestOutput(buf)
+	r.NoError(err)
+
+	expected := fmt.Sprintf("Cross Rates on %s from ecb:\n"+
+		"                    ABC              DEF\n"+
+		"   ABC           1.0000           4.0000\n"+
+		"   DEF           0.2500           1.0000\n"+
+		"       Warning: ABC to DEF: stale\n"+
+		"       Warning: DEF to ABC: stale\n", getFormattedDate(now))
 	r.Equal(expected, out)
 }
 
@@ -86,7 +164,7 @@ func TestNotifyBestSendsOK(t *testing.T) {
 
 	rate := &cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: time.Now(), Value: decimal.New(1234, 3)}
 
-	err := sender.NotifyBest(context.Background(), rate)
+	err := sender.NotifyBest(context.Background(), &cringletest.BestRate{Rate: rate, Days: 7})
 	r.NoError(err)
 
 	out, err := getTestOutput(buf)
@@ -95,3 +173,171 @@ func TestNotifyBestSendsOK(t *testing.T) {
 	expected := fmt.Sprintf("Best Exchange Rate in the last 7 days is:\n1.0000 ABC to 1.2340 DEF on %s\n", getFormattedDate(time.Now()))
 	r.Equal(expected, out)
 }
+
+func TestNotifyWorstWithStatsSendsOK(t *testing.T) {
+	r := require.New(t)
+
+	sender, buf := getTestNotifier()
+
+	until := time.Date(2018, 5, 25, 0, 0, 0, 0, time.UTC)
+	rates := []*cringletest.ExchangeRate{
+		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: until, Value: decimal.New(1, 0)},
+		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: until.AddDate(0, 0, -1), Value: decimal.New(2, 0)},
+	}
+
+	best := &cringletest.BestRate{
+		Rate:  rates[0],
+		Worst: true,
+		Days:  2,
+		Until: until,
+		Stats: cringletest.NewRateStats(rates),
+	}
+	err := sender.NotifyBest(context.Background(), best)
+	r.NoError(err)
+
+	out, err := getTestOutput(buf)
+	r.NoError(err)
+
+	expected := "Worst Exchange Rate in the 2 days to Fri 25 May 2018 is:\n" +
+		"1.0000 ABC to 1.0000 DEF on Fri 25 May 2018\n" +
+		"Statistics for 2 rates:\n" +
+		"             Min           1.0000\n" +
+		"             Max           2.0000\n" +
+		"            Mean           1.5000\n" +
+		"          Median           1.5000\n" +
+		"         Std Dev         0.500000\n" +
+		"  Best/Worst Gap          100.00%\n"
+	r.Equal(expected, out)
+}
+
+func TestNotifyRatesShowsWarnings(t *testing.T) {
+	r := require.New(t)
+	sender, buf := getTestNotifier()
+
+	rates := []*cringletest.ExchangeRate{
+		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: time.Now(), Value: decimal.New(1234, 3), Warning: "providers disagree"},
+	}
+
+	err := sender.NotifyRates(context.Background(), rates)
+	r.NoError(err)
+
+	out, err := getTestOutput(buf)
+	r.NoError(err)
+
+	expected := fmt.Sprintf("Exchange Rate Results on %s:\n          1.0000    ABC Buys           1.2340    DEF\n       Warning: providers disagree\n", getFormattedDate(time.Now()))
+	r.Equal(expected, out)
+}
+
+func TestNotifyHistorySendsOK(t *testing.T) {
+	r := require.New(t)
+
+	sender, buf := getTestNotifier()
+
+	start := time.Date(2018, 5, 21, 0, 0, 0, 0, time.UTC)
+	history := &cringletest.RateHistory{From: "ABC", To: []string{"DEF"}, Start: start, End: start.AddDate(0, 0, 2), Interval: cringletest.IntervalDay}
+	for i, value := range []int64{100, 110, 99} {
+		date := start.AddDate(0, 0, i)
+		history.Series = append(history.Series, &cringletest.DatedRates{
+			Date:  date,
+			Rates: cringletest.RateMap{"DEF": &cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: date, Value: decimal.New(value, 2)}},
+		})
+	}
+
+	err := sender.NotifyHistory(context.Background(), history)
+	r.NoError(err)
+
+	out, err := getTestOutput(buf)
+	r.NoError(err)
+
+	expected := "Exchange Rate History for ABC to DEF from Mon 21 May 2018 to Wed 23 May 2018 by day:\n" +
+		"            Date             Rate           Change   Change %\n" +
+		" Mon 21 May 2018           1.0000                            \n" +
+		" Tue 22 May 2018           1.1000          +0.1000    +10.00%\n" +
+		" Wed 23 May 2018           0.9900          -0.1100    -10.00%\n" +
+		"                 ▁█▁\n\n"
+	r.Equal(expected, out)
+}
+
+func TestNotifyHistoryFailsWithNoRates(t *testing.T) {
+	r := require.New(t)
+
+	err := New().NotifyHistory(context.Background(), &cringletest.RateHistory{To: []string{"DEF"}})
+	r.EqualError(err, cringletest.ErrNoRates.Error())
+}
+
+func TestNotifyAlertSendsOK(t *testing.T) {
+	r := require.New(t)
+	sender, buf := getTestNotifier()
+
+	fired := time.Date(2018, 5, 25, 9, 30, 0, 0, time.UTC)
+	err := sender.NotifyAlert(context.Background(), &cringletest.Alert{
+		Rule:    "abc-def-high",
+		Message: "ABC to DEF is 1.1600, above 1.15",
+		Rate:    &cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: fired, Value: decimal.New(116, 2)},
+		Fired:   fired,
+	})
+	r.NoError(err)
+
+	out, err := getTestOutput(buf)
+	r.NoError(err)
+
+	expected := "Alert from rule abc-def-high at Fri 25 May 2018 09:30 UTC:\n" +
+		"ABC to DEF is 1.1600, above 1.15\n" +
+		"          1.0000    ABC Buys           1.1600    DEF\n"
+	r.Equal(expected, out)
+}
+
+func TestConsoleTemplateOverridesLayout(t *testing.T) {
+	r := require.New(t)
+
+	dir, err := ioutil.TempDir("", "consolenotifier")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+	r.NoError(ioutil.WriteFile(filepath.Join(dir, "value.console.tmpl"), []byte("{{range .rates}}{{.OriginalValue}} {{.From}} = {{.ConvertedValue}} {{.To}}\n{{end}}"), 0644))
+
+	set, err := templates.Load(dir)
+	r.NoError(err)
+
+	sender, buf := getTestNotifier()
+	sender.(*notifier).templates = set
+
+	rates := []*cringletest.ExchangeRate{
+		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: time.Now(), Value: decimal.New(1234, 3)},
+	}
+	r.NoError(sender.NotifyValue(context.Background(), convert(r, decimal.New(2, 0), rates)))
+	r.Equal("2.0000 ABC = 2.4700 DEF\n", buf.String())
+
+	// kinds which are not overridden keep the built in layout
+	buf.Reset()
+	r.NoError(sender.NotifyRates(context.Background(), rates))
+	r.Contains(buf.String(), "Exchange Rate Results on ")
+
+	r.Equal(cringletest.ErrNoRates, sender.NotifyValue(context.Background(), nil))
+}
+
+func TestConsoleFormatsForLocale(t *testing.T) {
+	r := require.New(t)
+
+	sender, err := cringletest.NewNotifier("console://stdout?locale=en-US")
+	r.NoError(err)
+	n := sender.(*notifier)
+	buf := bytes.NewBuffer(nil)
+	n.out = buf
+
+	date := time.Date(2018, 5, 25, 0, 0, 0, 0, time.UTC)
+	rates := []*cringletest.ExchangeRate{
+		&cringletest.ExchangeRate{From: "GBP", To: "JPY", Date: date, Value: decimal.New(14987, 2)},
+		&cringletest.ExchangeRate{From: "GBP", To: "KWD", Date: date, Value: decimal.New(40512, 5)},
+	}
+	conversions, err := cringletest.Convert(cringletest.NewMoney(decimal.New(1500, 0), "GBP"), rates, cringletest.DefaultRounding)
+	r.NoError(err)
+	r.NoError(sender.NotifyValue(context.Background(), conversions))
+
+	expected := "Currency Conversion Results on Fri, May 25, 2018:\n" +
+		"              £1,500.00 Buys                ¥224,805\n" +
+		"              £1,500.00 Buys             KWD\u00a0607.680\n"
+	r.Equal(expected, buf.String())
+
+	_, err = cringletest.NewNotifier("console://stdout?locale=nonsense-locale-tag")
+	r.Error(err)
+}
@@ -18,12 +18,80 @@ const (
 	CurrencylayerAPIEnvVar = "CURRENCYLAYER_API_KEY"
 	// CurrencylayerTimeout is the maximum time to wait for a call to the Currencylayer api
 	CurrencylayerTimeout = 10 * time.Second
+	// ECBTimeout is the maximum time to wait for a call to the ECB reference rate feeds
+	ECBTimeout = 30 * time.Second
 	// SendGridAPIEnvVar is the name of the SendGrid API Key Env Var
 	SendGridAPIEnvVar = "SENDGRID_API_KEY"
 	//SendGridFromAddressEnvVar is the env var containing the test from address for sendgrid tests
 	SendGridFromAddressEnvVar = "SENDGRID_FROM_ADDRESS"
 	//SendGridTestToAddressEnvVar is the env var containing the to address for sendgrid tests
 	SendGridTestToAddressEnvVar = "SENDGRID_TEST_TO_ADDRESS"
+	// SMTPHostEnvVar is the env var containing the host name of the SMTP server
+	SMTPHostEnvVar = "SMTP_HOST"
+	// SMTPPortEnvVar is the env var containing the port of the SMTP server
+	SMTPPortEnvVar = "SMTP_PORT"
+	// SMTPUsernameEnvVar is the env var containing the username for the SMTP server
+	SMTPUsernameEnvVar = "SMTP_USERNAME"
+	// SMTPPasswordEnvVar is the env var containing the password for the SMTP server
+	SMTPPasswordEnvVar = "SMTP_PASSWORD"
+	// SMTPFromEnvVar is the env var containing the address email is sent from over SMTP
+	SMTPFromEnvVar = "SMTP_FROM"
+	// SMTPTLSEnvVar is the env var containing how the SMTP connection is encrypted, one of starttls, tls or none
+	SMTPTLSEnvVar = "SMTP_TLS"
+	// SMTPAuthEnvVar is the env var containing how to authenticate with the SMTP server, one of plain, login or none
+	SMTPAuthEnvVar = "SMTP_AUTH"
+	// SMTPPort is the default port of the SMTP server
+	SMTPPort = 587
+	// WebhookSecretEnvVar is the env var containing the secret webhook bodies are signed with
+	WebhookSecretEnvVar = "CCONV_WEBHOOK_SECRET"
+	// WebhookAttempts is the maximum number of times a webhook is tried
+	WebhookAttempts = 4
+	// WebhookBackoff is the time waited before the first retry of a webhook. It doubles after each retry
+	WebhookBackoff = 500 * time.Millisecond
+	// WebhookAttemptTimeout is the maximum time to wait for a single webhook attempt
+	WebhookAttemptTimeout = 10 * time.Second
+	// IdempotencyKeyHeader is the email header the idempotency key of a notification is sent in
+	IdempotencyKeyHeader = "X-Cconv-Idempotency-Key"
+	// ChatWebhookEnvVar is the env var containing the incoming webhook url chat messages are posted to
+	ChatWebhookEnvVar = "CCONV_CHAT_WEBHOOK"
+	// AuditLogEnvVar is the env var containing the path of the audit log
+	AuditLogEnvVar = "CCONV_AUDIT_LOG"
+	// OutboxDirEnvVar is the env var containing the directory notifications which could not be sent are kept in
+	OutboxDirEnvVar = "CCONV_OUTBOX_DIR"
+	// OutboxBackoff is the time a notification in the outbox waits before it is due to be tried again.
+	// It doubles after each attempt
+	OutboxBackoff = time.Minute
+	// OutboxMaxBackoff is the longest a notification in the outbox waits between attempts
+	OutboxMaxBackoff = 6 * time.Hour
+	// TemplateDirEnvVar is the env var containing the directory of templates which override the built in ones
+	TemplateDirEnvVar = "CCONV_TEMPLATE_DIR"
+	// LocaleEnvVar is the env var containing the locale numbers and dates are formatted for, such as de-DE
+	LocaleEnvVar = "CCONV_LOCALE"
+	// ConfigEnvVar is the env var containing the path of the cconv config file
+	ConfigEnvVar = "CCONV_CONFIG"
+	// MailerEnvVar is the env var containing the service used to send email, sendgrid or smtp
+	MailerEnvVar = "CCONV_MAILER"
 	// NotifyTimeout is the timeout allocated to the notify functions
 	NotifyTimeout = 30 * time.Second
+	// ProviderEnvVar is the env var containing a comma separated list of rate providers in priority order
+	ProviderEnvVar = "CCONV_PROVIDER"
+	// RangeConcurrency is the maximum number of concurrent requests made when a range of rates
+	// has to be fetched one day at a time
+	RangeConcurrency = 4
+	// ConsensusThreshold is the default number of basis points providers may disagree by before a warning is given
+	ConsensusThreshold = 50
+	// CacheDirEnvVar is the env var containing the directory used to cache rates
+	CacheDirEnvVar = "CCONV_CACHE_DIR"
+	// CacheTTL is the default length of time live rates are cached for
+	CacheTTL = 15 * time.Minute
+	// ServeAddress is the default address cconv serve listens on
+	ServeAddress = ":8080"
+	// ServeRequestTimeout is the maximum time allowed to answer a single cconv serve request
+	ServeRequestTimeout = 60 * time.Second
+	// WatchInterval is the default time between checks of the watch rules
+	WatchInterval = 5 * time.Minute
+	// WatchCooldown is the default time a watch rule waits after firing before it can fire again
+	WatchCooldown = time.Hour
+	// ServeShutdownTimeout is the maximum time allowed for requests in flight to finish when cconv serve is stopped
+	ServeShutdownTimeout = 15 * time.Second
 )
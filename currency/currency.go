@@ -0,0 +1,211 @@
+// Package currency is a registry of the ISO 4217 currencies, so that currency codes can be
+// checked before rates are requested for them
+package currency
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NoMinorUnits is the MinorUnits of currencies which are not divided, such as gold
+const NoMinorUnits = -1
+
+// Currency describes a currency
+type Currency struct {
+	// Code is the three letter code of the currency, such as GBP
+	Code string `json:"code"`
+	// Name is the ISO 4217 name of the currency, such as Pound Sterling
+	Name string `json:"name"`
+	// Numeric is the three digit ISO 4217 number of the currency, or 0 if it has none
+	Numeric int `json:"numeric,omitempty"`
+	// MinorUnits is the number of decimal places amounts of the currency are written with, or
+	// NoMinorUnits
+	MinorUnits int `json:"minorUnits"`
+	// Symbol is the symbol of the currency, or empty if it is written with its code
+	Symbol string `json:"symbol,omitempty"`
+	// Cash is the smallest amount which can be paid in cash, such as 0.05 for CHF, or empty if it
+	// is the minor unit
+	Cash string `json:"cash,omitempty"`
+	// Withdrawn currencies have been replaced but still have rates for the days before
+	Withdrawn bool `json:"withdrawn,omitempty"`
+	// Unofficial currencies have no ISO 4217 code but are quoted by rate providers
+	Unofficial bool `json:"unofficial,omitempty"`
+}
+
+// registry holds every currency by code
+var registry = func() map[string]*Currency {
+	currencies := map[string]*Currency{}
+	for _, list := range [][]*Currency{iso4217, withdrawn, unofficial} {
+		for _, c := range list {
+			currencies[c.Code] = c
+		}
+	}
+	return currencies
+}()
+
+// Lookup returns the currency with code, ignoring case
+func Lookup(code string) (*Currency, bool) {
+	c, ok := registry[strings.ToUpper(strings.TrimSpace(code))]
+	return c, ok
+}
+
+// All returns every currency in order of code
+func All() []*Currency {
+	currencies := []*Currency{}
+	for _, c := range registry {
+		currencies = append(currencies, c)
+	}
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i].Code < currencies[j].Code })
+	return currencies
+}
+
+// Search returns the currencies, in order of code, whose code, name, numeric code or symbol
+// contains query, ignoring case
+func Search(query string) []*Currency {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if len(query) == 0 {
+		return All()
+	}
+
+	found := []*Currency{}
+	for _, c := range All() {
+		for _, field := range []string{c.Code, c.Name, c.Symbol, c.NumericCode()} {
+			if strings.Contains(strings.ToLower(field), query) {
+				found = append(found, c)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// MinorUnits returns the number of decimal places amounts of the currency with code are written
+// with. Unknown currencies have 2
+func MinorUnits(code string) int {
+	if c, ok := Lookup(code); ok {
+		return c.MinorUnits
+	}
+	return 2
+}
+
+// Symbol returns the symbol of the currency with code, or code itself if it has none
+func Symbol(code string) string {
+	if c, ok := Lookup(code); ok && len(c.Symbol) != 0 {
+		return c.Symbol
+	}
+	return code
+}
+
+// Cash returns the smallest amount of the currency with code which can be paid in cash, or an
+// empty string if it is the minor unit
+func Cash(code string) string {
+	if c, ok := Lookup(code); ok {
+		return c.Cash
+	}
+	return ""
+}
+
+// maxSuggestions is the most codes Suggest returns
+const maxSuggestions = 3
+
+// Suggest returns the codes of up to three currencies which code might have been meant to be.
+// These are the currencies named code, such as JPY for YEN, or if there are none those whose code
+// is one typo away from it, such as GBP for GPB. Current currencies come first
+func Suggest(code string) []string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	named, nearby := []*Currency{}, []*Currency{}
+	for _, c := range All() {
+		if strings.EqualFold(c.Name, code) {
+			named = append(named, c)
+		} else if typos(code, c.Code) == 1 {
+			nearby = append(nearby, c)
+		}
+	}
+
+	suggestions := named
+	if len(suggestions) == 0 {
+		suggestions = nearby
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return !suggestions[i].Withdrawn && suggestions[j].Withdrawn
+	})
+
+	codes := []string{}
+	for i, c := range suggestions {
+		if i == maxSuggestions {
+			break
+		}
+		codes = append(codes, c.Code)
+	}
+	return codes
+}
+
+// typos returns the number of single letter insertions, deletions, substitutions or swaps of
+// neighbouring letters which turn a into b
+func typos(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = least(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = least(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func least(values ...int) int {
+	smallest := values[0]
+	for _, v := range values[1:] {
+		if v < smallest {
+			smallest = v
+		}
+	}
+	return smallest
+}
+
+// Validate returns an error for the first of codes which is not a known currency, suggesting
+// what might have been meant
+func Validate(codes ...string) error {
+	for _, code := range codes {
+		if _, ok := Lookup(code); ok {
+			continue
+		}
+
+		suggestions := Suggest(code)
+		switch len(suggestions) {
+		case 0:
+			return fmt.Errorf("unknown currency %s", code)
+		case 1:
+			return fmt.Errorf("unknown currency %s — did you mean %s?", code, suggestions[0])
+		default:
+			last := len(suggestions) - 1
+			return fmt.Errorf("unknown currency %s — did you mean %s or %s?", code, strings.Join(suggestions[:last], ", "), suggestions[last])
+		}
+	}
+	return nil
+}
+
+// NumericCode returns the numeric code of c as three digits, or an empty string if it has none
+func (c *Currency) NumericCode() string {
+	if c.Numeric == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%03d", c.Numeric)
+}
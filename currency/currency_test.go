@@ -0,0 +1,75 @@
+package currency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	r := require.New(t)
+
+	gbp, ok := Lookup("gbp")
+	r.True(ok)
+	r.Equal(&Currency{Code: "GBP", Name: "Pound Sterling", Numeric: 826, MinorUnits: 2, Symbol: "£"}, gbp)
+	r.Equal("826", gbp.NumericCode())
+
+	_, ok = Lookup("GPB")
+	r.False(ok)
+
+	r.Equal(0, MinorUnits("JPY"))
+	r.Equal(3, MinorUnits("KWD"))
+	r.Equal(NoMinorUnits, MinorUnits("XAU"))
+	r.Equal(2, MinorUnits("QQQ"))
+	r.Equal("€", Symbol("EUR"))
+	r.Equal("CHF", Symbol("CHF"))
+}
+
+func TestRegistryIsConsistent(t *testing.T) {
+	r := require.New(t)
+
+	seen := map[string]bool{}
+	for _, list := range [][]*Currency{iso4217, withdrawn, unofficial} {
+		for _, c := range list {
+			r.False(seen[c.Code], c.Code)
+			seen[c.Code] = true
+			r.Regexp("^[A-Z]{3}$", c.Code)
+			r.NotEmpty(c.Name, c.Code)
+			r.True(c.MinorUnits >= NoMinorUnits && c.MinorUnits <= 8, c.Code)
+			r.Equal(c.Unofficial, c.Numeric == 0, c.Code)
+		}
+	}
+	r.Len(All(), len(seen))
+}
+
+func TestSearch(t *testing.T) {
+	r := require.New(t)
+
+	codes := func(currencies []*Currency) []string {
+		found := []string{}
+		for _, c := range currencies {
+			found = append(found, c.Code)
+		}
+		return found
+	}
+
+	r.Equal([]string{"CHF"}, codes(Search("swiss")))
+	r.Equal([]string{"CHE", "CHW"}, codes(Search("wir")))
+	r.Equal([]string{"GBP"}, codes(Search("826")))
+	r.Equal([]string{"EUR"}, codes(Search("€")))
+	r.Len(Search(""), len(All()))
+}
+
+func TestValidateSuggests(t *testing.T) {
+	r := require.New(t)
+
+	r.NoError(Validate("GBP", "eur", "BTC", "HRK"))
+	r.EqualError(Validate("GBP", "GPB"), "unknown currency GPB — did you mean GBP?")
+	r.EqualError(Validate("EURO"), "unknown currency EURO — did you mean EUR?")
+	r.EqualError(Validate("YEN"), "unknown currency YEN — did you mean JPY?")
+	r.EqualError(Validate("QQQ"), "unknown currency QQQ")
+	r.EqualError(Validate("USF"), "unknown currency USF — did you mean USD or USN?")
+
+	// current currencies are suggested before withdrawn ones
+	r.Equal([]string{"XTS", "LTL"}, Suggest("LTS"))
+}
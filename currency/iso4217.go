@@ -0,0 +1,217 @@
+package currency
+
+// iso4217 lists the currencies of ISO 4217. Symbols are only given where they are widely
+// understood outside of the country which uses the currency, and cash increments only where the
+// smallest coin in use is larger than the minor unit
+var iso4217 = []*Currency{
+	{Code: "AED", Name: "UAE Dirham", Numeric: 784, MinorUnits: 2},
+	{Code: "AFN", Name: "Afghani", Numeric: 971, MinorUnits: 2},
+	{Code: "ALL", Name: "Lek", Numeric: 8, MinorUnits: 2},
+	{Code: "AMD", Name: "Armenian Dram", Numeric: 51, MinorUnits: 2},
+	{Code: "AOA", Name: "Kwanza", Numeric: 973, MinorUnits: 2},
+	{Code: "ARS", Name: "Argentine Peso", Numeric: 32, MinorUnits: 2},
+	{Code: "AUD", Name: "Australian Dollar", Numeric: 36, MinorUnits: 2, Symbol: "A$", Cash: "0.05"},
+	{Code: "AWG", Name: "Aruban Florin", Numeric: 533, MinorUnits: 2},
+	{Code: "AZN", Name: "Azerbaijan Manat", Numeric: 944, MinorUnits: 2},
+	{Code: "BAM", Name: "Convertible Mark", Numeric: 977, MinorUnits: 2},
+	{Code: "BBD", Name: "Barbados Dollar", Numeric: 52, MinorUnits: 2},
+	{Code: "BDT", Name: "Taka", Numeric: 50, MinorUnits: 2},
+	{Code: "BGN", Name: "Bulgarian Lev", Numeric: 975, MinorUnits: 2},
+	{Code: "BHD", Name: "Bahraini Dinar", Numeric: 48, MinorUnits: 3},
+	{Code: "BIF", Name: "Burundi Franc", Numeric: 108, MinorUnits: 0},
+	{Code: "BMD", Name: "Bermudian Dollar", Numeric: 60, MinorUnits: 2},
+	{Code: "BND", Name: "Brunei Dollar", Numeric: 96, MinorUnits: 2},
+	{Code: "BOB", Name: "Boliviano", Numeric: 68, MinorUnits: 2},
+	{Code: "BOV", Name: "Mvdol", Numeric: 984, MinorUnits: 2},
+	{Code: "BRL", Name: "Brazilian Real", Numeric: 986, MinorUnits: 2, Symbol: "R$"},
+	{Code: "BSD", Name: "Bahamian Dollar", Numeric: 44, MinorUnits: 2},
+	{Code: "BTN", Name: "Ngultrum", Numeric: 64, MinorUnits: 2},
+	{Code: "BWP", Name: "Pula", Numeric: 72, MinorUnits: 2},
+	{Code: "BYN", Name: "Belarusian Ruble", Numeric: 933, MinorUnits: 2},
+	{Code: "BZD", Name: "Belize Dollar", Numeric: 84, MinorUnits: 2},
+	{Code: "CAD", Name: "Canadian Dollar", Numeric: 124, MinorUnits: 2, Symbol: "CA$", Cash: "0.05"},
+	{Code: "CDF", Name: "Congolese Franc", Numeric: 976, MinorUnits: 2},
+	{Code: "CHE", Name: "WIR Euro", Numeric: 947, MinorUnits: 2},
+	{Code: "CHF", Name: "Swiss Franc", Numeric: 756, MinorUnits: 2, Cash: "0.05"},
+	{Code: "CHW", Name: "WIR Franc", Numeric: 948, MinorUnits: 2},
+	{Code: "CLF", Name: "Unidad de Fomento", Numeric: 990, MinorUnits: 4},
+	{Code: "CLP", Name: "Chilean Peso", Numeric: 152, MinorUnits: 0},
+	{Code: "CNY", Name: "Yuan Renminbi", Numeric: 156, MinorUnits: 2, Symbol: "CN¥"},
+	{Code: "COP", Name: "Colombian Peso", Numeric: 170, MinorUnits: 2},
+	{Code: "COU", Name: "Unidad de Valor Real", Numeric: 970, MinorUnits: 2},
+	{Code: "CRC", Name: "Costa Rican Colon", Numeric: 188, MinorUnits: 2},
+	{Code: "CUC", Name: "Peso Convertible", Numeric: 931, MinorUnits: 2},
+	{Code: "CUP", Name: "Cuban Peso", Numeric: 192, MinorUnits: 2},
+	{Code: "CVE", Name: "Cabo Verde Escudo", Numeric: 132, MinorUnits: 2},
+	{Code: "CZK", Name: "Czech Koruna", Numeric: 203, MinorUnits: 2, Cash: "1"},
+	{Code: "DJF", Name: "Djibouti Franc", Numeric: 262, MinorUnits: 0},
+	{Code: "DKK", Name: "Danish Krone", Numeric: 208, MinorUnits: 2, Cash: "0.50"},
+	{Code: "DOP", Name: "Dominican Peso", Numeric: 214, MinorUnits: 2},
+	{Code: "DZD", Name: "Algerian Dinar", Numeric: 12, MinorUnits: 2},
+	{Code: "EGP", Name: "Egyptian Pound", Numeric: 818, MinorUnits: 2},
+	{Code: "ERN", Name: "Nakfa", Numeric: 232, MinorUnits: 2},
+	{Code: "ETB", Name: "Ethiopian Birr", Numeric: 230, MinorUnits: 2},
+	{Code: "EUR", Name: "Euro", Numeric: 978, MinorUnits: 2, Symbol: "€"},
+	{Code: "FJD", Name: "Fiji Dollar", Numeric: 242, MinorUnits: 2},
+	{Code: "FKP", Name: "Falkland Islands Pound", Numeric: 238, MinorUnits: 2},
+	{Code: "GBP", Name: "Pound Sterling", Numeric: 826, MinorUnits: 2, Symbol: "£"},
+	{Code: "GEL", Name: "Lari", Numeric: 981, MinorUnits: 2},
+	{Code: "GHS", Name: "Ghana Cedi", Numeric: 936, MinorUnits: 2},
+	{Code: "GIP", Name: "Gibraltar Pound", Numeric: 292, MinorUnits: 2},
+	{Code: "GMD", Name: "Dalasi", Numeric: 270, MinorUnits: 2},
+	{Code: "GNF", Name: "Guinean Franc", Numeric: 324, MinorUnits: 0},
+	{Code: "GTQ", Name: "Quetzal", Numeric: 320, MinorUnits: 2},
+	{Code: "GYD", Name: "Guyana Dollar", Numeric: 328, MinorUnits: 2},
+	{Code: "HKD", Name: "Hong Kong Dollar", Numeric: 344, MinorUnits: 2, Symbol: "HK$"},
+	{Code: "HNL", Name: "Lempira", Numeric: 340, MinorUnits: 2},
+	{Code: "HTG", Name: "Gourde", Numeric: 332, MinorUnits: 2},
+	{Code: "HUF", Name: "Forint", Numeric: 348, MinorUnits: 2, Cash: "5"},
+	{Code: "IDR", Name: "Rupiah", Numeric: 360, MinorUnits: 2},
+	{Code: "ILS", Name: "New Israeli Sheqel", Numeric: 376, MinorUnits: 2, Symbol: "₪"},
+	{Code: "INR", Name: "Indian Rupee", Numeric: 356, MinorUnits: 2, Symbol: "₹"},
+	{Code: "IQD", Name: "Iraqi Dinar", Numeric: 368, MinorUnits: 3},
+	{Code: "IRR", Name: "Iranian Rial", Numeric: 364, MinorUnits: 2},
+	{Code: "ISK", Name: "Iceland Krona", Numeric: 352, MinorUnits: 0},
+	{Code: "JMD", Name: "Jamaican Dollar", Numeric: 388, MinorUnits: 2},
+	{Code: "JOD", Name: "Jordanian Dinar", Numeric: 400, MinorUnits: 3},
+	{Code: "JPY", Name: "Yen", Numeric: 392, MinorUnits: 0, Symbol: "¥"},
+	{Code: "KES", Name: "Kenyan Shilling", Numeric: 404, MinorUnits: 2},
+	{Code: "KGS", Name: "Som", Numeric: 417, MinorUnits: 2},
+	{Code: "KHR", Name: "Riel", Numeric: 116, MinorUnits: 2},
+	{Code: "KMF", Name: "Comorian Franc", Numeric: 174, MinorUnits: 0},
+	{Code: "KPW", Name: "North Korean Won", Numeric: 408, MinorUnits: 2},
+	{Code: "KRW", Name: "Won", Numeric: 410, MinorUnits: 0, Symbol: "₩"},
+	{Code: "KWD", Name: "Kuwaiti Dinar", Numeric: 414, MinorUnits: 3},
+	{Code: "KYD", Name: "Cayman Islands Dollar", Numeric: 136, MinorUnits: 2},
+	{Code: "KZT", Name: "Tenge", Numeric: 398, MinorUnits: 2},
+	{Code: "LAK", Name: "Lao Kip", Numeric: 418, MinorUnits: 2},
+	{Code: "LBP", Name: "Lebanese Pound", Numeric: 422, MinorUnits: 2},
+	{Code: "LKR", Name: "Sri Lanka Rupee", Numeric: 144, MinorUnits: 2},
+	{Code: "LRD", Name: "Liberian Dollar", Numeric: 430, MinorUnits: 2},
+	{Code: "LSL", Name: "Loti", Numeric: 426, MinorUnits: 2},
+	{Code: "LYD", Name: "Libyan Dinar", Numeric: 434, MinorUnits: 3},
+	{Code: "MAD", Name: "Moroccan Dirham", Numeric: 504, MinorUnits: 2},
+	{Code: "MDL", Name: "Moldovan Leu", Numeric: 498, MinorUnits: 2},
+	{Code: "MGA", Name: "Malagasy Ariary", Numeric: 969, MinorUnits: 2},
+	{Code: "MKD", Name: "Denar", Numeric: 807, MinorUnits: 2},
+	{Code: "MMK", Name: "Kyat", Numeric: 104, MinorUnits: 2},
+	{Code: "MNT", Name: "Tugrik", Numeric: 496, MinorUnits: 2},
+	{Code: "MOP", Name: "Pataca", Numeric: 446, MinorUnits: 2},
+	{Code: "MRU", Name: "Ouguiya", Numeric: 929, MinorUnits: 2},
+	{Code: "MUR", Name: "Mauritius Rupee", Numeric: 480, MinorUnits: 2},
+	{Code: "MVR", Name: "Rufiyaa", Numeric: 462, MinorUnits: 2},
+	{Code: "MWK", Name: "Malawi Kwacha", Numeric: 454, MinorUnits: 2},
+	{Code: "MXN", Name: "Mexican Peso", Numeric: 484, MinorUnits: 2, Symbol: "MX$"},
+	{Code: "MXV", Name: "Mexican Unidad de Inversion (UDI)", Numeric: 979, MinorUnits: 2},
+	{Code: "MYR", Name: "Malaysian Ringgit", Numeric: 458, MinorUnits: 2},
+	{Code: "MZN", Name: "Mozambique Metical", Numeric: 943, MinorUnits: 2},
+	{Code: "NAD", Name: "Namibia Dollar", Numeric: 516, MinorUnits: 2},
+	{Code: "NGN", Name: "Naira", Numeric: 566, MinorUnits: 2},
+	{Code: "NIO", Name: "Cordoba Oro", Numeric: 558, MinorUnits: 2},
+	{Code: "NOK", Name: "Norwegian Krone", Numeric: 578, MinorUnits: 2, Cash: "1"},
+	{Code: "NPR", Name: "Nepalese Rupee", Numeric: 524, MinorUnits: 2},
+	{Code: "NZD", Name: "New Zealand Dollar", Numeric: 554, MinorUnits: 2, Symbol: "NZ$", Cash: "0.10"},
+	{Code: "OMR", Name: "Rial Omani", Numeric: 512, MinorUnits: 3},
+	{Code: "PAB", Name: "Balboa", Numeric: 590, MinorUnits: 2},
+	{Code: "PEN", Name: "Sol", Numeric: 604, MinorUnits: 2},
+	{Code: "PGK", Name: "Kina", Numeric: 598, MinorUnits: 2},
+	{Code: "PHP", Name: "Philippine Peso", Numeric: 608, MinorUnits: 2, Symbol: "₱"},
+	{Code: "PKR", Name: "Pakistan Rupee", Numeric: 586, MinorUnits: 2},
+	{Code: "PLN", Name: "Zloty", Numeric: 985, MinorUnits: 2},
+	{Code: "PYG", Name: "Guarani", Numeric: 600, MinorUnits: 0},
+	{Code: "QAR", Name: "Qatari Rial", Numeric: 634, MinorUnits: 2},
+	{Code: "RON", Name: "Romanian Leu", Numeric: 946, MinorUnits: 2},
+	{Code: "RSD", Name: "Serbian Dinar", Numeric: 941, MinorUnits: 2},
+	{Code: "RUB", Name: "Russian Ruble", Numeric: 643, MinorUnits: 2},
+	{Code: "RWF", Name: "Rwanda Franc", Numeric: 646, MinorUnits: 0},
+	{Code: "SAR", Name: "Saudi Riyal", Numeric: 682, MinorUnits: 2},
+	{Code: "SBD", Name: "Solomon Islands Dollar", Numeric: 90, MinorUnits: 2},
+	{Code: "SCR", Name: "Seychelles Rupee", Numeric: 690, MinorUnits: 2},
+	{Code: "SDG", Name: "Sudanese Pound", Numeric: 938, MinorUnits: 2},
+	{Code: "SEK", Name: "Swedish Krona", Numeric: 752, MinorUnits: 2, Cash: "1"},
+	{Code: "SGD", Name: "Singapore Dollar", Numeric: 702, MinorUnits: 2},
+	{Code: "SHP", Name: "Saint Helena Pound", Numeric: 654, MinorUnits: 2},
+	{Code: "SLE", Name: "Leone", Numeric: 925, MinorUnits: 2},
+	{Code: "SOS", Name: "Somali Shilling", Numeric: 706, MinorUnits: 2},
+	{Code: "SRD", Name: "Surinam Dollar", Numeric: 968, MinorUnits: 2},
+	{Code: "SSP", Name: "South Sudanese Pound", Numeric: 728, MinorUnits: 2},
+	{Code: "STN", Name: "Dobra", Numeric: 930, MinorUnits: 2},
+	{Code: "SVC", Name: "El Salvador Colon", Numeric: 222, MinorUnits: 2},
+	{Code: "SYP", Name: "Syrian Pound", Numeric: 760, MinorUnits: 2},
+	{Code: "SZL", Name: "Lilangeni", Numeric: 748, MinorUnits: 2},
+	{Code: "THB", Name: "Baht", Numeric: 764, MinorUnits: 2},
+	{Code: "TJS", Name: "Somoni", Numeric: 972, MinorUnits: 2},
+	{Code: "TMT", Name: "Turkmenistan New Manat", Numeric: 934, MinorUnits: 2},
+	{Code: "TND", Name: "Tunisian Dinar", Numeric: 788, MinorUnits: 3},
+	{Code: "TOP", Name: "Pa'anga", Numeric: 776, MinorUnits: 2},
+	{Code: "TRY", Name: "Turkish Lira", Numeric: 949, MinorUnits: 2},
+	{Code: "TTD", Name: "Trinidad and Tobago Dollar", Numeric: 780, MinorUnits: 2},
+	{Code: "TWD", Name: "New Taiwan Dollar", Numeric: 901, MinorUnits: 2, Symbol: "NT$"},
+	{Code: "TZS", Name: "Tanzanian Shilling", Numeric: 834, MinorUnits: 2},
+	{Code: "UAH", Name: "Hryvnia", Numeric: 980, MinorUnits: 2},
+	{Code: "UGX", Name: "Uganda Shilling", Numeric: 800, MinorUnits: 0},
+	{Code: "USD", Name: "US Dollar", Numeric: 840, MinorUnits: 2, Symbol: "$"},
+	{Code: "USN", Name: "US Dollar (Next day)", Numeric: 997, MinorUnits: 2},
+	{Code: "UYI", Name: "Uruguay Peso en Unidades Indexadas (UI)", Numeric: 940, MinorUnits: 0},
+	{Code: "UYU", Name: "Peso Uruguayo", Numeric: 858, MinorUnits: 2},
+	{Code: "UYW", Name: "Unidad Previsional", Numeric: 927, MinorUnits: 4},
+	{Code: "UZS", Name: "Uzbekistan Sum", Numeric: 860, MinorUnits: 2},
+	{Code: "VED", Name: "Bolivar Soberano", Numeric: 926, MinorUnits: 2},
+	{Code: "VES", Name: "Bolivar Soberano", Numeric: 928, MinorUnits: 2},
+	{Code: "VND", Name: "Dong", Numeric: 704, MinorUnits: 0, Symbol: "₫"},
+	{Code: "VUV", Name: "Vatu", Numeric: 548, MinorUnits: 0},
+	{Code: "WST", Name: "Tala", Numeric: 882, MinorUnits: 2},
+	{Code: "XAF", Name: "CFA Franc BEAC", Numeric: 950, MinorUnits: 0, Symbol: "FCFA"},
+	{Code: "XAG", Name: "Silver", Numeric: 961, MinorUnits: NoMinorUnits},
+	{Code: "XAU", Name: "Gold", Numeric: 959, MinorUnits: NoMinorUnits},
+	{Code: "XBA", Name: "Bond Markets Unit European Composite Unit (EURCO)", Numeric: 955, MinorUnits: NoMinorUnits},
+	{Code: "XBB", Name: "Bond Markets Unit European Monetary Unit (E.M.U.-6)", Numeric: 956, MinorUnits: NoMinorUnits},
+	{Code: "XBC", Name: "Bond Markets Unit European Unit of Account 9 (E.U.A.-9)", Numeric: 957, MinorUnits: NoMinorUnits},
+	{Code: "XBD", Name: "Bond Markets Unit European Unit of Account 17 (E.U.A.-17)", Numeric: 958, MinorUnits: NoMinorUnits},
+	{Code: "XCD", Name: "East Caribbean Dollar", Numeric: 951, MinorUnits: 2, Symbol: "EC$"},
+	{Code: "XCG", Name: "Caribbean Guilder", Numeric: 532, MinorUnits: 2},
+	{Code: "XDR", Name: "SDR (Special Drawing Right)", Numeric: 960, MinorUnits: NoMinorUnits},
+	{Code: "XOF", Name: "CFA Franc BCEAO", Numeric: 952, MinorUnits: 0, Symbol: "F CFA"},
+	{Code: "XPD", Name: "Palladium", Numeric: 964, MinorUnits: NoMinorUnits},
+	{Code: "XPF", Name: "CFP Franc", Numeric: 953, MinorUnits: 0, Symbol: "CFPF"},
+	{Code: "XPT", Name: "Platinum", Numeric: 962, MinorUnits: NoMinorUnits},
+	{Code: "XSU", Name: "Sucre", Numeric: 994, MinorUnits: NoMinorUnits},
+	{Code: "XTS", Name: "Codes specifically reserved for testing purposes", Numeric: 963, MinorUnits: NoMinorUnits},
+	{Code: "XUA", Name: "ADB Unit of Account", Numeric: 965, MinorUnits: NoMinorUnits},
+	{Code: "XXX", Name: "The codes assigned for transactions where no currency is involved", Numeric: 999, MinorUnits: NoMinorUnits},
+	{Code: "YER", Name: "Yemeni Rial", Numeric: 886, MinorUnits: 2},
+	{Code: "ZAR", Name: "Rand", Numeric: 710, MinorUnits: 2, Cash: "0.10"},
+	{Code: "ZMW", Name: "Zambian Kwacha", Numeric: 967, MinorUnits: 2},
+	{Code: "ZWG", Name: "Zimbabwe Gold", Numeric: 924, MinorUnits: 2},
+}
+
+// withdrawn lists currencies which have left ISO 4217 but which rate providers still quote for
+// the days before they were replaced
+var withdrawn = []*Currency{
+	{Code: "ANG", Name: "Netherlands Antillean Guilder", Numeric: 532, MinorUnits: 2, Withdrawn: true},
+	{Code: "BYR", Name: "Belarusian Ruble", Numeric: 974, MinorUnits: 0, Withdrawn: true},
+	{Code: "CYP", Name: "Cyprus Pound", Numeric: 196, MinorUnits: 2, Withdrawn: true},
+	{Code: "EEK", Name: "Kroon", Numeric: 233, MinorUnits: 2, Withdrawn: true},
+	{Code: "HRK", Name: "Kuna", Numeric: 191, MinorUnits: 2, Withdrawn: true},
+	{Code: "LTL", Name: "Lithuanian Litas", Numeric: 440, MinorUnits: 2, Withdrawn: true},
+	{Code: "LVL", Name: "Latvian Lats", Numeric: 428, MinorUnits: 2, Withdrawn: true},
+	{Code: "MRO", Name: "Ouguiya", Numeric: 478, MinorUnits: 2, Withdrawn: true},
+	{Code: "MTL", Name: "Maltese Lira", Numeric: 470, MinorUnits: 2, Withdrawn: true},
+	{Code: "ROL", Name: "Old Leu", Numeric: 642, MinorUnits: 2, Withdrawn: true},
+	{Code: "SIT", Name: "Tolar", Numeric: 705, MinorUnits: 2, Withdrawn: true},
+	{Code: "SKK", Name: "Slovak Koruna", Numeric: 703, MinorUnits: 2, Withdrawn: true},
+	{Code: "SLL", Name: "Leone", Numeric: 694, MinorUnits: 2, Withdrawn: true},
+	{Code: "STD", Name: "Dobra", Numeric: 678, MinorUnits: 2, Withdrawn: true},
+	{Code: "TRL", Name: "Old Turkish Lira", Numeric: 792, MinorUnits: 0, Withdrawn: true},
+	{Code: "VEF", Name: "Bolivar", Numeric: 937, MinorUnits: 2, Withdrawn: true},
+	{Code: "ZMK", Name: "Zambian Kwacha", Numeric: 894, MinorUnits: 2, Withdrawn: true},
+	{Code: "ZWL", Name: "Zimbabwe Dollar", Numeric: 932, MinorUnits: 2, Withdrawn: true},
+}
+
+// unofficial lists currencies with no ISO 4217 code which rate providers quote
+var unofficial = []*Currency{
+	{Code: "BTC", Name: "Bitcoin", MinorUnits: 8, Symbol: "₿", Unofficial: true},
+	{Code: "GGP", Name: "Guernsey Pound", MinorUnits: 2, Unofficial: true},
+	{Code: "IMP", Name: "Manx Pound", MinorUnits: 2, Unofficial: true},
+	{Code: "JEP", Name: "Jersey Pound", MinorUnits: 2, Unofficial: true},
+}
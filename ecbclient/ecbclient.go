@@ -0,0 +1,250 @@
+// Package ecbclient implements the cringletest/RateClient interface using the
+// European Central Bank euro foreign exchange reference rate feeds
+package ecbclient
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"gopkg.in/resty.v1"
+)
+
+// Name is the provider name reported on the rates returned by the client
+const Name = "ecb"
+
+const (
+	// DefaultEndpoint is the location of the ECB reference rate feeds
+	DefaultEndpoint = "https://www.ecb.europa.eu/stats/eurofxref/"
+	dailyFeed       = "eurofxref-daily.xml"
+	recentFeed      = "eurofxref-hist-90d.xml"
+	historicalFeed  = "eurofxref-hist.xml"
+	// the ECB quotes every rate against the euro
+	sourceCurrency = "EUR"
+	recentDays     = 90
+	dateFormat     = "2006-01-02"
+)
+
+type rateClient struct {
+	endpoint string
+	// set the clock as a member of the client so that it can be modified during testing
+	now func() time.Time
+}
+
+type ecbRate struct {
+	Currency string `xml:"currency,attr"`
+	Rate     string `xml:"rate,attr"`
+}
+
+type ecbDay struct {
+	Time  string     `xml:"time,attr"`
+	Rates []*ecbRate `xml:"Cube"`
+}
+
+// ecbEnvelope mirrors the gesmes envelope used by every ECB feed. The rates for each
+// day are held in a Cube nested inside the outer Cube
+type ecbEnvelope struct {
+	XMLName xml.Name  `xml:"Envelope"`
+	Days    []*ecbDay `xml:"Cube>Cube"`
+}
+
+// New returns a new RateClient using the ECB feeds. The client also implements cringletest.RangeRateClient
+func New() cringletest.RateClient {
+	return NewWithEndpoint(DefaultEndpoint)
+}
+
+// NewWithEndpoint returns a new RateClient using the ECB feeds found under endpoint.
+// The feeds are expected to have their standard file names
+func NewWithEndpoint(endpoint string) cringletest.RateClient {
+	if len(endpoint) != 0 && endpoint[len(endpoint)-1] != '/' {
+		endpoint += "/"
+	}
+	return &rateClient{endpoint: endpoint, now: time.Now}
+}
+
+// parse rebases the EUR quoted rates for day onto from
+func parse(day *ecbDay, from string, to ...string) (cringletest.RateMap, error) {
+	date, err := time.Parse(dateFormat, day.Time)
+	if err != nil {
+		return nil, errors.Wrap(err, "bad date returned by ecb feed")
+	}
+
+	quotes := map[string]*decimal.Big{sourceCurrency: decimal.New(1, 0)}
+	for _, rate := range day.Rates {
+		value, ok := new(decimal.Big).SetString(rate.Rate)
+		if !ok {
+			return nil, fmt.Errorf("bad rate %s returned by ecb feed for %s", rate.Rate, rate.Currency)
+		}
+		quotes[rate.Currency] = value
+	}
+
+	fromRate, ok := quotes[from]
+	if !ok {
+		return nil, cringletest.ErrBadFromCurrency
+	}
+
+	rates := cringletest.RateMap{}
+	for _, currency := range to {
+		rate, ok := quotes[currency]
+		if !ok {
+			continue
+		}
+
+		rates[currency] = &cringletest.ExchangeRate{
+			From:     from,
+			To:       currency,
+			Date:     date,
+			Value:    cringletest.RateContext.Quo(new(decimal.Big), rate, fromRate),
+			Provider: Name,
+			Fetched:  time.Now(),
+		}
+	}
+
+	if len(rates) == 0 {
+		return nil, cringletest.ErrBadCurrencies
+	}
+
+	return rates, nil
+}
+
+func (rc *rateClient) fetch(ctx context.Context, feed string) ([]*ecbDay, error) {
+	// if the context already has a deadline set dont set a new one, otherwise use the ECBTimeout
+	_, ok := ctx.Deadline()
+	var cancel context.CancelFunc
+	if !ok {
+		ctx, cancel = context.WithTimeout(ctx, cringletest.ECBTimeout)
+		defer cancel()
+	}
+
+	resp, err := resty.R().
+		SetContext(ctx).
+		Get(rc.endpoint + feed)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "could not make ecb feed request")
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return nil, errors.New("unexpected status code returned from ecb feed")
+	}
+
+	envelope := new(ecbEnvelope)
+	if err := xml.Unmarshal(resp.Body(), envelope); err != nil {
+		return nil, errors.Wrap(err, "could not parse ecb feed")
+	}
+
+	// the feeds are published newest first but make sure of it
+	sort.Slice(envelope.Days, func(i, j int) bool {
+		return envelope.Days[i].Time > envelope.Days[j].Time
+	})
+
+	return envelope.Days, nil
+}
+
+// dayOn returns the most recent day in days which is not after date. The ECB does not
+// publish rates at weekends or on holidays so the rate for those dates is the last one published
+func dayOn(days []*ecbDay, date time.Time) *ecbDay {
+	target := date.Format(dateFormat)
+	for _, day := range days {
+		if day.Time <= target {
+			return day
+		}
+	}
+	return nil
+}
+
+// Implements cringletest.RateClient.Get using the ECB daily feed.
+func (rc *rateClient) Get(ctx context.Context, from string, to ...string) (cringletest.RateMap, error) {
+	if len(to) == 0 {
+		return nil, cringletest.ErrNoToCurrencies
+	}
+
+	days, err := rc.fetch(ctx, dailyFeed)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get live currencies")
+	}
+
+	if len(days) == 0 {
+		return nil, errors.New("ecb daily feed contained no rates")
+	}
+
+	return parse(days[0], from, to...)
+}
+
+// historicalDays returns the days from the smallest feed which covers date. The 90 day feed
+// is used when it covers date, otherwise the full historical feed is used
+func (rc *rateClient) historicalDays(ctx context.Context, date time.Time) ([]*ecbDay, error) {
+	if rc.now().Sub(date) < recentDays*24*time.Hour {
+		days, err := rc.fetch(ctx, recentFeed)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get historical currencies")
+		}
+		if len(days) != 0 && days[len(days)-1].Time <= date.Format(dateFormat) {
+			return days, nil
+		}
+	}
+
+	days, err := rc.fetch(ctx, historicalFeed)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get historical currencies")
+	}
+	return days, nil
+}
+
+// Implements cringletest.RateClient.GetOn using the ECB historical feeds.
+func (rc *rateClient) GetOn(ctx context.Context, date time.Time, from string, to ...string) (cringletest.RateMap, error) {
+	if len(to) == 0 {
+		return nil, cringletest.ErrNoToCurrencies
+	}
+
+	days, err := rc.historicalDays(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	day := dayOn(days, date)
+
+	if day == nil {
+		return nil, fmt.Errorf("no ecb rates published on or before %s", date.Format(dateFormat))
+	}
+
+	return parse(day, from, to...)
+}
+
+// Implements cringletest.RangeRateClient.GetRange using the ECB historical feeds. The ECB does not
+// publish rates at weekends or on holidays so those dates are missing from the series.
+func (rc *rateClient) GetRange(ctx context.Context, start, end time.Time, from string, to ...string) (cringletest.RateSeries, error) {
+	if len(to) == 0 {
+		return nil, cringletest.ErrNoToCurrencies
+	}
+
+	days, err := rc.historicalDays(ctx, start)
+	if err != nil {
+		return nil, err
+	}
+
+	first, last := start.Format(dateFormat), end.Format(dateFormat)
+	series := cringletest.RateSeries{}
+	// the days are newest first so walk them backwards to build the series in date order
+	for i := len(days) - 1; i >= 0; i-- {
+		if days[i].Time < first || days[i].Time > last {
+			continue
+		}
+
+		rates, err := parse(days[i], from, to...)
+		if err != nil {
+			return nil, err
+		}
+
+		date, _ := time.Parse(dateFormat, days[i].Time)
+		series = append(series, &cringletest.DatedRates{Date: date, Rates: rates})
+	}
+
+	return series, nil
+}
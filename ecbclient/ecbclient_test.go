@@ -0,0 +1,186 @@
+package ecbclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/stretchr/testify/require"
+)
+
+// getTestClient returns a client reading the checked in fixtures and a record of the feeds requested
+func getTestClient(t *testing.T, now string) (*rateClient, *[]string, func()) {
+	requested := &[]string{}
+	files := http.FileServer(http.Dir("testdata"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		*requested = append(*requested, req.URL.Path[1:])
+		files.ServeHTTP(w, req)
+	}))
+
+	client := NewWithEndpoint(server.URL).(*rateClient)
+	client.now = func() time.Time { return mustParseDate(t, now) }
+	return client, requested, server.Close
+}
+
+func mustParseDate(t *testing.T, date string) time.Time {
+	d, err := time.Parse(dateFormat, date)
+	require.NoError(t, err)
+	return d
+}
+
+func mustParseDecimal(value string) *decimal.Big {
+	d, _ := new(decimal.Big).SetString(value)
+	return d
+}
+
+func TestGetReturnsRequestedRates(t *testing.T) {
+	r := require.New(t)
+	client, requested, done := getTestClient(t, "2018-05-25")
+	defer done()
+
+	rates, err := client.Get(context.Background(), "EUR", "USD", "GBP")
+	r.NoError(err)
+	r.Equal([]string{dailyFeed}, *requested)
+	r.Equal(0, rates["USD"].Value.Cmp(mustParseDecimal("1.1675")))
+	r.Equal(0, rates["GBP"].Value.Cmp(mustParseDecimal("0.87383")))
+	r.Equal(mustParseDate(t, "2018-05-25"), rates["USD"].Date)
+}
+
+func TestGetRebasesRatesOntoFromCurrency(t *testing.T) {
+	r := require.New(t)
+	client, _, done := getTestClient(t, "2018-05-25")
+	defer done()
+
+	rates, err := client.Get(context.Background(), "GBP", "USD", "EUR")
+	r.NoError(err)
+	r.Equal(0, rates["USD"].Value.Cmp(new(decimal.Big).Quo(mustParseDecimal("1.1675"), mustParseDecimal("0.87383"))))
+	r.Equal(0, rates["EUR"].Value.Cmp(new(decimal.Big).Quo(decimal.New(1, 0), mustParseDecimal("0.87383"))))
+	r.Equal("GBP", rates["USD"].From)
+}
+
+func TestGetReturnsCorrectErrorWhenFromCurrencyDoesNotExist(t *testing.T) {
+	r := require.New(t)
+	client, _, done := getTestClient(t, "2018-05-25")
+	defer done()
+
+	_, err := client.Get(context.Background(), "NopeNopeNope", "USD")
+	r.EqualError(err, cringletest.ErrBadFromCurrency.Error())
+}
+
+func TestGetDoesNotReturnARateForUnknownCurrencies(t *testing.T) {
+	r := require.New(t)
+	client, _, done := getTestClient(t, "2018-05-25")
+	defer done()
+
+	rates, err := client.Get(context.Background(), "GBP", "USD", "NopeNopeNope")
+	r.NoError(err)
+	r.Contains(rates, "USD")
+	r.NotContains(rates, "NopeNopeNope")
+}
+
+func TestGetReturnsCorrectErrorWhenNoCurrenciesCanBeConverted(t *testing.T) {
+	r := require.New(t)
+	client, _, done := getTestClient(t, "2018-05-25")
+	defer done()
+
+	_, err := client.Get(context.Background(), "GBP", "NopeNopeNope")
+	r.EqualError(err, cringletest.ErrBadCurrencies.Error())
+}
+
+func TestGetReturnsCorrectErrorWhenNoToCurrenciesSupplied(t *testing.T) {
+	r := require.New(t)
+	client, _, done := getTestClient(t, "2018-05-25")
+	defer done()
+
+	_, err := client.Get(context.Background(), "GBP")
+	r.EqualError(err, cringletest.ErrNoToCurrencies.Error())
+}
+
+func TestGetOnUsesRecentFeedForRecentDates(t *testing.T) {
+	r := require.New(t)
+	client, requested, done := getTestClient(t, "2018-05-26")
+	defer done()
+
+	rates, err := client.GetOn(context.Background(), mustParseDate(t, "2018-05-24"), "EUR", "USD")
+	r.NoError(err)
+	r.Equal([]string{recentFeed}, *requested)
+	r.Equal(0, rates["USD"].Value.Cmp(mustParseDecimal("1.1728")))
+}
+
+func TestGetOnFallsBackToHistoricalFeed(t *testing.T) {
+	r := require.New(t)
+	client, requested, done := getTestClient(t, "2018-05-26")
+	defer done()
+
+	rates, err := client.GetOn(context.Background(), mustParseDate(t, "2018-05-21"), "EUR", "USD")
+	r.NoError(err)
+	r.Equal([]string{recentFeed, historicalFeed}, *requested)
+	r.Equal(0, rates["USD"].Value.Cmp(mustParseDecimal("1.1781")))
+}
+
+func TestGetOnUsesHistoricalFeedForOldDates(t *testing.T) {
+	r := require.New(t)
+	client, requested, done := getTestClient(t, "2019-01-01")
+	defer done()
+
+	_, err := client.GetOn(context.Background(), mustParseDate(t, "2018-05-22"), "EUR", "USD")
+	r.NoError(err)
+	r.Equal([]string{historicalFeed}, *requested)
+}
+
+func TestGetOnUsesLastPublishedRateForWeekends(t *testing.T) {
+	r := require.New(t)
+	client, _, done := getTestClient(t, "2018-05-28")
+	defer done()
+
+	rates, err := client.GetOn(context.Background(), mustParseDate(t, "2018-05-27"), "EUR", "USD")
+	r.NoError(err)
+	r.Equal(mustParseDate(t, "2018-05-25"), rates["USD"].Date)
+}
+
+func TestGetOnReturnsErrorBeforeFirstPublishedRate(t *testing.T) {
+	r := require.New(t)
+	client, _, done := getTestClient(t, "2019-01-01")
+	defer done()
+
+	_, err := client.GetOn(context.Background(), mustParseDate(t, "2001-01-01"), "EUR", "USD")
+	r.Error(err)
+}
+
+func TestGetReturnsErrorForBadStatus(t *testing.T) {
+	r := require.New(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := NewWithEndpoint(server.URL).Get(context.Background(), "EUR", "USD")
+	r.Error(err)
+}
+
+func TestGetRangeReturnsPublishedDaysInOrder(t *testing.T) {
+	r := require.New(t)
+	client, requested, done := getTestClient(t, "2019-01-01")
+	defer done()
+
+	series, err := client.GetRange(context.Background(), mustParseDate(t, "2018-05-20"), mustParseDate(t, "2018-05-23"), "GBP", "USD")
+	r.NoError(err)
+	r.Equal([]string{historicalFeed}, *requested)
+	r.Len(series, 3)
+	r.Equal(mustParseDate(t, "2018-05-21"), series[0].Date)
+	r.Equal(mustParseDate(t, "2018-05-23"), series[2].Date)
+	r.Equal(0, series[0].Rates["USD"].Value.Cmp(new(decimal.Big).Quo(mustParseDecimal("1.1781"), mustParseDecimal("0.87725"))))
+}
+
+func TestGetRangeUsesRecentFeedWhenItCoversTheRange(t *testing.T) {
+	r := require.New(t)
+	client, requested, done := getTestClient(t, "2018-05-26")
+	defer done()
+
+	series, err := client.GetRange(context.Background(), mustParseDate(t, "2018-05-23"), mustParseDate(t, "2018-05-27"), "EUR", "USD")
+	r.NoError(err)
+	r.Equal([]string{recentFeed}, *requested)
+	r.Len(series, 3)
+}
@@ -0,0 +1,134 @@
+// Package failoverclient implements a cringletest.RateClient which tries a list of
+// providers in priority order, falling back to the next when one is unavailable
+package failoverclient
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/rangeclient"
+)
+
+type rateClient struct {
+	providers []*cringletest.RateProvider
+}
+
+// New returns a cringletest.RangeRateClient which asks each of providers in turn until one
+// answers. A provider which fails with a network error, a timeout or an authentication
+// error is skipped. Any other error is returned immediately because the next provider
+// would be expected to fail in the same way
+func New(providers ...*cringletest.RateProvider) cringletest.RangeRateClient {
+	return &rateClient{providers}
+}
+
+// isUnavailable reports whether err means that the provider could not answer at all
+func isUnavailable(err error) bool {
+	cause := errors.Cause(err)
+
+	if cause == cringletest.ErrBadAuth || cause == cringletest.ErrNoAuth || cause == context.DeadlineExceeded {
+		return true
+	}
+
+	_, ok := cause.(net.Error)
+	return ok
+}
+
+func (rc *rateClient) names() string {
+	names := []string{}
+	for _, provider := range rc.providers {
+		names = append(names, provider.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// stamp records the provider on any rates which do not already say where they came from
+func stamp(rates cringletest.RateMap, provider *cringletest.RateProvider) {
+	for _, rate := range rates {
+		if len(rate.Provider) == 0 {
+			rate.Provider = provider.Name
+		}
+	}
+}
+
+// try calls fetch with each provider in turn until one succeeds
+func (rc *rateClient) try(ctx context.Context, fetch func(*cringletest.RateProvider) error) error {
+	if len(rc.providers) == 0 {
+		return errors.New("no rate providers configured")
+	}
+
+	var err error
+	for _, provider := range rc.providers {
+		// there is no point trying another provider once the caller has given up
+		if ctx.Err() != nil {
+			break
+		}
+
+		err = fetch(provider)
+		if err == nil {
+			return nil
+		}
+
+		if !isUnavailable(err) {
+			return err
+		}
+	}
+
+	if err == nil {
+		err = ctx.Err()
+	}
+	return errors.Wrapf(err, "no rate provider available (tried %s)", rc.names())
+}
+
+func (rc *rateClient) get(ctx context.Context, fetch func(cringletest.RateClient) (cringletest.RateMap, error)) (cringletest.RateMap, error) {
+	var rates cringletest.RateMap
+	err := rc.try(ctx, func(provider *cringletest.RateProvider) error {
+		var err error
+		rates, err = fetch(provider.Client)
+		if err == nil {
+			stamp(rates, provider)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// Implements cringletest.RateClient.Get using the first available provider
+func (rc *rateClient) Get(ctx context.Context, from string, to ...string) (cringletest.RateMap, error) {
+	return rc.get(ctx, func(client cringletest.RateClient) (cringletest.RateMap, error) {
+		return client.Get(ctx, from, to...)
+	})
+}
+
+// Implements cringletest.RateClient.GetOn using the first available provider
+func (rc *rateClient) GetOn(ctx context.Context, date time.Time, from string, to ...string) (cringletest.RateMap, error) {
+	return rc.get(ctx, func(client cringletest.RateClient) (cringletest.RateMap, error) {
+		return client.GetOn(ctx, date, from, to...)
+	})
+}
+
+// Implements cringletest.RangeRateClient.GetRange using the first available provider. The whole
+// series always comes from a single provider
+func (rc *rateClient) GetRange(ctx context.Context, start, end time.Time, from string, to ...string) (cringletest.RateSeries, error) {
+	var series cringletest.RateSeries
+	err := rc.try(ctx, func(provider *cringletest.RateProvider) error {
+		var err error
+		series, err = rangeclient.New(provider.Client, cringletest.RangeConcurrency).GetRange(ctx, start, end, from, to...)
+		if err == nil {
+			for _, day := range series {
+				stamp(day.Rates, provider)
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
@@ -0,0 +1,78 @@
+package failoverclient
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/testclient"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestClient(firstErr, secondErr error) cringletest.RateClient {
+	return New(
+		&cringletest.RateProvider{Name: "first", Client: testclient.New(firstErr)},
+		&cringletest.RateProvider{Name: "second", Client: testclient.New(secondErr)},
+	)
+}
+
+func TestGetUsesFirstProvider(t *testing.T) {
+	r := require.New(t)
+
+	rates, err := getTestClient(nil, nil).Get(context.Background(), "GBP", "EUR")
+	r.NoError(err)
+	r.Equal("first", rates["EUR"].Provider)
+}
+
+func TestGetFallsBackOnBadAuth(t *testing.T) {
+	r := require.New(t)
+
+	rates, err := getTestClient(cringletest.ErrBadAuth, nil).Get(context.Background(), "GBP", "EUR")
+	r.NoError(err)
+	r.Equal("second", rates["EUR"].Provider)
+}
+
+func TestGetOnFallsBackOnNetworkError(t *testing.T) {
+	r := require.New(t)
+
+	netErr := errors.Wrap(&net.OpError{Op: "dial", Err: errors.New("connection refused")}, "could not make api request")
+	rates, err := getTestClient(netErr, nil).GetOn(context.Background(), time.Now(), "GBP", "EUR")
+	r.NoError(err)
+	r.Equal("second", rates["EUR"].Provider)
+}
+
+func TestGetFallsBackOnTimeout(t *testing.T) {
+	r := require.New(t)
+
+	rates, err := getTestClient(context.DeadlineExceeded, nil).Get(context.Background(), "GBP", "EUR")
+	r.NoError(err)
+	r.Equal("second", rates["EUR"].Provider)
+}
+
+func TestGetDoesNotFallBackOnBadCurrency(t *testing.T) {
+	r := require.New(t)
+
+	_, err := getTestClient(cringletest.ErrBadFromCurrency, nil).Get(context.Background(), "GBP", "EUR")
+	r.EqualError(err, cringletest.ErrBadFromCurrency.Error())
+}
+
+func TestGetReturnsLastErrorWhenAllProvidersFail(t *testing.T) {
+	r := require.New(t)
+
+	_, err := getTestClient(cringletest.ErrBadAuth, cringletest.ErrNoAuth).Get(context.Background(), "GBP", "EUR")
+	r.Error(err)
+	r.Equal(cringletest.ErrNoAuth, errors.Cause(err))
+}
+
+func TestGetRangeFallsBackOnBadAuth(t *testing.T) {
+	r := require.New(t)
+
+	client := getTestClient(cringletest.ErrBadAuth, nil).(cringletest.RangeRateClient)
+	series, err := client.GetRange(context.Background(), time.Now().AddDate(0, 0, -2), time.Now(), "GBP", "EUR")
+	r.NoError(err)
+	r.Len(series, 3)
+	r.Equal("second", series[0].Rates["EUR"].Provider)
+}
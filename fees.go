@@ -0,0 +1,150 @@
+package cringletest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ericlagergren/decimal"
+)
+
+// basisPoints is the number of basis points in a whole
+var basisPoints = decimal.New(10000, 0)
+
+// Fees are what a bank or money transfer service charges to convert money, on top of giving a
+// worse rate than the mid-market rate providers report. Any of Percent, Fixed and Spread may be
+// nil when nothing is charged that way
+type Fees struct {
+	// Name is the name of the fee profile the fees came from, if any
+	Name string `json:"name,omitempty"`
+	// Percent is charged on the amount converted, such as 0.5 for half a percent
+	Percent *decimal.Big `json:"percent,omitempty"`
+	// Fixed is charged on every conversion. It must be in the currency converted from or one of
+	// the currencies converted to
+	Fixed *Money `json:"fixed,omitempty"`
+	// Spread is how far below the mid-market rate the rate given is, in basis points
+	Spread *decimal.Big `json:"spread,omitempty"`
+}
+
+// Validate returns an error if fees could never be charged
+func (f *Fees) Validate() error {
+	if f.Percent != nil && (f.Percent.Sign() < 0 || f.Percent.Cmp(decimal.New(100, 0)) >= 0) {
+		return fmt.Errorf("a percentage fee of %s is not between 0 and 100", f.Percent)
+	}
+	if f.Fixed != nil && f.Fixed.Amount.Sign() < 0 {
+		return fmt.Errorf("a fixed fee of %s is negative", f.Fixed)
+	}
+	if f.Spread != nil && (f.Spread.Sign() < 0 || f.Spread.Cmp(basisPoints) >= 0) {
+		return fmt.Errorf("a spread of %s basis points is not between 0 and 10000", f.Spread)
+	}
+	return nil
+}
+
+// String describes fees, such as 0.5% + 2 GBP + 150 bps spread
+func (f *Fees) String() string {
+	parts := []string{}
+	if f.Percent != nil && f.Percent.Sign() != 0 {
+		parts = append(parts, fmt.Sprintf("%s%%", f.Percent))
+	}
+	if f.Fixed != nil && f.Fixed.Amount.Sign() != 0 {
+		parts = append(parts, f.Fixed.String())
+	}
+	if f.Spread != nil && f.Spread.Sign() != 0 {
+		parts = append(parts, fmt.Sprintf("%s bps spread", f.Spread))
+	}
+	if len(parts) == 0 {
+		return "no fees"
+	}
+	return strings.Join(parts, " + ")
+}
+
+// Charges are the fees charged on a Conversion. Gross is what the amount would have bought at
+// the mid-market rate and Fees how much less than that was received, both in the currency
+// converted to. EffectiveRate is what each unit converted bought once every fee was paid
+type Charges struct {
+	Fees          *Fees        `json:"fees"`
+	Gross         *Money       `json:"gross"`
+	Charged       *Money       `json:"charged"`
+	EffectiveRate *decimal.Big `json:"effectiveRate"`
+}
+
+// fixedFee returns the fixed fee of f in the currency of amount, converting it back at the rate
+// to its currency if it is in one of the currencies converted to
+func (f *Fees) fixedFee(amount *Money, rates []*ExchangeRate) (*decimal.Big, error) {
+	if f.Fixed == nil {
+		return new(decimal.Big), nil
+	}
+	if strings.EqualFold(f.Fixed.Currency, amount.Currency) {
+		return f.Fixed.Amount, nil
+	}
+	for _, rate := range rates {
+		if strings.EqualFold(rate.To, f.Fixed.Currency) && rate.Value.Sign() != 0 {
+			return MoneyContext.Quo(new(decimal.Big), f.Fixed.Amount, rate.Value), nil
+		}
+	}
+	return nil, fmt.Errorf("a fixed fee in %s cannot be charged when converting %s", f.Fixed.Currency, amount.Currency)
+}
+
+// ConvertWithFees converts amount at each of rates, less fees, rounding each amount by r. The
+// percentage and fixed fees are taken from amount before it is converted at the rate less the
+// spread. Converted is the net amount received and Charges is set on every Conversion. With nil
+// fees it is the same as Convert
+func ConvertWithFees(amount *Money, rates []*ExchangeRate, r *Rounding, fees *Fees) ([]*Conversion, error) {
+	if fees == nil {
+		return Convert(amount, rates, r)
+	}
+	if err := fees.Validate(); err != nil {
+		return nil, err
+	}
+
+	fixed, err := fees.fixedFee(amount, rates)
+	if err != nil {
+		return nil, err
+	}
+
+	// what is left of amount once the percentage and fixed fees are paid
+	left := new(decimal.Big).Copy(amount.Amount)
+	if fees.Percent != nil {
+		percent := MoneyContext.Mul(new(decimal.Big), amount.Amount, fees.Percent)
+		MoneyContext.Sub(left, left, MoneyContext.Quo(percent, percent, decimal.New(100, 0)))
+	}
+	MoneyContext.Sub(left, left, fixed)
+	if left.Sign() < 0 {
+		return nil, fmt.Errorf("fees of %s are more than the %s converted", fees, amount)
+	}
+
+	// the share of the mid-market rate given
+	given := decimal.New(1, 0)
+	if fees.Spread != nil {
+		given = MoneyContext.Quo(new(decimal.Big), fees.Spread, basisPoints)
+		MoneyContext.Sub(given, decimal.New(1, 0), given)
+	}
+
+	conversions := []*Conversion{}
+	for _, rate := range rates {
+		gross, err := amount.Convert(rate, r)
+		if err != nil {
+			return nil, err
+		}
+
+		net := MoneyContext.Mul(new(decimal.Big), left, rate.Value)
+		net = r.Round(MoneyContext.Mul(net, net, given), rate.To)
+
+		effective := new(decimal.Big)
+		if amount.Amount.Sign() != 0 {
+			RateContext.Quo(effective, net, amount.Amount)
+		}
+
+		conversions = append(conversions, &Conversion{
+			Amount:    amount,
+			Rate:      rate,
+			Converted: NewMoney(net, rate.To),
+			Charges: &Charges{
+				Fees:          fees,
+				Gross:         gross,
+				Charged:       NewMoney(MoneyContext.Sub(new(decimal.Big), gross.Amount, net), rate.To),
+				EffectiveRate: effective,
+			},
+		})
+	}
+	return conversions, nil
+}
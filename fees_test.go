@@ -0,0 +1,71 @@
+package cringletest
+
+import (
+	"testing"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertWithFees(t *testing.T) {
+	r := require.New(t)
+
+	rates := []*ExchangeRate{
+		{From: "GBP", To: "EUR", Value: decimal.New(115, 2)},
+		{From: "GBP", To: "USD", Value: decimal.New(125, 2)},
+	}
+	fees := &Fees{Percent: decimal.New(5, 1), Fixed: NewMoney(decimal.New(2, 0), "GBP"), Spread: decimal.New(150, 0)}
+	r.Equal("0.5% + 2 GBP + 150 bps spread", fees.String())
+
+	conversions, err := ConvertWithFees(NewMoney(decimal.New(1000, 0), "GBP"), rates, DefaultRounding, fees)
+	r.NoError(err)
+	r.Len(conversions, 2)
+
+	// (1000 - 5 - 2) * 1.15 * 0.985 = 1124.82
+	eur := conversions[0]
+	r.Equal("1124.82 EUR", eur.Converted.String())
+	r.Equal("1150.00 EUR", eur.Charges.Gross.String())
+	r.Equal("25.18 EUR", eur.Charges.Charged.String())
+	r.Equal("1.12482", eur.Charges.EffectiveRate.String())
+	r.Equal(fees, eur.Charges.Fees)
+
+	// (1000 - 5 - 2) * 1.25 * 0.985 = 1222.63
+	r.Equal("1222.63 USD", conversions[1].Converted.String())
+
+	conversions, err = ConvertWithFees(NewMoney(decimal.New(1000, 0), "GBP"), rates, DefaultRounding, nil)
+	r.NoError(err)
+	r.Nil(conversions[0].Charges)
+	r.Equal("1150.00 EUR", conversions[0].Converted.String())
+}
+
+func TestConvertWithFixedFeeInTargetCurrency(t *testing.T) {
+	r := require.New(t)
+
+	rates := []*ExchangeRate{{From: "GBP", To: "EUR", Value: decimal.New(125, 2)}}
+	fees := &Fees{Fixed: NewMoney(decimal.New(5, 0), "EUR")}
+
+	// the 5 EUR fee is 4 GBP at the rate
+	conversions, err := ConvertWithFees(NewMoney(decimal.New(100, 0), "GBP"), rates, DefaultRounding, fees)
+	r.NoError(err)
+	r.Equal("120.00 EUR", conversions[0].Converted.String())
+	r.Equal("5.00 EUR", conversions[0].Charges.Charged.String())
+
+	fees.Fixed.Currency = "USD"
+	_, err = ConvertWithFees(NewMoney(decimal.New(100, 0), "GBP"), rates, DefaultRounding, fees)
+	r.EqualError(err, "a fixed fee in USD cannot be charged when converting GBP")
+}
+
+func TestConvertWithFeesRejectsImpossibleFees(t *testing.T) {
+	r := require.New(t)
+
+	rates := []*ExchangeRate{{From: "GBP", To: "EUR", Value: decimal.New(115, 2)}}
+	amount := NewMoney(decimal.New(10, 0), "GBP")
+
+	_, err := ConvertWithFees(amount, rates, DefaultRounding, &Fees{Fixed: NewMoney(decimal.New(11, 0), "GBP")})
+	r.EqualError(err, "fees of 11 GBP are more than the 10 GBP converted")
+
+	_, err = ConvertWithFees(amount, rates, DefaultRounding, &Fees{Percent: decimal.New(100, 0)})
+	r.Error(err)
+	_, err = ConvertWithFees(amount, rates, DefaultRounding, &Fees{Spread: decimal.New(-1, 0)})
+	r.Error(err)
+}
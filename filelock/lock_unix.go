@@ -0,0 +1,34 @@
+//go:build !windows
+// +build !windows
+
+// Package filelock takes advisory locks on whole files so that cconv processes sharing a file
+// do not trip over each other
+package filelock
+
+import (
+	"os"
+	"syscall"
+)
+
+// Lock takes an advisory lock on f, shared or exclusive, waiting until it is available. It
+// returns a function which releases the lock
+func Lock(f *os.File, exclusive bool) (func(), error) {
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+		if err == nil {
+			break
+		}
+		if err != syscall.EINTR {
+			return nil, err
+		}
+	}
+
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	}, nil
+}
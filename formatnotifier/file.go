@@ -0,0 +1,69 @@
+package formatnotifier
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+)
+
+// extensionFormats are the formats used for files with each extension
+var extensionFormats = map[string]string{
+	".json":  JSON,
+	".jsonl": JSONL,
+	".csv":   CSV,
+	".tsv":   TSV,
+	".md":    Markdown,
+}
+
+// appendFile is an io.Writer which appends each write to the file at its path, creating the
+// file if it does not exist. The file is only open while it is written to
+type appendFile string
+
+func (f appendFile) Write(p []byte) (int, error) {
+	file, err := os.OpenFile(string(f), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := file.Write(p)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return n, err
+}
+
+// NewFile returns a cringletest.Notifier which appends each notification to the file at path in
+// format. If format is empty it is chosen from the extension of path, or JSONL if the extension
+// is not known
+func NewFile(path, format string) (cringletest.Notifier, error) {
+	if len(path) == 0 {
+		return nil, errors.New("no file path")
+	}
+
+	if len(format) == 0 {
+		format = JSONL
+		if f, ok := extensionFormats[strings.ToLower(filepath.Ext(path))]; ok {
+			format = f
+		}
+	}
+	return NewWithWriter(format, appendFile(path))
+}
+
+func init() {
+	// file:///var/log/cconv.jsonl for an absolute path or file:cconv.csv for a relative one, with
+	// an optional ?format=
+	cringletest.RegisterNotifier("file", func(u *url.URL) (cringletest.Notifier, error) {
+		path := u.Path
+		if len(u.Opaque) != 0 {
+			path = u.Opaque
+		}
+		if len(u.Host) != 0 && u.Host != "localhost" {
+			return nil, errors.Errorf("file urls cannot name a host, use file:///%s", strings.TrimPrefix(u.Host+path, "/"))
+		}
+		return NewFile(path, u.Query().Get("format"))
+	})
+}
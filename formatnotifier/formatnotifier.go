@@ -0,0 +1,176 @@
+// Package formatnotifier implements cringletest.Notifier by writing machine-readable reports
+// in one of several formats
+package formatnotifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/report"
+)
+
+// The supported formats
+const (
+	JSON     = "json"
+	JSONL    = "jsonl"
+	CSV      = "csv"
+	TSV      = "tsv"
+	Markdown = "markdown"
+)
+
+// Formats lists every supported format
+var Formats = []string{JSON, JSONL, CSV, TSV, Markdown}
+
+// ErrUnknownFormat is returned when a notifier is requested for an unsupported format
+var ErrUnknownFormat = errors.New("unknown output format")
+
+type encoder func(io.Writer, *report.Document) error
+
+type notifier struct {
+	// set the io.Writer as a member of the notifier so that it can be
+	// modified during testing
+	out    io.Writer
+	encode encoder
+}
+
+// New returns a cringletest.Notifier which writes each notification to stdout in format
+func New(format string) (cringletest.Notifier, error) {
+	return NewWithWriter(format, os.Stdout)
+}
+
+// NewWithWriter returns a cringletest.Notifier which writes each notification to out in format
+func NewWithWriter(format string, out io.Writer) (cringletest.Notifier, error) {
+	encoders := map[string]encoder{
+		JSON:     encodeJSON,
+		JSONL:    encodeJSONL,
+		CSV:      encodeDelimited(','),
+		TSV:      encodeDelimited('\t'),
+		Markdown: encodeMarkdown,
+	}
+
+	encode, ok := encoders[format]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownFormat, "%s, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+	return &notifier{out: out, encode: encode}, nil
+}
+
+func encodeJSON(out io.Writer, doc *report.Document) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// encodeJSONL writes doc as a single line so that a file of notifications has one per line
+func encodeJSONL(out io.Writer, doc *report.Document) error {
+	return json.NewEncoder(out).Encode(doc)
+}
+
+func encodeDelimited(comma rune) encoder {
+	return func(out io.Writer, doc *report.Document) error {
+		w := csv.NewWriter(out)
+		w.Comma = comma
+		columns, rows := doc.Table()
+		if err := w.Write(columns); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := w.Write(row); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	}
+}
+
+// markdownCell escapes the characters which would break a markdown table
+func markdownCell(s string) string {
+	s = strings.Replace(s, "|", `\|`, -1)
+	return strings.Replace(s, "\n", " ", -1)
+}
+
+func writeMarkdownTable(out io.Writer, columns []string, rows [][]string) {
+	fmt.Fprintf(out, "| %s |\n", strings.Join(columns, " | "))
+	fmt.Fprintf(out, "|%s\n", strings.Repeat(" --- |", len(columns)))
+	for _, row := range rows {
+		cells := []string{}
+		for _, cell := range row {
+			cells = append(cells, markdownCell(cell))
+		}
+		fmt.Fprintf(out, "| %s |\n", strings.Join(cells, " | "))
+	}
+}
+
+func encodeMarkdown(out io.Writer, doc *report.Document) error {
+	columns, rows := doc.Table()
+	writeMarkdownTable(out, columns, rows)
+
+	if doc.Best != nil && doc.Best.Stats != nil {
+		stats := doc.Best.Stats
+		fmt.Fprintln(out)
+		writeMarkdownTable(out, []string{"statistic", "value"}, [][]string{
+			{"count", fmt.Sprintf("%d", stats.Count)},
+			{"min", stats.Min},
+			{"max", stats.Max},
+			{"mean", stats.Mean},
+			{"median", stats.Median},
+			{"stddev", stats.StdDev},
+			{"gap_percent", stats.Gap},
+		})
+	}
+
+	_, err := fmt.Fprintln(out)
+	return err
+}
+
+// write encodes doc in full before writing it so that each notification reaches out in a
+// single write
+func (n *notifier) write(doc *report.Document) error {
+	if len(doc.Rows) == 0 {
+		return cringletest.ErrNoRates
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := n.encode(buf, doc); err != nil {
+		return err
+	}
+	_, err := n.out.Write(buf.Bytes())
+	return err
+}
+
+func (n *notifier) NotifyRates(ctx context.Context, rates []*cringletest.ExchangeRate) error {
+	return n.write(report.FromRates(rates))
+}
+
+func (n *notifier) NotifyValue(ctx context.Context, conversions []*cringletest.Conversion) error {
+	return n.write(report.FromValue(conversions))
+}
+
+func (n *notifier) NotifyBest(ctx context.Context, best *cringletest.BestRate) error {
+	return n.write(report.FromBest(best))
+}
+
+func (n *notifier) NotifyHistory(ctx context.Context, history *cringletest.RateHistory) error {
+	return n.write(report.FromHistory(history))
+}
+
+func (n *notifier) NotifyAlert(ctx context.Context, alert *cringletest.Alert) error {
+	return n.write(report.FromAlert(alert))
+}
+
+func (n *notifier) NotifyNeed(ctx context.Context, requirements []*cringletest.Requirement) error {
+	return n.write(report.FromNeed(requirements))
+}
+
+func (n *notifier) NotifyMatrix(ctx context.Context, matrix *cringletest.RateMatrix) error {
+	return n.write(report.FromMatrix(matrix))
+}
@@ -0,0 +1,159 @@
+package formatnotifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/report"
+	"github.com/stretchr/testify/require"
+)
+
+var testDate = time.Date(2018, 5, 25, 0, 0, 0, 0, time.UTC)
+
+func getTestNotifier(t *testing.T, format string) (cringletest.Notifier, *bytes.Buffer) {
+	buf := bytes.NewBuffer(nil)
+	sender, err := NewWithWriter(format, buf)
+	require.NoError(t, err)
+	return sender, buf
+}
+
+func getTestRates() []*cringletest.ExchangeRate {
+	return []*cringletest.ExchangeRate{
+		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: testDate, Value: decimal.New(1234, 3), Provider: "ecb"},
+		&cringletest.ExchangeRate{From: "ABC", To: "GHI", Date: testDate, Value: decimal.New(5, 1), Warning: "a, \"quoted\" | warning"},
+	}
+}
+
+func getTestConversions(r *require.Assertions) []*cringletest.Conversion {
+	conversions, err := cringletest.Convert(cringletest.NewMoney(decimal.New(2, 0), "ABC"), getTestRates(), cringletest.DefaultRounding)
+	r.NoError(err)
+	return conversions
+}
+
+func TestNewFailsWithUnknownFormat(t *testing.T) {
+	_, err := New("yaml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), ErrUnknownFormat.Error())
+}
+
+func TestNotifyValueWritesJSON(t *testing.T) {
+	r := require.New(t)
+	sender, buf := getTestNotifier(t, JSON)
+
+	err := sender.NotifyValue(context.Background(), getTestConversions(r))
+	r.NoError(err)
+
+	doc := &report.Document{}
+	r.NoError(json.Unmarshal(buf.Bytes(), doc))
+	r.Equal(report.Schema, doc.Schema)
+	r.Equal(report.KindValue, doc.Kind)
+	r.Len(doc.Rows, 2)
+	r.Equal("1.234", doc.Rows[0].Rate)
+	r.Equal("2.47", doc.Rows[0].Converted)
+	r.Equal("ecb", doc.Rows[0].Provider)
+}
+
+func TestNotifyRatesWritesCSV(t *testing.T) {
+	r := require.New(t)
+	sender, buf := getTestNotifier(t, CSV)
+
+	err := sender.NotifyRates(context.Background(), getTestRates())
+	r.NoError(err)
+
+	expected := "from,to,date,rate,amount,converted,provider,warning\n" +
+		"ABC,DEF,2018-05-25,1.234,1,1.234,ecb,\n" +
+		"ABC,GHI,2018-05-25,0.5,1,0.5,,\"a, \"\"quoted\"\" | warning\"\n"
+	r.Equal(expected, buf.String())
+}
+
+func TestNotifyRatesWritesTSV(t *testing.T) {
+	r := require.New(t)
+	sender, buf := getTestNotifier(t, TSV)
+
+	err := sender.NotifyRates(context.Background(), getTestRates()[:1])
+	r.NoError(err)
+
+	expected := "from\tto\tdate\trate\tamount\tconverted\tprovider\twarning\n" +
+		"ABC\tDEF\t2018-05-25\t1.234\t1\t1.234\tecb\t\n"
+	r.Equal(expected, buf.String())
+}
+
+func TestNotifyBestWritesMarkdown(t *testing.T) {
+	r := require.New(t)
+	sender, buf := getTestNotifier(t, Markdown)
+
+	rates := getTestRates()
+	err := sender.NotifyBest(context.Background(), &cringletest.BestRate{Rate: rates[1], Days: 7})
+	r.NoError(err)
+
+	expected := "| from | to | date | rate | amount | converted | provider | warning |\n" +
+		"| --- | --- | --- | --- | --- | --- | --- | --- |\n" +
+		"| ABC | GHI | 2018-05-25 | 0.5 | 1 | 0.5 |  | a, \"quoted\" \\| warning |\n\n"
+	r.Equal(expected, buf.String())
+}
+
+func TestNotifyRatesFailsWithNoRates(t *testing.T) {
+	sender, _ := getTestNotifier(t, JSON)
+
+	err := sender.NotifyRates(context.Background(), nil)
+	require.EqualError(t, err, cringletest.ErrNoRates.Error())
+}
+
+func TestFileNotifierAppendsJSONL(t *testing.T) {
+	r := require.New(t)
+
+	dir, err := ioutil.TempDir("", "formatnotifier")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cconv.log")
+	sender, err := cringletest.NewNotifier("file://" + filepath.ToSlash(path))
+	r.NoError(err)
+
+	r.NoError(sender.NotifyRates(context.Background(), getTestRates()))
+	r.NoError(sender.NotifyValue(context.Background(), getTestConversions(r)))
+
+	data, err := ioutil.ReadFile(path)
+	r.NoError(err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	r.Len(lines, 2)
+	for i, kind := range []string{report.KindRates, report.KindValue} {
+		doc := &report.Document{}
+		r.NoError(json.Unmarshal([]byte(lines[i]), doc))
+		r.Equal(kind, doc.Kind)
+	}
+}
+
+func TestFileNotifierChoosesFormat(t *testing.T) {
+	r := require.New(t)
+
+	dir, err := ioutil.TempDir("", "formatnotifier")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "rates.csv")
+	sender, err := NewFile(path, "")
+	r.NoError(err)
+	r.NoError(sender.NotifyRates(context.Background(), getTestRates()[:1]))
+
+	data, err := ioutil.ReadFile(path)
+	r.NoError(err)
+	r.Equal("from,to,date,rate,amount,converted,provider,warning\nABC,DEF,2018-05-25,1.234,1,1.234,ecb,\n", string(data))
+
+	_, err = cringletest.NewNotifier("file://" + filepath.ToSlash(path) + "?format=yaml")
+	r.Error(err)
+	r.Contains(err.Error(), ErrUnknownFormat.Error())
+
+	_, err = cringletest.NewNotifier("file://example.com/rates.csv")
+	r.EqualError(err, "notifier file://example.com/rates.csv: file urls cannot name a host, use file:///example.com/rates.csv")
+}
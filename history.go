@@ -0,0 +1,32 @@
+package cringletest
+
+import "time"
+
+// The intervals a RateHistory can be sampled at
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// RateHistory describes how the rates from From to each of To moved between Start and End,
+// with Series holding one set of rates per Interval
+type RateHistory struct {
+	From     string
+	To       []string
+	Start    time.Time
+	End      time.Time
+	Interval string
+	Series   RateSeries
+}
+
+// Rates returns the rates to currency in date order
+func (h *RateHistory) Rates(currency string) []*ExchangeRate {
+	rates := []*ExchangeRate{}
+	for _, day := range h.Series {
+		if rate, ok := day.Rates[currency]; ok {
+			rates = append(rates, rate)
+		}
+	}
+	return rates
+}
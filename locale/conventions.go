@@ -0,0 +1,102 @@
+package locale
+
+import (
+	"github.com/robotlovesyou/cringletest/currency"
+	"golang.org/x/text/language"
+)
+
+// conventions are how a locale writes numbers, money and dates
+type conventions struct {
+	decimal string
+	group   string
+	percent string
+	// symbolFirst puts the currency symbol before the amount and space separates them with a space
+	symbolFirst bool
+	space       bool
+	// symbols override the usual symbol of a currency
+	symbols map[string]string
+	// date is a format for fmt.Sprintf given the abbreviated weekday, abbreviated month, day,
+	// year and month number, and time a layout for time.Format
+	date   string
+	time   string
+	days   []string
+	months []string
+}
+
+// symbol returns the symbol c writes the currency with code with, which is its code if it has none
+func (c *conventions) symbol(code string) string {
+	if symbol, ok := c.symbols[code]; ok {
+		return symbol
+	}
+	return currency.Symbol(code)
+}
+
+var englishDays = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+var englishMonths = []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+
+// supported are the locales with conventions of their own, the first of which is used for tags
+// which are closest to none of them
+var supported = []struct {
+	tag language.Tag
+	*conventions
+}{
+	{language.AmericanEnglish, &conventions{
+		decimal: ".", group: ",", percent: "%", symbolFirst: true,
+		date: "%[1]s, %[2]s %[3]d, %[4]d", time: "3:04 PM MST", days: englishDays, months: englishMonths,
+	}},
+	{language.BritishEnglish, &conventions{
+		decimal: ".", group: ",", percent: "%", symbolFirst: true,
+		symbols: map[string]string{"USD": "US$"},
+		date:    "%[1]s %[3]d %[2]s %[4]d", time: "15:04 MST", days: englishDays, months: englishMonths,
+	}},
+	{language.German, &conventions{
+		decimal: ",", group: ".", percent: "\u00a0%", space: true,
+		date: "%[1]s, %[3]d. %[2]s %[4]d", time: "15:04 MST",
+		days:   []string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+		months: []string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+	}},
+	{language.French, &conventions{
+		decimal: ",", group: "\u202f", percent: "\u202f%", space: true,
+		symbols: map[string]string{"USD": "$US"},
+		date:    "%[1]s %[3]d %[2]s %[4]d", time: "15:04 MST",
+		days:   []string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		months: []string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+	}},
+	{language.Spanish, &conventions{
+		decimal: ",", group: ".", percent: "\u00a0%", space: true,
+		symbols: map[string]string{"USD": "US$"},
+		date:    "%[1]s, %[3]d %[2]s %[4]d", time: "15:04 MST",
+		days:   []string{"dom.", "lun.", "mar.", "mié.", "jue.", "vie.", "sáb."},
+		months: []string{"ene.", "feb.", "mar.", "abr.", "may.", "jun.", "jul.", "ago.", "sept.", "oct.", "nov.", "dic."},
+	}},
+	{language.Italian, &conventions{
+		decimal: ",", group: ".", percent: "%", space: true,
+		symbols: map[string]string{"USD": "USD"},
+		date:    "%[1]s %[3]d %[2]s %[4]d", time: "15:04 MST",
+		days:   []string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+		months: []string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+	}},
+	{language.Dutch, &conventions{
+		decimal: ",", group: ".", percent: "%", symbolFirst: true, space: true,
+		symbols: map[string]string{"USD": "US$"},
+		date:    "%[1]s %[3]d %[2]s %[4]d", time: "15:04 MST",
+		days:   []string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+		months: []string{"jan.", "feb.", "mrt.", "apr.", "mei", "jun.", "jul.", "aug.", "sep.", "okt.", "nov.", "dec."},
+	}},
+	{language.Japanese, &conventions{
+		decimal: ".", group: ",", percent: "%", symbolFirst: true,
+		symbols: map[string]string{"JPY": "￥", "CNY": "元"},
+		date:    "%[4]d年%[5]d月%[3]d日(%[1]s)", time: "15:04 MST",
+		days:   []string{"日", "月", "火", "水", "木", "金", "土"},
+		months: []string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+	}},
+}
+
+var matcher = func() language.Matcher {
+	tags := []language.Tag{}
+	for _, s := range supported {
+		tags = append(tags, s.tag)
+	}
+	return language.NewMatcher(tags)
+}()
@@ -0,0 +1,207 @@
+// Package locale formats numbers, amounts of money and dates for people who expect the
+// conventions of their own language and region
+package locale
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest/currency"
+	"golang.org/x/text/language"
+)
+
+// RatePlaces is the number of decimal places exchange rates are formatted with
+const RatePlaces = 4
+
+const (
+	plainDateFormat = "Mon 02 Jan 2006"
+	plainTimeFormat = "15:04 MST"
+)
+
+// nbsp keeps amounts of money on one line with their currency
+const nbsp = "\u00a0"
+
+// Locale formats numbers, money and dates by the conventions of a language and region
+type Locale struct {
+	tag language.Tag
+	// plain formats as cconv always has, with no grouping, four decimal places for every
+	// currency, currency codes instead of symbols and english dates
+	plain bool
+	*conventions
+}
+
+// Plain is the locale used when none is chosen. Numbers have no grouping, amounts of money
+// have four decimal places and are followed by their currency code, and dates are in english,
+// such as Fri 25 May 2018
+var Plain = &Locale{tag: language.Und, plain: true, conventions: &conventions{decimal: ".", percent: "%"}}
+
+// Parse returns the Locale for a language tag such as de, de-DE or ja-JP. An empty tag is
+// Plain. It is an error if none of the supported locales is close enough to the tag
+func Parse(s string) (*Locale, error) {
+	if len(strings.TrimSpace(s)) == 0 {
+		return Plain, nil
+	}
+
+	tag, err := language.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid locale %s", s)
+	}
+
+	_, i, confidence := matcher.Match(tag)
+	if confidence == language.No {
+		return nil, fmt.Errorf("unsupported locale %s, expected one of %s", s, strings.Join(Supported(), ", "))
+	}
+	return &Locale{tag: tag, conventions: supported[i].conventions}, nil
+}
+
+// Supported returns the tags of the locales with conventions of their own. Other tags are
+// formatted by the closest of them
+func Supported() []string {
+	tags := []string{}
+	for _, s := range supported {
+		tags = append(tags, s.tag.String())
+	}
+	return tags
+}
+
+// String returns the language tag of l, which is empty for Plain
+func (l *Locale) String() string {
+	if l.plain {
+		return ""
+	}
+	return l.tag.String()
+}
+
+// IsPlain reports whether l formats as cconv always has
+func (l *Locale) IsPlain() bool {
+	return l.plain
+}
+
+// localize replaces the separators of a number formatted by fmt with those of l
+func (l *Locale) localize(s string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+
+	whole, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, fraction = s[:i], s[i+1:]
+	}
+
+	if len(l.group) != 0 && len(whole) > 3 {
+		groups := []string{}
+		for len(whole) > 3 {
+			groups = append([]string{whole[len(whole)-3:]}, groups...)
+			whole = whole[:len(whole)-3]
+		}
+		whole = strings.Join(append([]string{whole}, groups...), l.group)
+	}
+
+	if len(fraction) != 0 {
+		whole += l.decimal + fraction
+	}
+	return sign + whole
+}
+
+// Number formats d with places decimal places
+func (l *Locale) Number(d *decimal.Big, places int) string {
+	return l.localize(fmt.Sprintf("%.*f", places, d))
+}
+
+// Signed formats d with places decimal places and a sign even when it is positive
+func (l *Locale) Signed(d *decimal.Big, places int) string {
+	return l.localize(fmt.Sprintf("%+.*f", places, d))
+}
+
+// Rate formats an exchange rate
+func (l *Locale) Rate(d *decimal.Big) string {
+	return l.Number(d, RatePlaces)
+}
+
+// Percent adds a percent sign to number, which is already formatted
+func (l *Locale) Percent(number string) string {
+	return number + l.percent
+}
+
+// Places returns the number of decimal places amounts of the currency with code are formatted with
+func (l *Locale) Places(code string) int {
+	if l.plain {
+		return RatePlaces
+	}
+	if units := currency.MinorUnits(code); units != currency.NoMinorUnits {
+		return units
+	}
+	// currencies such as gold are only ever quoted by weight
+	return RatePlaces
+}
+
+// Amount formats d as an amount of the currency with code, without its symbol
+func (l *Locale) Amount(d *decimal.Big, code string) string {
+	return l.Number(d, l.Places(code))
+}
+
+// Currency places the symbol of the currency with code, or the code if it has no symbol, around
+// number, which is already formatted
+func (l *Locale) Currency(number, code string) string {
+	if l.plain {
+		return number + " " + code
+	}
+
+	symbol := l.symbol(code)
+	// codes are always kept apart from the number
+	space := l.space || symbol == code
+	switch {
+	case l.symbolFirst && space:
+		return symbol + nbsp + number
+	case l.symbolFirst:
+		return symbol + number
+	default:
+		return number + nbsp + symbol
+	}
+}
+
+// Money formats d as an amount of the currency with code along with its symbol
+func (l *Locale) Money(d *decimal.Big, code string) string {
+	return l.Currency(l.Amount(d, code), code)
+}
+
+// Date formats the day of t
+func (l *Locale) Date(t time.Time) string {
+	if l.plain {
+		return t.Format(plainDateFormat)
+	}
+	return fmt.Sprintf(l.date, l.days[t.Weekday()], l.months[t.Month()-1], t.Day(), t.Year(), int(t.Month()))
+}
+
+// DateTime formats the day and time of t
+func (l *Locale) DateTime(t time.Time) string {
+	if l.plain {
+		return t.Format(plainDateFormat + " " + plainTimeFormat)
+	}
+	return l.Date(t) + " " + t.Format(l.time)
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLocale = Plain
+)
+
+// Default returns the Locale the notifiers format with unless they are given one, which is
+// Plain unless SetDefault has been called
+func Default() *Locale {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLocale
+}
+
+// SetDefault makes l the Locale the notifiers format with unless they are given one
+func SetDefault(l *Locale) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLocale = l
+}
@@ -0,0 +1,90 @@
+package locale
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+var testTime = time.Date(2018, 5, 25, 9, 30, 0, 0, time.UTC)
+
+func TestPlainFormatsAsBefore(t *testing.T) {
+	r := require.New(t)
+
+	l, err := Parse("")
+	r.NoError(err)
+	r.Equal(Plain, l)
+	r.True(l.IsPlain())
+
+	r.Equal("1234567.8900", l.Rate(decimal.New(123456789, 2)))
+	r.Equal("1234.5000 JPY", l.Money(decimal.New(12345, 1), "JPY"))
+	r.Equal("+0.1000", l.Signed(decimal.New(1, 1), RatePlaces))
+	r.Equal("Fri 25 May 2018", l.Date(testTime))
+	r.Equal("Fri 25 May 2018 09:30 UTC", l.DateTime(testTime))
+}
+
+func TestLocalesFormatNumbersMoneyAndDates(t *testing.T) {
+	r := require.New(t)
+
+	amount := decimal.New(123456789, 3)
+	tests := []struct {
+		tag, number, eur, jpy, kwd, date, dateTime string
+	}{
+		{"en-US", "123,456.7890", "€123,456.79", "¥123,457", "KWD\u00a0123,456.789", "Fri, May 25, 2018", "Fri, May 25, 2018 9:30 AM UTC"},
+		{"en-GB", "123,456.7890", "€123,456.79", "¥123,457", "KWD\u00a0123,456.789", "Fri 25 May 2018", "Fri 25 May 2018 09:30 UTC"},
+		{"de-DE", "123.456,7890", "123.456,79\u00a0€", "123.457\u00a0¥", "123.456,789\u00a0KWD", "Fr., 25. Mai 2018", "Fr., 25. Mai 2018 09:30 UTC"},
+		{"de-AT", "123.456,7890", "123.456,79\u00a0€", "123.457\u00a0¥", "123.456,789\u00a0KWD", "Fr., 25. Mai 2018", "Fr., 25. Mai 2018 09:30 UTC"},
+		{"fr", "123\u202f456,7890", "123\u202f456,79\u00a0€", "123\u202f457\u00a0¥", "123\u202f456,789\u00a0KWD", "ven. 25 mai 2018", "ven. 25 mai 2018 09:30 UTC"},
+		{"ja-JP", "123,456.7890", "€123,456.79", "￥123,457", "KWD\u00a0123,456.789", "2018年5月25日(金)", "2018年5月25日(金) 09:30 UTC"},
+	}
+
+	for _, test := range tests {
+		l, err := Parse(test.tag)
+		r.NoError(err, test.tag)
+		r.Equal(test.tag, l.String())
+		r.False(l.IsPlain())
+		r.Equal(test.number, l.Rate(amount), test.tag)
+		r.Equal(test.eur, l.Money(amount, "EUR"), test.tag)
+		r.Equal(test.jpy, l.Money(amount, "JPY"), test.tag)
+		r.Equal(test.kwd, l.Money(amount, "KWD"), test.tag)
+		r.Equal(test.date, l.Date(testTime), test.tag)
+		r.Equal(test.dateTime, l.DateTime(testTime), test.tag)
+	}
+}
+
+func TestSignsAndSmallNumbers(t *testing.T) {
+	r := require.New(t)
+
+	l, err := Parse("de")
+	r.NoError(err)
+	r.Equal("-1.234,50", l.Number(decimal.New(-12345, 1), 2))
+	r.Equal("+0,1000", l.Signed(decimal.New(1, 1), RatePlaces))
+	r.Equal("999", l.Number(decimal.New(999, 0), 0))
+	r.Equal("+10,00\u00a0%", l.Percent(l.Signed(decimal.New(10, 0), 2)))
+}
+
+func TestParseRejectsUnknownLocales(t *testing.T) {
+	r := require.New(t)
+
+	_, err := Parse("not a locale")
+	r.Error(err)
+	r.Contains(err.Error(), "invalid locale not a locale")
+
+	_, err = Parse("pt")
+	r.Error(err)
+	r.Contains(err.Error(), "unsupported locale pt, expected one of en-US, en-GB, de, ")
+}
+
+func TestDefault(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal(Plain, Default())
+
+	l, err := Parse("de")
+	r.NoError(err)
+	SetDefault(l)
+	defer SetDefault(Plain)
+	r.Equal(l, Default())
+}
@@ -0,0 +1,96 @@
+package cringletest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ericlagergren/decimal"
+)
+
+// RateMatrix holds the rate between every pair of Currencies, each derived from the rates of a
+// single provider call quoted against the first of them
+type RateMatrix struct {
+	Currencies []string
+	// Rates holds the rates from each currency to every currency, including 1 to itself
+	Rates map[string]RateMap
+}
+
+// joinWarnings returns the distinct non-empty warnings joined into one
+func joinWarnings(warnings ...string) string {
+	joined := []string{}
+	for _, warning := range warnings {
+		if len(warning) == 0 {
+			continue
+		}
+		seen := false
+		for _, w := range joined {
+			seen = seen || w == warning
+		}
+		if !seen {
+			joined = append(joined, warning)
+		}
+	}
+	return strings.Join(joined, "; ")
+}
+
+// NewRateMatrix returns the RateMatrix of currencies from quoted, the rates from the first of
+// currencies to each of the others. The rate from A to B is the quote of B over the quote of A,
+// calculated in RateContext
+func NewRateMatrix(currencies []string, quoted RateMap) (*RateMatrix, error) {
+	if len(currencies) < 2 {
+		return nil, fmt.Errorf("a rate matrix needs at least two currencies")
+	}
+
+	base := currencies[0]
+	// quotes holds the rate from base to every currency, including base itself
+	quotes := RateMap{}
+	for _, code := range currencies[1:] {
+		quote, ok := quoted[code]
+		if !ok {
+			return nil, fmt.Errorf("no rate from %s to %s", base, code)
+		}
+		if quote.Value.Sign() == 0 {
+			return nil, fmt.Errorf("cannot derive cross rates from the rate of zero from %s to %s", base, code)
+		}
+		quotes[code] = quote
+	}
+	first := quotes[currencies[1]]
+	quotes[base] = &ExchangeRate{From: base, To: base, Date: first.Date, Value: decimal.New(1, 0), Provider: first.Provider, Fetched: first.Fetched}
+
+	m := &RateMatrix{Currencies: append([]string{}, currencies...), Rates: map[string]RateMap{}}
+	for _, from := range currencies {
+		m.Rates[from] = RateMap{}
+		for _, to := range currencies {
+			rate := *quotes[to]
+			rate.From, rate.To = from, to
+			rate.Warning = joinWarnings(quotes[from].Warning, quotes[to].Warning)
+			switch {
+			case from == to:
+				rate.Value = decimal.New(1, 0)
+			case from != base:
+				rate.Value = RateContext.Quo(new(decimal.Big), quotes[to].Value, quotes[from].Value)
+			}
+			m.Rates[from][to] = &rate
+		}
+	}
+	return m, nil
+}
+
+// Rate returns the rate from from to to, or nil if either is not in the matrix
+func (m *RateMatrix) Rate(from, to string) *ExchangeRate {
+	return m.Rates[from][to]
+}
+
+// CrossRates returns the rate between every pair of different currencies, in the order of
+// Currencies
+func (m *RateMatrix) CrossRates() []*ExchangeRate {
+	rates := []*ExchangeRate{}
+	for _, from := range m.Currencies {
+		for _, to := range m.Currencies {
+			if from != to {
+				rates = append(rates, m.Rate(from, to))
+			}
+		}
+	}
+	return rates
+}
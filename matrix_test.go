@@ -0,0 +1,48 @@
+package cringletest
+
+import (
+	"testing"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateMatrixDerivesEveryCrossRate(t *testing.T) {
+	r := require.New(t)
+
+	quoted := RateMap{
+		"USD": {From: "EUR", To: "USD", Value: decimal.New(125, 2), Provider: "ecb"},
+		"GBP": {From: "EUR", To: "GBP", Value: decimal.New(8, 1), Provider: "ecb", Warning: "stale"},
+		"JPY": {From: "EUR", To: "JPY", Value: decimal.New(150, 0), Provider: "ecb"},
+	}
+	m, err := NewRateMatrix([]string{"EUR", "USD", "GBP", "JPY"}, quoted)
+	r.NoError(err)
+
+	r.Equal("1", m.Rate("EUR", "EUR").Value.String())
+	r.Equal("1.25", m.Rate("EUR", "USD").Value.String())
+	r.Equal("0.64", m.Rate("USD", "GBP").Value.String())
+	r.Equal("1.5625", m.Rate("GBP", "USD").Value.String())
+	r.Equal("187.5", m.Rate("GBP", "JPY").Value.String())
+	r.Equal("0.006666666666666667", m.Rate("JPY", "EUR").Value.String())
+
+	r.Equal("GBP", m.Rate("GBP", "JPY").From)
+	r.Equal("JPY", m.Rate("GBP", "JPY").To)
+	r.Equal("ecb", m.Rate("GBP", "JPY").Provider)
+	r.Equal("stale", m.Rate("GBP", "JPY").Warning)
+	r.Equal("", m.Rate("USD", "JPY").Warning)
+
+	cross := m.CrossRates()
+	r.Len(cross, 12)
+	r.Equal("EUR", cross[0].From)
+	r.Equal("USD", cross[0].To)
+}
+
+func TestRateMatrixNeedsEveryQuote(t *testing.T) {
+	r := require.New(t)
+
+	_, err := NewRateMatrix([]string{"EUR"}, RateMap{})
+	r.Error(err)
+
+	_, err = NewRateMatrix([]string{"EUR", "USD", "GBP"}, RateMap{"USD": {From: "EUR", To: "USD", Value: decimal.New(125, 2)}})
+	r.EqualError(err, "no rate from EUR to GBP")
+}
@@ -0,0 +1,167 @@
+package cringletest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/robotlovesyou/cringletest/currency"
+)
+
+var (
+	// RateContext is the decimal context exchange rates derived from other rates, such as a
+	// cross rate through a provider's base currency, are calculated in
+	RateContext = decimal.Context{Precision: 16, RoundingMode: decimal.ToNearestEven}
+	// MoneyContext is the decimal context amounts of money are calculated in before they are
+	// rounded
+	MoneyContext = decimal.Context{Precision: 34, RoundingMode: decimal.ToNearestEven}
+)
+
+// MinorUnits is the Places of a Rounding which rounds each amount to the minor unit of its
+// currency, such as 0.01 for GBP or 1 for JPY
+const MinorUnits = -1
+
+// unitPlaces is the number of decimal places amounts of currencies with no minor unit, such as
+// gold, are rounded to
+const unitPlaces = 4
+
+// roundingModes are the rounding modes by the names they are chosen with
+var roundingModes = map[string]decimal.RoundingMode{
+	"half-even": decimal.ToNearestEven,
+	"half-up":   decimal.ToNearestAway,
+	"down":      decimal.ToZero,
+	"up":        decimal.AwayFromZero,
+}
+
+// RoundingModes returns the names of the rounding modes ParseRounding accepts
+func RoundingModes() []string {
+	return []string{"half-even", "half-up", "down", "up"}
+}
+
+// Rounding is how amounts of money are rounded
+type Rounding struct {
+	// Mode decides which way amounts between two steps are rounded
+	Mode decimal.RoundingMode
+	// Places is the number of decimal places amounts are rounded to, or MinorUnits
+	Places int
+	// Cash rounds amounts of currencies whose smallest coin is larger than their minor unit to
+	// that coin, such as CHF 0.05
+	Cash bool
+}
+
+// DefaultRounding rounds half to even at the minor unit of each currency
+var DefaultRounding = &Rounding{Mode: decimal.ToNearestEven, Places: MinorUnits}
+
+// ParseRounding returns the Rounding for mode, one of RoundingModes, rounding to places decimal
+// places or MinorUnits. An empty mode is half-even
+func ParseRounding(mode string, places int, cash bool) (*Rounding, error) {
+	if len(mode) == 0 {
+		mode = "half-even"
+	}
+	m, ok := roundingModes[strings.ToLower(mode)]
+	if !ok {
+		return nil, fmt.Errorf("unknown rounding mode %s, expected one of %s", mode, strings.Join(RoundingModes(), ", "))
+	}
+	if places < MinorUnits {
+		return nil, fmt.Errorf("cannot round to %d decimal places", places)
+	}
+	return &Rounding{Mode: m, Places: places, Cash: cash}, nil
+}
+
+// String returns the name of the rounding mode of r
+func (r *Rounding) String() string {
+	for name, mode := range roundingModes {
+		if mode == r.Mode {
+			return name
+		}
+	}
+	return r.Mode.String()
+}
+
+// places returns the number of decimal places amounts of the currency with code are rounded to
+func (r *Rounding) places(code string) int {
+	if r.Places != MinorUnits {
+		return r.Places
+	}
+	if units := currency.MinorUnits(code); units != currency.NoMinorUnits {
+		return units
+	}
+	return unitPlaces
+}
+
+// Round returns amount of the currency with code rounded by r
+func (r *Rounding) Round(amount *decimal.Big, code string) *decimal.Big {
+	ctx := MoneyContext
+	ctx.RoundingMode = r.Mode
+
+	places := r.places(code)
+	rounded := new(decimal.Big).Copy(amount)
+	if r.Cash {
+		if cash, ok := new(decimal.Big).SetString(currency.Cash(code)); ok && cash.Sign() > 0 {
+			// round the number of coins, then count them back up to an amount
+			ctx.Quo(rounded, rounded, cash)
+			ctx.Quantize(rounded, 0)
+			ctx.Mul(rounded, rounded, cash)
+		}
+	}
+	ctx.Quantize(rounded, places)
+	if rounded.Scale() != places {
+		// rounding which carries into a new digit, as 9.995 does to 10.00, can leave the result
+		// with fewer places than asked for, which quantizing the exact result again restores
+		ctx.Quantize(rounded, places)
+	}
+	return rounded
+}
+
+// Money is an Amount of Currency
+type Money struct {
+	Amount   *decimal.Big `json:"amount"`
+	Currency string       `json:"currency"`
+}
+
+// NewMoney returns amount of the currency with code
+func NewMoney(amount *decimal.Big, code string) *Money {
+	return &Money{Amount: amount, Currency: code}
+}
+
+// String returns the amount and currency code of m, such as 12.50 GBP
+func (m *Money) String() string {
+	return fmt.Sprintf("%s %s", m.Amount, m.Currency)
+}
+
+// Round returns a copy of m rounded by r
+func (m *Money) Round(r *Rounding) *Money {
+	return NewMoney(r.Round(m.Amount, m.Currency), m.Currency)
+}
+
+// Convert returns what m buys at rate, rounded by r. It is an error if rate is not from the
+// currency of m
+func (m *Money) Convert(rate *ExchangeRate, r *Rounding) (*Money, error) {
+	if !strings.EqualFold(m.Currency, rate.From) {
+		return nil, fmt.Errorf("cannot convert %s at a rate from %s", m.Currency, rate.From)
+	}
+	converted := MoneyContext.Mul(new(decimal.Big), m.Amount, rate.Value)
+	return NewMoney(r.Round(converted, rate.To), rate.To), nil
+}
+
+// Conversion is Amount converted at Rate into Converted. Charges is set when fees were paid,
+// and Converted is then the net amount received
+type Conversion struct {
+	Amount    *Money        `json:"amount"`
+	Rate      *ExchangeRate `json:"rate"`
+	Converted *Money        `json:"converted"`
+	Charges   *Charges      `json:"charges,omitempty"`
+}
+
+// Convert converts amount at each of rates, rounding each result by r
+func Convert(amount *Money, rates []*ExchangeRate, r *Rounding) ([]*Conversion, error) {
+	conversions := []*Conversion{}
+	for _, rate := range rates {
+		converted, err := amount.Convert(rate, r)
+		if err != nil {
+			return nil, err
+		}
+		conversions = append(conversions, &Conversion{Amount: amount, Rate: rate, Converted: converted})
+	}
+	return conversions, nil
+}
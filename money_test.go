@@ -0,0 +1,72 @@
+package cringletest
+
+import (
+	"testing"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundingModes(t *testing.T) {
+	r := require.New(t)
+
+	amount := decimal.New(12345, 3)
+	for mode, expected := range map[string]string{
+		"half-even": "12.34",
+		"half-up":   "12.35",
+		"down":      "12.34",
+		"up":        "12.35",
+	} {
+		rounding, err := ParseRounding(mode, MinorUnits, false)
+		r.NoError(err)
+		r.Equal(expected, rounding.Round(amount, "GBP").String(), mode)
+		r.Equal(mode, rounding.String())
+	}
+
+	_, err := ParseRounding("sideways", MinorUnits, false)
+	r.EqualError(err, "unknown rounding mode sideways, expected one of half-even, half-up, down, up")
+	_, err = ParseRounding("", -2, false)
+	r.Error(err)
+}
+
+func TestRoundingUsesMinorUnitsAndCash(t *testing.T) {
+	r := require.New(t)
+
+	amount := decimal.New(1234567, 4)
+	r.Equal("123.46", DefaultRounding.Round(amount, "EUR").String())
+	r.Equal("123", DefaultRounding.Round(amount, "JPY").String())
+	r.Equal("123.457", DefaultRounding.Round(amount, "KWD").String())
+	r.Equal("123.4567", DefaultRounding.Round(amount, "XAU").String())
+
+	cash := &Rounding{Mode: decimal.ToNearestEven, Places: MinorUnits, Cash: true}
+	r.Equal("123.45", cash.Round(amount, "CHF").String())
+	r.Equal("123.00", cash.Round(amount, "SEK").String())
+	r.Equal("123.46", cash.Round(amount, "EUR").String())
+
+	// rounding which carries keeps every place
+	r.Equal("10.00", DefaultRounding.Round(decimal.New(9995, 3), "EUR").String())
+	r.Equal("10", DefaultRounding.Round(decimal.New(95, 1), "JPY").String())
+
+	fixed := &Rounding{Mode: decimal.ToNearestEven, Places: 3}
+	r.Equal("123.457", fixed.Round(amount, "EUR").String())
+}
+
+func TestConvertRoundsEachAmountOnce(t *testing.T) {
+	r := require.New(t)
+
+	rates := []*ExchangeRate{
+		{From: "GBP", To: "EUR", Value: decimal.New(114371, 5)},
+		{From: "GBP", To: "JPY", Value: decimal.New(14987, 2)},
+	}
+	conversions, err := Convert(NewMoney(decimal.New(12345, 1), "GBP"), rates, DefaultRounding)
+	r.NoError(err)
+	r.Len(conversions, 2)
+	r.Equal("1411.91 EUR", conversions[0].Converted.String())
+	// 185014.515 rounds up rather than being truncated
+	r.Equal("185015 JPY", conversions[1].Converted.String())
+	r.Equal(rates[1], conversions[1].Rate)
+	r.Equal("1234.5 GBP", conversions[1].Amount.String())
+
+	_, err = NewMoney(decimal.New(1, 0), "USD").Convert(rates[0], DefaultRounding)
+	r.EqualError(err, "cannot convert USD at a rate from GBP")
+}
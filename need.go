@@ -0,0 +1,164 @@
+package cringletest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/robotlovesyou/cringletest/currency"
+)
+
+// maxSteps is the most times Need moves an amount by the smallest step of its currency before
+// giving up on finding the least amount which buys the target
+const maxSteps = 1000
+
+// Requirement is the least amount which must be converted to receive Target, along with the
+// Conversion of that amount. Its Converted is Target, or the least amount above Target which
+// can be bought when no amount buys exactly that
+type Requirement struct {
+	Target *Money `json:"target"`
+	*Conversion
+}
+
+// Invert returns the rate from r.To to r.From
+func (r *ExchangeRate) Invert() (*ExchangeRate, error) {
+	if r.Value.Sign() == 0 {
+		return nil, fmt.Errorf("cannot invert the rate of zero from %s to %s", r.From, r.To)
+	}
+	inverted := *r
+	inverted.From, inverted.To = r.To, r.From
+	inverted.Value = RateContext.Quo(new(decimal.Big), decimal.New(1, 0), r.Value)
+	return &inverted, nil
+}
+
+// step returns the smallest amount of the currency with code which r rounds to
+func (r *Rounding) step(code string) *decimal.Big {
+	if r.Cash {
+		if cash, ok := new(decimal.Big).SetString(currency.Cash(code)); ok && cash.Sign() > 0 {
+			return cash
+		}
+	}
+	return decimal.New(1, r.places(code))
+}
+
+// roundUp returns amount rounded up to a whole number of steps
+func roundUp(amount, step *decimal.Big) *decimal.Big {
+	ctx := MoneyContext
+	ctx.RoundingMode = decimal.AwayFromZero
+	steps := ctx.Quo(new(decimal.Big), amount, step)
+	ctx.Quantize(steps, 0)
+	return ctx.Mul(steps, steps, step)
+}
+
+// estimate returns the amount of rate.From which buys target at rate less fees before any of
+// them are rounded
+func estimate(target *Money, rate *ExchangeRate, fees *Fees) (*decimal.Big, error) {
+	amount := MoneyContext.Quo(new(decimal.Big), target.Amount, rate.Value)
+	if fees == nil {
+		return amount, nil
+	}
+
+	if fees.Spread != nil {
+		given := MoneyContext.Quo(new(decimal.Big), fees.Spread, basisPoints)
+		MoneyContext.Sub(given, decimal.New(1, 0), given)
+		MoneyContext.Quo(amount, amount, given)
+	}
+
+	fixed, err := fees.fixedFee(NewMoney(new(decimal.Big), rate.From), []*ExchangeRate{rate})
+	if err != nil {
+		return nil, err
+	}
+	MoneyContext.Add(amount, amount, fixed)
+
+	if fees.Percent != nil {
+		kept := MoneyContext.Quo(new(decimal.Big), fees.Percent, decimal.New(100, 0))
+		MoneyContext.Sub(kept, decimal.New(1, 0), kept)
+		MoneyContext.Quo(amount, amount, kept)
+	}
+	return amount, nil
+}
+
+// need returns the Requirement for target from rate.From
+func need(target *Money, rate *ExchangeRate, r *Rounding, fees *Fees) (*Requirement, error) {
+	if rate.Value.Sign() <= 0 {
+		return nil, fmt.Errorf("cannot convert %s to %s at a rate of %s", rate.From, rate.To, rate.Value)
+	}
+
+	amount, err := estimate(target, rate, fees)
+	if err != nil {
+		return nil, err
+	}
+
+	step := r.step(rate.From)
+	amount = r.Round(roundUp(amount, step), rate.From)
+
+	convert := func(amount *decimal.Big) (*Conversion, error) {
+		conversions, err := ConvertWithFees(NewMoney(amount, rate.From), []*ExchangeRate{rate}, r, fees)
+		if err != nil {
+			return nil, err
+		}
+		return conversions[0], nil
+	}
+
+	conversion, err := convert(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	// the estimate is rounded up so usually buys enough already, but rounding the converted amount
+	// can leave it a step short or a step over
+	for i := 0; ; i++ {
+		if i == maxSteps {
+			return nil, fmt.Errorf("could not find an amount of %s which buys %s", rate.From, target)
+		}
+
+		if conversion.Converted.Amount.Cmp(target.Amount) < 0 {
+			amount = MoneyContext.Add(new(decimal.Big), amount, step)
+			if conversion, err = convert(amount); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		less := MoneyContext.Sub(new(decimal.Big), amount, step)
+		if less.Sign() <= 0 {
+			break
+		}
+		smaller, err := convert(less)
+		if err != nil || smaller.Converted.Amount.Cmp(target.Amount) < 0 {
+			// fees larger than less are an error, and mean amount is the least which buys target
+			break
+		}
+		amount, conversion = less, smaller
+	}
+
+	return &Requirement{Target: target, Conversion: conversion}, nil
+}
+
+// Need returns, for each of rates, the least amount of the currency the rate is from which must
+// be converted to receive target, taking rounding by r and any fees into account. Every rate must
+// be to the currency of target
+func Need(target *Money, rates []*ExchangeRate, r *Rounding, fees *Fees) ([]*Requirement, error) {
+	if target.Amount.Sign() <= 0 {
+		return nil, fmt.Errorf("cannot need %s, which is not more than zero", target)
+	}
+	if fees != nil {
+		if err := fees.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	requirements := []*Requirement{}
+	for _, rate := range rates {
+		if !strings.EqualFold(rate.To, target.Currency) {
+			return nil, fmt.Errorf("cannot receive %s at a rate to %s", target.Currency, rate.To)
+		}
+
+		requirement, err := need(target, rate, r, fees)
+		if err != nil {
+			return nil, err
+		}
+		requirements = append(requirements, requirement)
+	}
+	return requirements, nil
+}
@@ -0,0 +1,99 @@
+package cringletest
+
+import (
+	"testing"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNeedFindsTheLeastAmount(t *testing.T) {
+	r := require.New(t)
+
+	rates := []*ExchangeRate{
+		{From: "GBP", To: "EUR", Value: decimal.New(114371, 5)},
+		{From: "JPY", To: "EUR", Value: decimal.New(667, 5)},
+	}
+	target := NewMoney(decimal.New(50000, 2), "EUR")
+
+	requirements, err := Need(target, rates, DefaultRounding, nil)
+	r.NoError(err)
+	r.Len(requirements, 2)
+
+	// 437.17 GBP buys 499.995... which rounds to 500.00, and 437.16 only buys 499.98
+	r.Equal("437.17 GBP", requirements[0].Amount.String())
+	r.Equal("500.00 EUR", requirements[0].Converted.String())
+	r.Equal(target, requirements[0].Target)
+
+	// 74962 JPY buys 499.996... which rounds to 500.00, and 74961 only buys 499.99
+	r.Equal("74962 JPY", requirements[1].Amount.String())
+	r.Equal("500.00 EUR", requirements[1].Converted.String())
+}
+
+func TestNeedTakesRoundingIntoAccount(t *testing.T) {
+	r := require.New(t)
+
+	rates := []*ExchangeRate{{From: "GBP", To: "EUR", Value: decimal.New(114371, 5)}}
+	down := &Rounding{Mode: decimal.ToZero, Places: MinorUnits}
+
+	requirements, err := Need(NewMoney(decimal.New(50000, 2), "EUR"), rates, down, nil)
+	r.NoError(err)
+	r.Equal("437.18 GBP", requirements[0].Amount.String())
+	r.Equal("500.00 EUR", requirements[0].Converted.String())
+
+	// CHF is paid in multiples of 0.05 in cash
+	rates = []*ExchangeRate{{From: "CHF", To: "EUR", Value: decimal.New(86, 2)}}
+	cash := &Rounding{Mode: decimal.ToNearestEven, Places: MinorUnits, Cash: true}
+	requirements, err = Need(NewMoney(decimal.New(100, 0), "EUR"), rates, cash, nil)
+	r.NoError(err)
+	r.Equal("116.30 CHF", requirements[0].Amount.String())
+}
+
+func TestNeedTakesFeesIntoAccount(t *testing.T) {
+	r := require.New(t)
+
+	rates := []*ExchangeRate{{From: "GBP", To: "EUR", Value: decimal.New(115, 2)}}
+	fees := &Fees{Percent: decimal.New(5, 1), Fixed: NewMoney(decimal.New(2, 0), "GBP"), Spread: decimal.New(150, 0)}
+	target := NewMoney(decimal.New(1124, 0), "EUR")
+
+	requirements, err := Need(target, rates, DefaultRounding, fees)
+	r.NoError(err)
+	requirement := requirements[0]
+	r.True(requirement.Converted.Amount.Cmp(target.Amount) >= 0)
+	r.NotNil(requirement.Charges)
+
+	// a penny less does not buy enough
+	less := new(decimal.Big).Sub(requirement.Amount.Amount, decimal.New(1, 2))
+	conversions, err := ConvertWithFees(NewMoney(less, "GBP"), rates, DefaultRounding, fees)
+	r.NoError(err)
+	r.Equal(-1, conversions[0].Converted.Amount.Cmp(target.Amount))
+}
+
+func TestNeedRejectsImpossibleRequests(t *testing.T) {
+	r := require.New(t)
+
+	rates := []*ExchangeRate{{From: "GBP", To: "EUR", Value: decimal.New(115, 2)}}
+
+	_, err := Need(NewMoney(decimal.New(0, 0), "EUR"), rates, DefaultRounding, nil)
+	r.EqualError(err, "cannot need 0 EUR, which is not more than zero")
+
+	_, err = Need(NewMoney(decimal.New(1, 0), "USD"), rates, DefaultRounding, nil)
+	r.EqualError(err, "cannot receive USD at a rate to EUR")
+
+	_, err = Need(NewMoney(decimal.New(1, 0), "EUR"), rates, DefaultRounding, &Fees{Fixed: NewMoney(decimal.New(1, 0), "USD")})
+	r.EqualError(err, "a fixed fee in USD cannot be charged when converting GBP")
+}
+
+func TestInvert(t *testing.T) {
+	r := require.New(t)
+
+	inverted, err := (&ExchangeRate{From: "EUR", To: "GBP", Value: decimal.New(8, 1), Provider: "ecb"}).Invert()
+	r.NoError(err)
+	r.Equal("GBP", inverted.From)
+	r.Equal("EUR", inverted.To)
+	r.Equal("1.25", inverted.Value.String())
+	r.Equal("ecb", inverted.Provider)
+
+	_, err = (&ExchangeRate{From: "EUR", To: "GBP", Value: decimal.New(0, 0)}).Invert()
+	r.Error(err)
+}
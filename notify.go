@@ -3,7 +3,6 @@ package cringletest
 import (
 	"context"
 
-	"github.com/ericlagergren/decimal"
 	"github.com/pkg/errors"
 )
 
@@ -16,9 +15,29 @@ var (
 	ErrNoRates = errors.New("no rates provided")
 )
 
+// idempotencyKey is the context key of the idempotency key of a notification
+type idempotencyKey struct{}
+
+// WithIdempotencyKey returns a copy of ctx which carries key. A notification sent again with
+// the same key, because an earlier attempt may or may not have arrived, is the same
+// notification, and notifiers pass the key on to receivers which can use it to ignore repeats
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// IdempotencyKey returns the idempotency key carried by ctx, or an empty string if it has none
+func IdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	return key
+}
+
 //Notifier describes a service which can send a notification about the results of a query
 type Notifier interface {
 	NotifyRates(ctx context.Context, rates []*ExchangeRate) error
-	NotifyValue(ctx context.Context, value *decimal.Big, rates []*ExchangeRate) error
-	NotifyBest(ctx context.Context, rate *ExchangeRate) error
+	NotifyValue(ctx context.Context, conversions []*Conversion) error
+	NotifyBest(ctx context.Context, best *BestRate) error
+	NotifyHistory(ctx context.Context, history *RateHistory) error
+	NotifyAlert(ctx context.Context, alert *Alert) error
+	NotifyNeed(ctx context.Context, requirements []*Requirement) error
+	NotifyMatrix(ctx context.Context, matrix *RateMatrix) error
 }
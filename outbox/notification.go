@@ -0,0 +1,92 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/report"
+)
+
+// Notification is the result of a request, held so that it can be sent to any
+// cringletest.Notifier, now or after being stored in an Outbox. Kind is one of the report
+// kinds and says which of the other fields are set. Value and Rates hold value notifications
+// stored before amounts were rounded once for every notifier, which are rounded by
+// cringletest.DefaultRounding when they are sent
+type Notification struct {
+	Kind        string                      `json:"kind"`
+	Value       *decimal.Big                `json:"value,omitempty"`
+	Rates       []*cringletest.ExchangeRate `json:"rates,omitempty"`
+	Conversions []*cringletest.Conversion   `json:"conversions,omitempty"`
+	Best        *cringletest.BestRate       `json:"best,omitempty"`
+	History     *cringletest.RateHistory    `json:"history,omitempty"`
+	Alert       *cringletest.Alert          `json:"alert,omitempty"`
+	Needs       []*cringletest.Requirement  `json:"needs,omitempty"`
+	Matrix      *cringletest.RateMatrix     `json:"matrix,omitempty"`
+}
+
+// Rates returns a Notification of rates
+func Rates(rates []*cringletest.ExchangeRate) *Notification {
+	return &Notification{Kind: report.KindRates, Rates: rates}
+}
+
+// Value returns a Notification of an amount converted at each of several rates
+func Value(conversions []*cringletest.Conversion) *Notification {
+	return &Notification{Kind: report.KindValue, Conversions: conversions}
+}
+
+// Best returns a Notification of best
+func Best(best *cringletest.BestRate) *Notification {
+	return &Notification{Kind: report.KindBest, Best: best}
+}
+
+// History returns a Notification of history
+func History(history *cringletest.RateHistory) *Notification {
+	return &Notification{Kind: report.KindHistory, History: history}
+}
+
+// Alert returns a Notification of alert
+func Alert(alert *cringletest.Alert) *Notification {
+	return &Notification{Kind: report.KindAlert, Alert: alert}
+}
+
+// Need returns a Notification of the amounts of several currencies needed to receive a target
+// amount
+func Need(requirements []*cringletest.Requirement) *Notification {
+	return &Notification{Kind: report.KindNeed, Needs: requirements}
+}
+
+// Matrix returns a Notification of the cross rates of a basket of currencies
+func Matrix(matrix *cringletest.RateMatrix) *Notification {
+	return &Notification{Kind: report.KindMatrix, Matrix: matrix}
+}
+
+// Send sends the notification to notifier
+func (n *Notification) Send(ctx context.Context, notifier cringletest.Notifier) error {
+	switch n.Kind {
+	case report.KindRates:
+		return notifier.NotifyRates(ctx, n.Rates)
+	case report.KindValue:
+		conversions := n.Conversions
+		if conversions == nil && n.Value != nil && len(n.Rates) != 0 {
+			var err error
+			conversions, err = cringletest.Convert(cringletest.NewMoney(n.Value, n.Rates[0].From), n.Rates, cringletest.DefaultRounding)
+			if err != nil {
+				return err
+			}
+		}
+		return notifier.NotifyValue(ctx, conversions)
+	case report.KindBest:
+		return notifier.NotifyBest(ctx, n.Best)
+	case report.KindHistory:
+		return notifier.NotifyHistory(ctx, n.History)
+	case report.KindAlert:
+		return notifier.NotifyAlert(ctx, n.Alert)
+	case report.KindNeed:
+		return notifier.NotifyNeed(ctx, n.Needs)
+	case report.KindMatrix:
+		return notifier.NotifyMatrix(ctx, n.Matrix)
+	}
+	return fmt.Errorf("unknown notification kind %s", n.Kind)
+}
@@ -0,0 +1,364 @@
+// Package outbox keeps notifications which could not be sent on disk so that they can be sent
+// again later. An entry is marked as sent before it is removed, so a later flush never sends a
+// notification which is known to have been delivered. Every notification also keeps the
+// idempotency key it was first sent with, so that receivers can ignore a repeat of one which
+// arrived without cconv knowing
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/filelock"
+)
+
+const (
+	entryExt = ".json"
+	lockName = ".lock"
+)
+
+// ErrNotFound is returned when no entry has the requested id
+var ErrNotFound = errors.New("no such outbox entry")
+
+// Entry is a notification waiting in the outbox. Notifier is the URL of the notifier it is
+// for, which cringletest.NewNotifier can build, and Name is the name that notifier is reported
+// by. ID is the idempotency key of the notification
+type Entry struct {
+	ID           string        `json:"id"`
+	Notifier     string        `json:"notifier"`
+	Name         string        `json:"name"`
+	Created      time.Time     `json:"created"`
+	Attempts     int           `json:"attempts"`
+	LastError    string        `json:"last_error,omitempty"`
+	NextAttempt  time.Time     `json:"next_attempt"`
+	Sent         bool          `json:"sent,omitempty"`
+	Notification *Notification `json:"notification"`
+}
+
+// SendFunc sends the notification held by entry. ctx carries the idempotency key of the entry
+type SendFunc func(ctx context.Context, entry *Entry) error
+
+// FlushResult describes the entries a Flush sent, failed to send and left for later because
+// they were not yet due
+type FlushResult struct {
+	Sent    []*Entry
+	Failed  []*Entry
+	Waiting []*Entry
+}
+
+// Outbox is a directory of entries
+type Outbox struct {
+	dir        string
+	backoff    time.Duration
+	maxBackoff time.Duration
+	now        func() time.Time
+}
+
+// New returns an Outbox which keeps its entries in dir
+func New(dir string) *Outbox {
+	return &Outbox{
+		dir:        dir,
+		backoff:    cringletest.OutboxBackoff,
+		maxBackoff: cringletest.OutboxMaxBackoff,
+		now:        time.Now,
+	}
+}
+
+// NewKey returns a new random idempotency key
+func NewKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// Add stores notification in the outbox for the notifier at notifierURL, known as name. key is
+// the idempotency key it was first sent with and cause is why it failed
+func (o *Outbox) Add(key, notifierURL, name string, notification *Notification, cause error) (*Entry, error) {
+	if len(key) == 0 {
+		return nil, errors.New("no idempotency key")
+	}
+
+	now := o.now().UTC()
+	entry := &Entry{
+		ID:           key,
+		Notifier:     notifierURL,
+		Name:         name,
+		Created:      now,
+		Attempts:     1,
+		NextAttempt:  now.Add(o.backoff),
+		Notification: notification,
+	}
+	if cause != nil {
+		entry.LastError = cause.Error()
+	}
+
+	if err := o.write(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (o *Outbox) path(id string) string {
+	return filepath.Join(o.dir, id+entryExt)
+}
+
+// write stores entry in a temporary file and renames it into place, so that an entry is never
+// seen half written. Entries can hold credentials in their notifier URL so only the user can
+// read them
+func (o *Outbox) write(entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "could not encode outbox entry")
+	}
+
+	if err := os.MkdirAll(o.dir, 0700); err != nil {
+		return errors.Wrap(err, "could not create outbox")
+	}
+
+	f, err := ioutil.TempFile(o.dir, "."+entry.ID+"-*")
+	if err != nil {
+		return errors.Wrap(err, "could not write outbox entry")
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not write outbox entry")
+	}
+
+	return errors.Wrap(os.Rename(f.Name(), o.path(entry.ID)), "could not write outbox entry")
+}
+
+func (o *Outbox) read(name string) (*Entry, error) {
+	data, err := ioutil.ReadFile(filepath.Join(o.dir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &Entry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, errors.Wrapf(err, "bad outbox entry %s", name)
+	}
+	if entry.Notification == nil {
+		return nil, fmt.Errorf("bad outbox entry %s: no notification", name)
+	}
+	return entry, nil
+}
+
+// entries returns every entry in the outbox, including those already sent, oldest first
+func (o *Outbox) entries() ([]*Entry, error) {
+	files, err := ioutil.ReadDir(o.dir)
+	if os.IsNotExist(err) {
+		return []*Entry{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read outbox")
+	}
+
+	entries := []*Entry{}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != entryExt {
+			continue
+		}
+
+		entry, err := o.read(name)
+		if os.IsNotExist(err) {
+			// removed since the directory was read
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Created.Before(entries[j].Created)
+	})
+	return entries, nil
+}
+
+// List returns every entry waiting to be sent, oldest first
+func (o *Outbox) List() ([]*Entry, error) {
+	entries, err := o.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	waiting := []*Entry{}
+	for _, entry := range entries {
+		if !entry.Sent {
+			waiting = append(waiting, entry)
+		}
+	}
+	return waiting, nil
+}
+
+// lock takes an exclusive lock on the outbox so that only one Flush or Drop works on it at a
+// time. It returns a function which releases the lock
+func (o *Outbox) lock() (func(), error) {
+	if err := os.MkdirAll(o.dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "could not create outbox")
+	}
+
+	f, err := os.OpenFile(filepath.Join(o.dir, lockName), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open outbox lock")
+	}
+
+	unlock, err := filelock.Lock(f, true)
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "could not lock outbox")
+	}
+
+	return func() {
+		unlock()
+		f.Close()
+	}, nil
+}
+
+// find returns the entry whose id is, or uniquely starts with, id
+func find(entries []*Entry, id string) (*Entry, error) {
+	var found *Entry
+	for _, entry := range entries {
+		if entry.ID == id {
+			return entry, nil
+		}
+		if strings.HasPrefix(entry.ID, id) {
+			if found != nil {
+				return nil, fmt.Errorf("%s matches more than one outbox entry", id)
+			}
+			found = entry
+		}
+	}
+	if found == nil {
+		return nil, errors.Wrap(ErrNotFound, id)
+	}
+	return found, nil
+}
+
+// Drop removes the entries with each of ids, any of which may be the start of an id, without
+// sending them. It drops every entry if no ids are given and returns the entries it removed
+func (o *Outbox) Drop(ids ...string) ([]*Entry, error) {
+	unlock, err := o.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	entries, err := o.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	drop := entries
+	if len(ids) != 0 {
+		drop = []*Entry{}
+		for _, id := range ids {
+			entry, err := find(entries, id)
+			if err != nil {
+				return nil, err
+			}
+			drop = append(drop, entry)
+		}
+	}
+
+	for _, entry := range drop {
+		if err := os.Remove(o.path(entry.ID)); err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrap(err, "could not drop outbox entry")
+		}
+	}
+	return drop, nil
+}
+
+// backoffAfter returns how long to wait before trying an entry again after attempts attempts.
+// The wait doubles after each attempt up to the maximum
+func (o *Outbox) backoffAfter(attempts int) time.Duration {
+	backoff := o.backoff
+	for i := 1; i < attempts && backoff < o.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > o.maxBackoff {
+		return o.maxBackoff
+	}
+	return backoff
+}
+
+// Flush calls send for each entry which is due to be tried again, or every entry if all is set,
+// oldest first. An entry which is sent is marked as sent and then removed, and one which fails
+// waits twice as long as it did last time before it is due again. Flush stops early if ctx
+// finishes
+func (o *Outbox) Flush(ctx context.Context, all bool, send SendFunc) (*FlushResult, error) {
+	unlock, err := o.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	entries, err := o.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FlushResult{Sent: []*Entry{}, Failed: []*Entry{}, Waiting: []*Entry{}}
+	for _, entry := range entries {
+		if entry.Sent {
+			// it was delivered by an earlier flush which stopped before removing it
+			if err := os.Remove(o.path(entry.ID)); err != nil && !os.IsNotExist(err) {
+				return result, errors.Wrap(err, "could not remove sent outbox entry")
+			}
+			continue
+		}
+
+		if ctx.Err() != nil || (!all && o.now().Before(entry.NextAttempt)) {
+			result.Waiting = append(result.Waiting, entry)
+			continue
+		}
+
+		sendErr := send(cringletest.WithIdempotencyKey(ctx, entry.ID), entry)
+		entry.Attempts++
+		if sendErr != nil {
+			entry.LastError = sendErr.Error()
+			entry.NextAttempt = o.now().UTC().Add(o.backoffAfter(entry.Attempts))
+			if err := o.write(entry); err != nil {
+				return result, err
+			}
+			result.Failed = append(result.Failed, entry)
+			continue
+		}
+
+		// record the delivery before removing the entry so that it is never sent twice, even
+		// if the entry cannot be removed
+		entry.Sent = true
+		entry.LastError = ""
+		if err := o.write(entry); err != nil {
+			return result, err
+		}
+		if err := os.Remove(o.path(entry.ID)); err != nil && !os.IsNotExist(err) {
+			return result, errors.Wrap(err, "could not remove sent outbox entry")
+		}
+		result.Sent = append(result.Sent, entry)
+	}
+	return result, nil
+}
@@ -0,0 +1,256 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/report"
+	"github.com/robotlovesyou/cringletest/testnotifier"
+	"github.com/stretchr/testify/require"
+)
+
+var testDate = time.Date(2018, 5, 25, 0, 0, 0, 0, time.UTC)
+
+var testRates = []*cringletest.ExchangeRate{
+	{From: "ABC", To: "DEF", Date: testDate, Value: decimal.New(123456789012345, 14), Provider: "ecb"},
+}
+
+// testClock is a clock which only moves when told to
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func getTestOutbox(t *testing.T) (*Outbox, *testClock, func()) {
+	dir, err := ioutil.TempDir("", "outbox")
+	require.NoError(t, err)
+
+	clock := &testClock{now: testDate}
+	o := New(filepath.Join(dir, "outbox"))
+	o.now = clock.Now
+	return o, clock, func() { os.RemoveAll(dir) }
+}
+
+func addTestEntry(r *require.Assertions, o *Outbox, notification *Notification) *Entry {
+	key, err := NewKey()
+	r.NoError(err)
+	entry, err := o.Add(key, "console://stdout", "console", notification, cringletest.ErrSendFailed)
+	r.NoError(err)
+	return entry
+}
+
+func TestNotificationSurvivesTheOutbox(t *testing.T) {
+	r := require.New(t)
+	o, _, cleanup := getTestOutbox(t)
+	defer cleanup()
+
+	best := &cringletest.BestRate{Rate: testRates[0], Days: 7, Until: testDate, Stats: cringletest.NewRateStats(testRates)}
+	added := addTestEntry(r, o, Best(best))
+	r.Equal(1, added.Attempts)
+	r.Equal("notification failed to send", added.LastError)
+
+	entries, err := o.List()
+	r.NoError(err)
+	r.Len(entries, 1)
+	r.Equal(added.ID, entries[0].ID)
+	r.Equal(report.KindBest, entries[0].Notification.Kind)
+
+	// the notification is sent exactly as it would have been
+	want, err := json.Marshal(report.FromBest(best))
+	r.NoError(err)
+	got, err := json.Marshal(report.FromBest(entries[0].Notification.Best))
+	r.NoError(err)
+	r.JSONEq(string(want), string(got))
+	r.Equal("1.23456789012345", entries[0].Notification.Best.Rate.Value.String())
+
+	info, err := os.Stat(o.path(added.ID))
+	r.NoError(err)
+	r.Equal(os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestNeedSurvivesTheOutbox(t *testing.T) {
+	r := require.New(t)
+	o, _, cleanup := getTestOutbox(t)
+	defer cleanup()
+
+	rate, err := testRates[0].Invert()
+	r.NoError(err)
+	fees := &cringletest.Fees{Percent: decimal.New(5, 1)}
+	requirements, err := cringletest.Need(cringletest.NewMoney(decimal.New(100, 0), "ABC"), []*cringletest.ExchangeRate{rate}, cringletest.DefaultRounding, fees)
+	r.NoError(err)
+	addTestEntry(r, o, Need(requirements))
+
+	entries, err := o.List()
+	r.NoError(err)
+	r.Equal(report.KindNeed, entries[0].Notification.Kind)
+
+	want, err := json.Marshal(report.FromNeed(requirements))
+	r.NoError(err)
+	got, err := json.Marshal(report.FromNeed(entries[0].Notification.Needs))
+	r.NoError(err)
+	r.JSONEq(string(want), string(got))
+}
+
+func TestFlushSendsDueEntriesWithTheirKey(t *testing.T) {
+	r := require.New(t)
+	o, clock, cleanup := getTestOutbox(t)
+	defer cleanup()
+
+	conversions, err := cringletest.Convert(cringletest.NewMoney(decimal.New(2, 0), "ABC"), testRates, cringletest.DefaultRounding)
+	r.NoError(err)
+	entry := addTestEntry(r, o, Value(conversions))
+
+	keys := []string{}
+	send := func(ctx context.Context, e *Entry) error {
+		keys = append(keys, cringletest.IdempotencyKey(ctx))
+		return e.Notification.Send(ctx, testnotifier.New(nil))
+	}
+
+	// not due until the backoff has passed
+	result, err := o.Flush(context.Background(), false, send)
+	r.NoError(err)
+	r.Len(result.Waiting, 1)
+	r.Empty(keys)
+
+	clock.now = clock.now.Add(cringletest.OutboxBackoff)
+	result, err = o.Flush(context.Background(), false, send)
+	r.NoError(err)
+	r.Len(result.Sent, 1)
+	r.Equal([]string{entry.ID}, keys)
+
+	entries, err := o.List()
+	r.NoError(err)
+	r.Empty(entries)
+}
+
+func TestFlushBacksOffFailures(t *testing.T) {
+	r := require.New(t)
+	o, clock, cleanup := getTestOutbox(t)
+	defer cleanup()
+
+	addTestEntry(r, o, Rates(testRates))
+	fail := func(ctx context.Context, e *Entry) error {
+		return errors.Wrap(cringletest.ErrSendFailed, "still down")
+	}
+
+	result, err := o.Flush(context.Background(), true, fail)
+	r.NoError(err)
+	r.Len(result.Failed, 1)
+
+	entries, err := o.List()
+	r.NoError(err)
+	r.Equal(2, entries[0].Attempts)
+	r.Equal("still down: notification failed to send", entries[0].LastError)
+	r.Equal(testDate.Add(2*cringletest.OutboxBackoff), entries[0].NextAttempt)
+
+	clock.now = entries[0].NextAttempt
+	_, err = o.Flush(context.Background(), false, fail)
+	r.NoError(err)
+
+	entries, err = o.List()
+	r.NoError(err)
+	r.Equal(3, entries[0].Attempts)
+	r.Equal(clock.now.Add(4*cringletest.OutboxBackoff), entries[0].NextAttempt)
+
+	r.Equal(cringletest.OutboxMaxBackoff, o.backoffAfter(100))
+}
+
+func TestFlushNeverResendsSentEntries(t *testing.T) {
+	r := require.New(t)
+	o, _, cleanup := getTestOutbox(t)
+	defer cleanup()
+
+	// an entry left behind by a flush which delivered it but stopped before removing it
+	entry := addTestEntry(r, o, Rates(testRates))
+	entry.Sent = true
+	r.NoError(o.write(entry))
+
+	entries, err := o.List()
+	r.NoError(err)
+	r.Empty(entries)
+
+	sent := 0
+	result, err := o.Flush(context.Background(), true, func(ctx context.Context, e *Entry) error {
+		sent++
+		return nil
+	})
+	r.NoError(err)
+	r.Equal(0, sent)
+	r.Empty(result.Sent)
+
+	_, err = os.Stat(o.path(entry.ID))
+	r.True(os.IsNotExist(err))
+}
+
+func TestDropByPrefix(t *testing.T) {
+	r := require.New(t)
+	o, clock, cleanup := getTestOutbox(t)
+	defer cleanup()
+
+	first := addTestEntry(r, o, Rates(testRates))
+	clock.now = clock.now.Add(time.Second)
+	second := addTestEntry(r, o, Alert(&cringletest.Alert{Rule: "rule", Rate: testRates[0]}))
+
+	dropped, err := o.Drop(first.ID[:8])
+	r.NoError(err)
+	r.Len(dropped, 1)
+	r.Equal(first.ID, dropped[0].ID)
+
+	_, err = o.Drop("nope")
+	r.Equal(ErrNotFound, errors.Cause(err))
+
+	entries, err := o.List()
+	r.NoError(err)
+	r.Len(entries, 1)
+	r.Equal(second.ID, entries[0].ID)
+
+	dropped, err = o.Drop()
+	r.NoError(err)
+	r.Len(dropped, 1)
+
+	entries, err = o.List()
+	r.NoError(err)
+	r.Empty(entries)
+}
+
+func TestListOfMissingOutboxIsEmpty(t *testing.T) {
+	o := New(filepath.Join(os.TempDir(), "cconv-no-such-outbox"))
+	entries, err := o.List()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+// valueNotifier keeps the conversions it is notified of
+type valueNotifier struct {
+	cringletest.Notifier
+	conversions []*cringletest.Conversion
+}
+
+func (n *valueNotifier) NotifyValue(ctx context.Context, conversions []*cringletest.Conversion) error {
+	n.conversions = conversions
+	return nil
+}
+
+func TestValueStoredWithoutConversionsIsRounded(t *testing.T) {
+	r := require.New(t)
+
+	notification := &Notification{}
+	r.NoError(json.Unmarshal([]byte(`{"kind":"value","value":"2","rates":[{"From":"ABC","To":"DEF","Value":"1.23456789012345"}]}`), notification))
+
+	n := &valueNotifier{Notifier: testnotifier.New(nil)}
+	r.NoError(notification.Send(context.Background(), n))
+	r.Len(n.conversions, 1)
+	r.Equal("2", n.conversions[0].Amount.Amount.String())
+	r.Equal("2.47", n.conversions[0].Converted.Amount.String())
+}
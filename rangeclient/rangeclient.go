@@ -0,0 +1,97 @@
+// Package rangeclient adapts any cringletest.RateClient into a cringletest.RangeRateClient
+package rangeclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+)
+
+type rateClient struct {
+	cringletest.RateClient
+	concurrency int
+}
+
+type dayResult struct {
+	date  time.Time
+	rates cringletest.RateMap
+	err   error
+}
+
+// New returns client if it already implements cringletest.RangeRateClient. Otherwise it returns
+// a cringletest.RangeRateClient which fetches each day in a range with GetOn, making at most
+// concurrency requests at once
+func New(client cringletest.RateClient, concurrency int) cringletest.RangeRateClient {
+	if rangeClient, ok := client.(cringletest.RangeRateClient); ok {
+		return rangeClient
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &rateClient{RateClient: client, concurrency: concurrency}
+}
+
+// Day returns date truncated to midnight UTC so that dates from different sources compare equal
+func Day(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Days returns every day from start to end, inclusive
+func Days(start, end time.Time) []time.Time {
+	days := []time.Time{}
+	for day, last := Day(start), Day(end); !day.After(last); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// GetRange implements cringletest.RangeRateClient.GetRange with one GetOn call per day
+func (rc *rateClient) GetRange(ctx context.Context, start, end time.Time, from string, to ...string) (cringletest.RateSeries, error) {
+	if len(to) == 0 {
+		return nil, cringletest.ErrNoToCurrencies
+	}
+
+	days := Days(start, end)
+	if len(days) == 0 {
+		return nil, errors.New("range ends before it starts")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the semaphore bounds the number of requests in flight and every result channel is
+	// buffered so that no goroutine is left blocked if an error ends the range early
+	semaphore := make(chan struct{}, rc.concurrency)
+	channels := []chan *dayResult{}
+	for _, day := range days {
+		ch := make(chan *dayResult, 1)
+		channels = append(channels, ch)
+
+		go func(day time.Time) {
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				ch <- &dayResult{date: day, err: ctx.Err()}
+				return
+			}
+			defer func() { <-semaphore }()
+
+			rates, err := rc.GetOn(ctx, day, from, to...)
+			ch <- &dayResult{date: day, rates: rates, err: err}
+		}(day)
+	}
+
+	series := cringletest.RateSeries{}
+	for _, ch := range channels {
+		result := <-ch
+		if result.err != nil {
+			return nil, result.err
+		}
+		series = append(series, &cringletest.DatedRates{Date: result.date, Rates: result.rates})
+	}
+
+	return series, nil
+}
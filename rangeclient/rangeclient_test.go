@@ -0,0 +1,106 @@
+package rangeclient
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/testclient"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrencyClient records the greatest number of concurrent GetOn calls it receives
+type concurrencyClient struct {
+	cringletest.RateClient
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+func (c *concurrencyClient) GetOn(ctx context.Context, date time.Time, from string, to ...string) (cringletest.RateMap, error) {
+	c.mu.Lock()
+	c.current++
+	if c.current > c.max {
+		c.max = c.current
+	}
+	c.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	c.mu.Lock()
+	c.current--
+	c.mu.Unlock()
+	return c.RateClient.GetOn(ctx, date, from, to...)
+}
+
+type rangeOnlyClient struct {
+	cringletest.RateClient
+}
+
+func (c *rangeOnlyClient) GetRange(ctx context.Context, start, end time.Time, from string, to ...string) (cringletest.RateSeries, error) {
+	return nil, nil
+}
+
+func mustParseDate(t *testing.T, date string) time.Time {
+	d, err := time.Parse("2006-01-02", date)
+	require.NoError(t, err)
+	return d
+}
+
+func TestNewReturnsRangeRateClientsUnchanged(t *testing.T) {
+	r := require.New(t)
+	client := &rangeOnlyClient{testclient.New(nil)}
+
+	r.Equal(client, New(client, 1))
+}
+
+func TestDaysIsInclusive(t *testing.T) {
+	r := require.New(t)
+
+	days := Days(mustParseDate(t, "2018-05-30"), mustParseDate(t, "2018-06-02"))
+	r.Len(days, 4)
+	r.Equal(mustParseDate(t, "2018-05-30"), days[0])
+	r.Equal(mustParseDate(t, "2018-06-02"), days[3])
+}
+
+func TestGetRangeReturnsOrderedSeries(t *testing.T) {
+	r := require.New(t)
+	client := New(testclient.New(nil), 2)
+
+	series, err := client.GetRange(context.Background(), mustParseDate(t, "2018-05-01"), mustParseDate(t, "2018-05-10"), "GBP", "EUR", "USD")
+	r.NoError(err)
+	r.Len(series, 10)
+	for i, day := range series {
+		r.Equal(mustParseDate(t, "2018-05-01").AddDate(0, 0, i), day.Date)
+		r.Contains(day.Rates, "EUR")
+		r.Contains(day.Rates, "USD")
+	}
+}
+
+func TestGetRangeBoundsConcurrency(t *testing.T) {
+	r := require.New(t)
+	counter := &concurrencyClient{RateClient: testclient.New(nil)}
+	client := New(counter, 3)
+
+	_, err := client.GetRange(context.Background(), mustParseDate(t, "2018-05-01"), mustParseDate(t, "2018-05-20"), "GBP", "EUR")
+	r.NoError(err)
+	r.True(counter.max <= 3, "max concurrency was %d", counter.max)
+}
+
+func TestGetRangeReturnsClientError(t *testing.T) {
+	r := require.New(t)
+	client := New(testclient.New(cringletest.ErrBadAuth), 2)
+
+	_, err := client.GetRange(context.Background(), mustParseDate(t, "2018-05-01"), mustParseDate(t, "2018-05-10"), "GBP", "EUR")
+	r.EqualError(err, cringletest.ErrBadAuth.Error())
+}
+
+func TestGetRangeReturnsCorrectErrorWhenNoToCurrenciesSupplied(t *testing.T) {
+	r := require.New(t)
+	client := New(testclient.New(nil), 2)
+
+	_, err := client.GetRange(context.Background(), mustParseDate(t, "2018-05-01"), mustParseDate(t, "2018-05-10"), "GBP")
+	r.EqualError(err, cringletest.ErrNoToCurrencies.Error())
+}
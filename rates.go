@@ -17,12 +17,16 @@ var (
 	ErrBadCurrencies = errors.New("bad currencies")
 )
 
-// ExchangeRate describes An exchange rate of Value between From and To on Date
+// ExchangeRate describes An exchange rate of Value between From and To on Date, as
+// reported by Provider at Fetched. Warning is set when there is reason to doubt the rate
 type ExchangeRate struct {
-	From  string
-	To    string
-	Date  time.Time
-	Value *decimal.Big
+	From     string
+	To       string
+	Date     time.Time
+	Value    *decimal.Big
+	Provider string
+	Warning  string
+	Fetched  time.Time
 }
 
 // RateMap is a map from string to exchange rate
@@ -33,3 +37,26 @@ type RateClient interface {
 	Get(ctx context.Context, from string, to ...string) (rates RateMap, err error)
 	GetOn(ctx context.Context, date time.Time, from string, to ...string) (rates RateMap, err error)
 }
+
+// RateProvider is a RateClient along with the name it is known by
+type RateProvider struct {
+	Name   string
+	Client RateClient
+}
+
+// DatedRates holds the exchange rates available on Date
+type DatedRates struct {
+	Date  time.Time
+	Rates RateMap
+}
+
+// RateSeries is a series of DatedRates in date order
+type RateSeries []*DatedRates
+
+// RangeRateClient describes a client able to get a series of exchange rates between start
+// and end, inclusive, in a single call. Dates on which the provider publishes no rates may
+// be missing from the series
+type RangeRateClient interface {
+	RateClient
+	GetRange(ctx context.Context, start, end time.Time, from string, to ...string) (series RateSeries, err error)
+}
@@ -0,0 +1,87 @@
+package cringletest
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// NotifierFactory builds a Notifier from a notifier URL such as smtp://mail.example.com?to=someone@example.com.
+// The fragment of the URL, if any, has been removed
+type NotifierFactory func(u *url.URL) (Notifier, error)
+
+var (
+	notifierFactoriesMu sync.RWMutex
+	notifierFactories   = map[string]NotifierFactory{}
+)
+
+// RegisterNotifier makes a notifier available through NewNotifier for URLs with scheme. It
+// should be called from the init function of the package which implements the notifier and
+// panics if the scheme is already taken
+func RegisterNotifier(scheme string, factory NotifierFactory) {
+	notifierFactoriesMu.Lock()
+	defer notifierFactoriesMu.Unlock()
+
+	scheme = strings.ToLower(scheme)
+	if _, ok := notifierFactories[scheme]; ok {
+		panic(fmt.Sprintf("notifier scheme %s is already registered", scheme))
+	}
+	notifierFactories[scheme] = factory
+}
+
+// NotifierSchemes returns every registered notifier scheme in order
+func NotifierSchemes() []string {
+	notifierFactoriesMu.RLock()
+	defer notifierFactoriesMu.RUnlock()
+
+	schemes := []string{}
+	for scheme := range notifierFactories {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// NewNotifier builds the Notifier described by rawURL using the factory registered for its
+// scheme. Errors name the URL, with any password removed
+func NewNotifier(rawURL string) (Notifier, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "bad notifier url")
+	}
+	u.Fragment = ""
+
+	if len(u.Scheme) == 0 {
+		return nil, fmt.Errorf("notifier %s has no scheme, expected one of %s", u.Redacted(), strings.Join(NotifierSchemes(), ", "))
+	}
+
+	notifierFactoriesMu.RLock()
+	factory, ok := notifierFactories[u.Scheme]
+	notifierFactoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("notifier %s has unknown scheme %s, expected one of %s", u.Redacted(), u.Scheme, strings.Join(NotifierSchemes(), ", "))
+	}
+
+	notifier, err := factory(u)
+	if err != nil {
+		return nil, errors.Wrapf(err, "notifier %s", u.Redacted())
+	}
+	return notifier, nil
+}
+
+// NotifierName returns the name a notifier URL is known by, which is its fragment if it has one
+// and its scheme otherwise
+func NotifierName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if len(u.Fragment) != 0 {
+		return u.Fragment
+	}
+	return u.Scheme
+}
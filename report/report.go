@@ -0,0 +1,335 @@
+// Package report converts the results of a cconv query into a Document with a stable,
+// machine-readable schema. Every decimal is held as a string so that no precision is lost
+package report
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/robotlovesyou/cringletest"
+)
+
+// Schema identifies the version of the Document layout. It will only change if a field is
+// removed or changes meaning; new fields may be added to the same version
+const Schema = "cconv.report/v1"
+
+// DateFormat is the format of every date in a Document
+const DateFormat = "2006-01-02"
+
+// The kinds of Document
+const (
+	KindRates   = "rates"
+	KindValue   = "value"
+	KindBest    = "best"
+	KindHistory = "history"
+	KindAlert   = "alert"
+	KindNeed    = "need"
+	KindMatrix  = "matrix"
+)
+
+// Columns are the names of the fields of a Row, in order, for tabular formats
+var Columns = []string{"from", "to", "date", "rate", "amount", "converted", "provider", "warning"}
+
+// FeeColumns follow Columns in tabular formats when fees were paid
+var FeeColumns = []string{"gross", "fees", "effective_rate"}
+
+// Document is a report of the results of a single query
+type Document struct {
+	Schema  string   `json:"schema"`
+	Kind    string   `json:"kind"`
+	From    string   `json:"from"`
+	Amount  string   `json:"amount"`
+	Fees    string   `json:"fees,omitempty"`
+	Rows    []*Row   `json:"rates"`
+	Best    *Best    `json:"best,omitempty"`
+	History *History `json:"history,omitempty"`
+	Alert   *Alert   `json:"alert,omitempty"`
+	Need    *Need    `json:"need,omitempty"`
+	Matrix  *Matrix  `json:"matrix,omitempty"`
+}
+
+// Row is a single exchange rate. Converted is Amount of From converted into To at Rate, as
+// rounded for a value query. When fees were paid Converted is the net amount received, Gross what
+// Amount would have bought at Rate, Fees the difference and EffectiveRate what each unit of
+// Amount bought once the fees were paid
+type Row struct {
+	From          string `json:"from"`
+	To            string `json:"to"`
+	Date          string `json:"date"`
+	Rate          string `json:"rate"`
+	Amount        string `json:"amount"`
+	Converted     string `json:"converted"`
+	Gross         string `json:"gross,omitempty"`
+	Fees          string `json:"fees,omitempty"`
+	EffectiveRate string `json:"effective_rate,omitempty"`
+	Provider      string `json:"provider"`
+	Warning       string `json:"warning,omitempty"`
+}
+
+// Best describes the window a best (or worst) rate was chosen from. Until is empty when the
+// window ends today
+type Best struct {
+	Worst bool   `json:"worst"`
+	Days  int    `json:"days"`
+	Until string `json:"until,omitempty"`
+	Stats *Stats `json:"stats,omitempty"`
+}
+
+// Stats holds the statistics for a best rate window
+type Stats struct {
+	Count  int    `json:"count"`
+	Min    string `json:"min"`
+	Max    string `json:"max"`
+	Mean   string `json:"mean"`
+	Median string `json:"median"`
+	StdDev string `json:"stddev"`
+	Gap    string `json:"gap_percent"`
+}
+
+// History describes the period covered by a history Document
+type History struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Interval string `json:"interval"`
+}
+
+// Alert describes the watch rule which matched. Fired is an RFC 3339 timestamp
+type Alert struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Fired   string `json:"fired"`
+}
+
+// Need is the Target amount of Currency to be received in a need Document. Each row is the amount
+// of a different currency which must be converted to receive it, so From and Amount of the
+// Document are empty
+type Need struct {
+	Target   string `json:"target"`
+	Currency string `json:"currency"`
+}
+
+// Matrix holds the rate between every pair of Currencies in a matrix Document. Rates[i][j] is the
+// rate from Currencies[i] to Currencies[j]. Each row of the Document is one of the cross rates
+type Matrix struct {
+	Currencies []string   `json:"currencies"`
+	Rates      [][]string `json:"rates"`
+}
+
+// Decimal formats x as a plain decimal string with every digit it holds
+func Decimal(x *decimal.Big) string {
+	if x == nil {
+		return ""
+	}
+	return fmt.Sprintf("%f", x)
+}
+
+// Date formats date in DateFormat, or returns an empty string if it is zero
+func Date(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(DateFormat)
+}
+
+// Values returns the fields of r in the same order as Columns
+func (r *Row) Values() []string {
+	return []string{r.From, r.To, r.Date, r.Rate, r.Amount, r.Converted, r.Provider, r.Warning}
+}
+
+// Table returns the column names and the values of each row of d for tabular formats. The fee
+// columns are only included when fees were paid. A matrix Document is a table of the rate from the
+// currency in its first column to each of the currencies in the others
+func (d *Document) Table() ([]string, [][]string) {
+	if d.Matrix != nil {
+		return d.Matrix.table()
+	}
+
+	columns, rows := Columns, [][]string{}
+	if len(d.Fees) != 0 {
+		columns = append(append([]string{}, Columns...), FeeColumns...)
+	}
+	for _, row := range d.Rows {
+		values := row.Values()
+		if len(d.Fees) != 0 {
+			values = append(values, row.Gross, row.Fees, row.EffectiveRate)
+		}
+		rows = append(rows, values)
+	}
+	return columns, rows
+}
+
+func (m *Matrix) table() ([]string, [][]string) {
+	columns, rows := append([]string{"from"}, m.Currencies...), [][]string{}
+	for i, from := range m.Currencies {
+		rows = append(rows, append([]string{from}, m.Rates[i]...))
+	}
+	return columns, rows
+}
+
+// NewRow returns a Row for amount converted at rate
+func NewRow(amount *decimal.Big, rate *cringletest.ExchangeRate) *Row {
+	return &Row{
+		From:      rate.From,
+		To:        rate.To,
+		Date:      Date(rate.Date),
+		Rate:      Decimal(rate.Value),
+		Amount:    Decimal(amount),
+		Converted: Decimal(new(decimal.Big).Mul(amount, rate.Value)),
+		Provider:  rate.Provider,
+		Warning:   rate.Warning,
+	}
+}
+
+func newDocument(kind string, amount *decimal.Big, rates []*cringletest.ExchangeRate) *Document {
+	doc := &Document{
+		Schema: Schema,
+		Kind:   kind,
+		Amount: Decimal(amount),
+		Rows:   []*Row{},
+	}
+	for _, rate := range rates {
+		doc.Rows = append(doc.Rows, NewRow(amount, rate))
+	}
+	if len(rates) != 0 {
+		doc.From = rates[0].From
+	}
+	return doc
+}
+
+// sortRates returns a copy of rates sorted by currency so that documents are the same every time
+func sortRates(rates []*cringletest.ExchangeRate) []*cringletest.ExchangeRate {
+	sorted := append([]*cringletest.ExchangeRate{}, rates...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].To < sorted[j].To
+	})
+	return sorted
+}
+
+// FromRates returns a Document for a rates query
+func FromRates(rates []*cringletest.ExchangeRate) *Document {
+	return newDocument(KindRates, decimal.New(1, 0), sortRates(rates))
+}
+
+// addConversions adds a row to doc for each of conversions, exactly as they were rounded
+func addConversions(doc *Document, conversions []*cringletest.Conversion) {
+	for _, conversion := range conversions {
+		row := NewRow(conversion.Amount.Amount, conversion.Rate)
+		row.Converted = Decimal(conversion.Converted.Amount)
+		if charges := conversion.Charges; charges != nil {
+			doc.Fees = charges.Fees.String()
+			row.Gross = Decimal(charges.Gross.Amount)
+			row.Fees = Decimal(charges.Charged.Amount)
+			row.EffectiveRate = Decimal(charges.EffectiveRate)
+		}
+		doc.Rows = append(doc.Rows, row)
+	}
+}
+
+// FromValue returns a Document for an amount converted at each of several rates
+func FromValue(conversions []*cringletest.Conversion) *Document {
+	sorted := append([]*cringletest.Conversion{}, conversions...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Rate.To < sorted[j].Rate.To
+	})
+
+	doc := newDocument(KindValue, nil, nil)
+	addConversions(doc, sorted)
+	if len(sorted) != 0 {
+		doc.From = sorted[0].Amount.Currency
+		doc.Amount = Decimal(sorted[0].Amount.Amount)
+	}
+	return doc
+}
+
+// FromNeed returns a Document for the amounts of each of several currencies which must be
+// converted to receive a target amount. Rows are sorted by the currency converted from
+func FromNeed(requirements []*cringletest.Requirement) *Document {
+	sorted := append([]*cringletest.Requirement{}, requirements...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Rate.From < sorted[j].Rate.From
+	})
+
+	doc := newDocument(KindNeed, nil, nil)
+	conversions := []*cringletest.Conversion{}
+	for _, requirement := range sorted {
+		conversions = append(conversions, requirement.Conversion)
+	}
+	addConversions(doc, conversions)
+	if len(sorted) != 0 {
+		doc.Need = &Need{Target: Decimal(sorted[0].Target.Amount), Currency: sorted[0].Target.Currency}
+	}
+	return doc
+}
+
+// FromBest returns a Document for a best rate query
+func FromBest(best *cringletest.BestRate) *Document {
+	doc := newDocument(KindBest, decimal.New(1, 0), []*cringletest.ExchangeRate{best.Rate})
+	doc.Best = &Best{
+		Worst: best.Worst,
+		Days:  best.Days,
+		Until: Date(best.Until),
+	}
+
+	if stats := best.Stats; stats != nil {
+		doc.Best.Stats = &Stats{
+			Count:  stats.Count,
+			Min:    Decimal(stats.Min),
+			Max:    Decimal(stats.Max),
+			Mean:   Decimal(stats.Mean),
+			Median: Decimal(stats.Median),
+			StdDev: Decimal(stats.StdDev),
+			Gap:    Decimal(stats.Gap),
+		}
+	}
+	return doc
+}
+
+// FromHistory returns a Document for a history query. Rows are in date order and, within
+// each date, in the order of history.To
+func FromHistory(history *cringletest.RateHistory) *Document {
+	rates := []*cringletest.ExchangeRate{}
+	for _, day := range history.Series {
+		for _, currency := range history.To {
+			if rate, ok := day.Rates[currency]; ok {
+				rates = append(rates, rate)
+			}
+		}
+	}
+
+	doc := newDocument(KindHistory, decimal.New(1, 0), rates)
+	doc.From = history.From
+	doc.History = &History{
+		Start:    Date(history.Start),
+		End:      Date(history.End),
+		Interval: history.Interval,
+	}
+	return doc
+}
+
+// FromAlert returns a Document for an alert fired by a watch rule
+func FromAlert(alert *cringletest.Alert) *Document {
+	doc := newDocument(KindAlert, decimal.New(1, 0), []*cringletest.ExchangeRate{alert.Rate})
+	doc.Alert = &Alert{
+		Rule:    alert.Rule,
+		Message: alert.Message,
+		Fired:   alert.Fired.Format(time.RFC3339),
+	}
+	return doc
+}
+
+// FromMatrix returns a Document for the cross rates of a basket of currencies. Rows are in the
+// order of the currencies of matrix
+func FromMatrix(matrix *cringletest.RateMatrix) *Document {
+	doc := newDocument(KindMatrix, decimal.New(1, 0), matrix.CrossRates())
+	doc.Matrix = &Matrix{Currencies: append([]string{}, matrix.Currencies...), Rates: [][]string{}}
+	for _, from := range matrix.Currencies {
+		rates := []string{}
+		for _, to := range matrix.Currencies {
+			rates = append(rates, Decimal(matrix.Rate(from, to).Value))
+		}
+		doc.Matrix.Rates = append(doc.Matrix.Rates, rates)
+	}
+	return doc
+}
@@ -0,0 +1,152 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/stretchr/testify/require"
+)
+
+var testDate = time.Date(2018, 5, 25, 0, 0, 0, 0, time.UTC)
+
+func TestFromValueKeepsEveryDigit(t *testing.T) {
+	r := require.New(t)
+
+	rates := []*cringletest.ExchangeRate{
+		&cringletest.ExchangeRate{From: "ABC", To: "GHI", Date: testDate, Value: decimal.New(123456789, 8), Provider: "ecb"},
+		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: testDate, Value: decimal.New(1, -2), Warning: "careful"},
+	}
+
+	conversions, err := cringletest.Convert(cringletest.NewMoney(decimal.New(15, 1), "ABC"), rates, &cringletest.Rounding{Places: 9})
+	r.NoError(err)
+
+	doc := FromValue(conversions)
+	r.Equal(Schema, doc.Schema)
+	r.Equal(KindValue, doc.Kind)
+	r.Equal("ABC", doc.From)
+	r.Equal("1.5", doc.Amount)
+	r.Len(doc.Rows, 2)
+
+	// rows are sorted by currency
+	r.Equal(&Row{From: "ABC", To: "DEF", Date: "2018-05-25", Rate: "100", Amount: "1.5", Converted: "150.000000000", Warning: "careful"}, doc.Rows[0])
+	r.Equal(&Row{From: "ABC", To: "GHI", Date: "2018-05-25", Rate: "1.23456789", Amount: "1.5", Converted: "1.851851835", Provider: "ecb"}, doc.Rows[1])
+}
+
+func TestFromValueWithFeesAddsFeeColumns(t *testing.T) {
+	r := require.New(t)
+
+	rates := []*cringletest.ExchangeRate{
+		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: testDate, Value: decimal.New(2, 0)},
+	}
+	fees := &cringletest.Fees{Percent: decimal.New(1, 0)}
+
+	conversions, err := cringletest.ConvertWithFees(cringletest.NewMoney(decimal.New(100, 0), "ABC"), rates, cringletest.DefaultRounding, fees)
+	r.NoError(err)
+
+	doc := FromValue(conversions)
+	r.Equal("1%", doc.Fees)
+	r.Equal(&Row{From: "ABC", To: "DEF", Date: "2018-05-25", Rate: "2", Amount: "100", Converted: "198.00", Gross: "200.00", Fees: "2.00", EffectiveRate: "1.98"}, doc.Rows[0])
+
+	columns, rows := doc.Table()
+	r.Equal(append(append([]string{}, Columns...), FeeColumns...), columns)
+	r.Equal([]string{"200.00", "2.00", "1.98"}, rows[0][len(Columns):])
+
+	columns, rows = FromValue(convertWithoutFees(r, rates)).Table()
+	r.Equal(Columns, columns)
+	r.Len(rows[0], len(Columns))
+}
+
+func convertWithoutFees(r *require.Assertions, rates []*cringletest.ExchangeRate) []*cringletest.Conversion {
+	conversions, err := cringletest.Convert(cringletest.NewMoney(decimal.New(100, 0), "ABC"), rates, cringletest.DefaultRounding)
+	r.NoError(err)
+	return conversions
+}
+
+func TestFromNeedHasARowForEachSource(t *testing.T) {
+	r := require.New(t)
+
+	rates := []*cringletest.ExchangeRate{
+		&cringletest.ExchangeRate{From: "GHI", To: "ABC", Date: testDate, Value: decimal.New(5, 1)},
+		&cringletest.ExchangeRate{From: "DEF", To: "ABC", Date: testDate, Value: decimal.New(2, 0)},
+	}
+	requirements, err := cringletest.Need(cringletest.NewMoney(decimal.New(10, 0), "ABC"), rates, cringletest.DefaultRounding, nil)
+	r.NoError(err)
+
+	doc := FromNeed(requirements)
+	r.Equal(KindNeed, doc.Kind)
+	r.Equal("", doc.From)
+	r.Equal(&Need{Target: "10", Currency: "ABC"}, doc.Need)
+	r.Len(doc.Rows, 2)
+
+	// rows are sorted by the currency converted from, and 19.99 GHI buys 9.995, which rounds to 10.00
+	r.Equal(&Row{From: "DEF", To: "ABC", Date: "2018-05-25", Rate: "2", Amount: "5.00", Converted: "10.00"}, doc.Rows[0])
+	r.Equal(&Row{From: "GHI", To: "ABC", Date: "2018-05-25", Rate: "0.5", Amount: "19.99", Converted: "10.00"}, doc.Rows[1])
+}
+
+func TestFromMatrixTabulatesEveryPair(t *testing.T) {
+	r := require.New(t)
+
+	matrix, err := cringletest.NewRateMatrix([]string{"ABC", "DEF"}, cringletest.RateMap{
+		"DEF": &cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: testDate, Value: decimal.New(4, 0)},
+	})
+	r.NoError(err)
+
+	doc := FromMatrix(matrix)
+	r.Equal(KindMatrix, doc.Kind)
+	r.Equal(&Matrix{Currencies: []string{"ABC", "DEF"}, Rates: [][]string{{"1", "4"}, {"0.25", "1"}}}, doc.Matrix)
+	r.Len(doc.Rows, 2)
+	r.Equal(&Row{From: "DEF", To: "ABC", Date: "2018-05-25", Rate: "0.25", Amount: "1", Converted: "0.25"}, doc.Rows[1])
+
+	columns, rows := doc.Table()
+	r.Equal([]string{"from", "ABC", "DEF"}, columns)
+	r.Equal([][]string{{"ABC", "1", "4"}, {"DEF", "0.25", "1"}}, rows)
+}
+
+func TestFromBestIncludesStats(t *testing.T) {
+	r := require.New(t)
+
+	rates := []*cringletest.ExchangeRate{
+		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: testDate, Value: decimal.New(12, 1)},
+		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: testDate.AddDate(0, 0, -1), Value: decimal.New(10, 1)},
+	}
+
+	doc := FromBest(&cringletest.BestRate{Rate: rates[0], Days: 2, Until: testDate, Stats: cringletest.NewRateStats(rates)})
+	r.Equal(KindBest, doc.Kind)
+	r.Len(doc.Rows, 1)
+	r.Equal("1.2", doc.Rows[0].Rate)
+	r.Equal(&Best{Days: 2, Until: "2018-05-25", Stats: doc.Best.Stats}, doc.Best)
+	r.Equal(2, doc.Best.Stats.Count)
+	r.Equal("1.1", doc.Best.Stats.Mean)
+	r.Equal("20", doc.Best.Stats.Gap)
+}
+
+func TestFromHistoryOrdersRowsByDateThenCurrency(t *testing.T) {
+	r := require.New(t)
+
+	series := cringletest.RateSeries{}
+	for i := 0; i < 2; i++ {
+		date := testDate.AddDate(0, 0, i)
+		series = append(series, &cringletest.DatedRates{Date: date, Rates: cringletest.RateMap{
+			"DEF": &cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: date, Value: decimal.New(1, 0)},
+			"GHI": &cringletest.ExchangeRate{From: "ABC", To: "GHI", Date: date, Value: decimal.New(2, 0)},
+		}})
+	}
+
+	doc := FromHistory(&cringletest.RateHistory{
+		From:     "ABC",
+		To:       []string{"GHI", "DEF"},
+		Start:    testDate,
+		End:      testDate.AddDate(0, 0, 1),
+		Interval: cringletest.IntervalDay,
+		Series:   series,
+	})
+	r.Equal(&History{Start: "2018-05-25", End: "2018-05-26", Interval: "day"}, doc.History)
+
+	order := []string{}
+	for _, row := range doc.Rows {
+		order = append(order, row.Date+" "+row.To)
+	}
+	r.Equal([]string{"2018-05-25 GHI", "2018-05-25 DEF", "2018-05-26 GHI", "2018-05-26 DEF"}, order)
+}
@@ -113,7 +113,10 @@ func TestNotifyValueSendsOK(t *testing.T) {
 		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: time.Now(), Value: decimal.New(1234, 3)},
 	}
 
-	err = sender.NotifyValue(context.Background(), decimal.New(2, 0), rates)
+	conversions, err := cringletest.Convert(cringletest.NewMoney(decimal.New(2, 0), "ABC"), rates, cringletest.DefaultRounding)
+	r.NoError(err)
+
+	err = sender.NotifyValue(context.Background(), conversions)
 	r.NoError(err)
 }
 
@@ -125,6 +128,6 @@ func TestNotifyBestSendsOK(t *testing.T) {
 
 	rate := &cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: time.Now(), Value: decimal.New(1234, 3)}
 
-	err = sender.NotifyBest(context.Background(), rate)
+	err = sender.NotifyBest(context.Background(), &cringletest.BestRate{Rate: rate, Days: 7, Stats: cringletest.NewRateStats([]*cringletest.ExchangeRate{rate})})
 	r.NoError(err)
 }
@@ -0,0 +1,45 @@
+package smtpnotifier
+
+import (
+	"net/smtp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// loginAuth implements the LOGIN authentication mechanism, which net/smtp does not, but which
+// some servers still insist on
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	// like smtp.PlainAuth, never send the password over a connection which is not encrypted
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	}
+	return nil, errors.Errorf("unexpected login challenge %q", fromServer)
+}
@@ -0,0 +1,261 @@
+package smtpnotifier
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeMail is a mail received by a fakeServer
+type fakeMail struct {
+	mechanism string
+	username  string
+	tls       bool
+	from      string
+	to        []string
+	data      []byte
+}
+
+// fakeServer is an in-process SMTP server which records the mail it is sent. It offers STARTTLS
+// when startTLS is set, speaks TLS from the start when implicitTLS is set and offers PLAIN and LOGIN
+// authentication when username is set
+type fakeServer struct {
+	listener    net.Listener
+	tlsConfig   *tls.Config
+	startTLS    bool
+	implicitTLS bool
+	username    string
+	password    string
+
+	mu    sync.Mutex
+	mails []*fakeMail
+}
+
+// newTestCertificate returns a self-signed certificate for 127.0.0.1 along with a pool which trusts it
+func newTestCertificate() (tls.Certificate, *x509.CertPool, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fake smtp"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool, nil
+}
+
+// start listens on a random local port and returns a client tls.Config which trusts the server
+func (s *fakeServer) start() (*tls.Config, error) {
+	cert, pool, err := newTestCertificate()
+	if err != nil {
+		return nil, err
+	}
+	s.tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	s.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	if s.implicitTLS {
+		s.listener = tls.NewListener(s.listener, s.tlsConfig)
+	}
+
+	go func() {
+		for {
+			conn, err := s.listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+
+	return &tls.Config{RootCAs: pool}, nil
+}
+
+func (s *fakeServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeServer) close() {
+	s.listener.Close()
+}
+
+func (s *fakeServer) received() []*fakeMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*fakeMail{}, s.mails...)
+}
+
+func decode(s string) string {
+	b, _ := base64.StdEncoding.DecodeString(s)
+	return string(b)
+}
+
+// address returns the address between the angle brackets of a MAIL or RCPT command
+func address(arg string) string {
+	start, end := strings.Index(arg, "<"), strings.Index(arg, ">")
+	if start == -1 || end < start {
+		return ""
+	}
+	return arg[start+1 : end]
+}
+
+func challenge(tp *textproto.Conn, prompt string) (string, error) {
+	if err := tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(prompt))); err != nil {
+		return "", err
+	}
+	line, err := tp.ReadLine()
+	return decode(line), err
+}
+
+// auth handles an AUTH command and reports whether it succeeded
+func (s *fakeServer) auth(tp *textproto.Conn, arg string, mail *fakeMail) (bool, error) {
+	fields := strings.Fields(arg)
+	if len(fields) == 0 {
+		return false, nil
+	}
+	mail.mechanism = strings.ToUpper(fields[0])
+
+	var username, password string
+	switch mail.mechanism {
+	case "PLAIN":
+		response := ""
+		if len(fields) > 1 {
+			response = decode(fields[1])
+		} else {
+			var err error
+			if response, err = challenge(tp, ""); err != nil {
+				return false, err
+			}
+		}
+		parts := strings.Split(response, "\x00")
+		if len(parts) != 3 {
+			return false, nil
+		}
+		username, password = parts[1], parts[2]
+	case "LOGIN":
+		var err error
+		if username, err = challenge(tp, "Username:"); err != nil {
+			return false, err
+		}
+		if password, err = challenge(tp, "Password:"); err != nil {
+			return false, err
+		}
+	default:
+		return false, nil
+	}
+
+	mail.username = username
+	return username == s.username && password == s.password, nil
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	mail := &fakeMail{}
+	_, mail.tls = conn.(*tls.Conn)
+
+	tp.PrintfLine("220 127.0.0.1 fake ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg := line, ""
+		if space := strings.Index(line, " "); space != -1 {
+			verb, arg = line[:space], line[space+1:]
+		}
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			lines := []string{"127.0.0.1 greets you"}
+			if s.startTLS && !mail.tls {
+				lines = append(lines, "STARTTLS")
+			}
+			if len(s.username) != 0 {
+				lines = append(lines, "AUTH PLAIN LOGIN")
+			}
+			lines = append(lines, "8BITMIME")
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				tp.PrintfLine("250%s%s", sep, l)
+			}
+		case "STARTTLS":
+			tp.PrintfLine("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			tp = textproto.NewConn(conn)
+			mail.tls = true
+		case "AUTH":
+			ok, err := s.auth(tp, arg, mail)
+			if err != nil {
+				return
+			}
+			if !ok {
+				tp.PrintfLine("535 5.7.8 authentication credentials invalid")
+				continue
+			}
+			tp.PrintfLine("235 2.7.0 authentication successful")
+		case "MAIL":
+			mail.from = address(arg)
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			mail.to = append(mail.to, address(arg))
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.data = data
+			s.mu.Lock()
+			s.mails = append(s.mails, mail)
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 command not implemented")
+		}
+	}
+}
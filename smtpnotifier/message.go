@@ -0,0 +1,98 @@
+package smtpnotifier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/templates"
+)
+
+// messageID returns a Message-ID in the domain of the from address. It is made from key if there
+// is one, so that a notification sent again has the same Message-ID, and is random otherwise
+func messageID(from, key string) (string, error) {
+	if len(key) == 0 {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return "", err
+		}
+		key = hex.EncodeToString(id)
+	}
+
+	domain := "cconv"
+	if at := strings.LastIndex(from, "@"); at != -1 {
+		domain = from[at+1:]
+	}
+	return fmt.Sprintf("<%s@%s>", key, domain), nil
+}
+
+// writePart writes body as a quoted-printable part of mw
+func writePart(mw *multipart.Writer, contentType, body string) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType+"; charset=utf-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// buildMessage returns msg as a multipart/alternative mail with a plain text part followed by an
+// html part, so that mail readers show the html if they can. key is the idempotency key of the
+// notification, if it has one
+func buildMessage(from, to string, msg *templates.Message, date time.Time, key string) ([]byte, error) {
+	id, err := messageID(from, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create message id")
+	}
+
+	body := bytes.NewBuffer(nil)
+	mw := multipart.NewWriter(body)
+	if err := writePart(mw, "text/plain", msg.Text); err != nil {
+		return nil, errors.Wrap(err, "could not write text part")
+	}
+	if err := writePart(mw, "text/html", msg.HTML); err != nil {
+		return nil, errors.Wrap(err, "could not write html part")
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	headers := []struct{ name, value string }{
+		{"From", (&mail.Address{Address: from}).String()},
+		{"To", (&mail.Address{Address: to}).String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Subject)},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"Message-ID", id},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", mw.Boundary())},
+	}
+	if len(key) != 0 {
+		headers = append(headers, struct{ name, value string }{cringletest.IdempotencyKeyHeader, key})
+	}
+
+	out := bytes.NewBuffer(nil)
+	for _, header := range headers {
+		fmt.Fprintf(out, "%s: %s\r\n", header.name, header.value)
+	}
+	out.WriteString("\r\n")
+	out.Write(body.Bytes())
+	return out.Bytes(), nil
+}
@@ -0,0 +1,142 @@
+package cringletest
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+)
+
+// statsPrecision is the number of significant digits used when calculating statistics
+const statsPrecision = 16
+
+// BestRate describes the best (or, if Worst is set, the worst) exchange rate found over the
+// Days days up to and including Until. Until is zero when the window ends today. Stats is
+// only set when statistics for the whole window were requested
+type BestRate struct {
+	Rate  *ExchangeRate
+	Worst bool
+	Days  int
+	Until time.Time
+	Stats *RateStats
+}
+
+// RateStats summarises a series of exchange rates between the same currencies. Gap is the
+// difference between the best and worst rates as a percentage of the worst
+type RateStats struct {
+	Count  int
+	Min    *decimal.Big
+	Max    *decimal.Big
+	Mean   *decimal.Big
+	Median *decimal.Big
+	StdDev *decimal.Big
+	Gap    *decimal.Big
+}
+
+func newStatsDecimal() *decimal.Big {
+	return decimal.WithPrecision(statsPrecision)
+}
+
+// plain removes any positive exponent from x so that it formats as a plain number, e.g. 100
+// rather than 1E+2
+func plain(x *decimal.Big) *decimal.Big {
+	if x.Scale() < 0 {
+		x.Quantize(0)
+	}
+	return x
+}
+
+// PercentChange returns the change from previous to current as a percentage of previous, rounded
+// to two decimal places, or nil if previous is zero. It is quantized so that it always formats
+// with two places, since a quotient can carry a positive exponent which %f does not pad
+func PercentChange(previous, current *decimal.Big) *decimal.Big {
+	if previous.Sign() == 0 {
+		return nil
+	}
+	percent := new(decimal.Big).Sub(current, previous)
+	percent.Mul(percent, decimal.New(100, 0))
+	return percent.Quo(percent, previous).Quantize(2)
+}
+
+// sqrt returns the square root of x using Newton's method
+func sqrt(x *decimal.Big) *decimal.Big {
+	if x.Sign() <= 0 {
+		return newStatsDecimal()
+	}
+
+	guess := newStatsDecimal()
+	if f, ok := x.Float64(); ok && f > 0 {
+		guess.SetFloat64(f)
+	} else {
+		guess.Set(x)
+	}
+
+	two := decimal.New(2, 0)
+	for i := 0; i < 50; i++ {
+		next := newStatsDecimal().Quo(x, guess)
+		next.Add(next, guess)
+		next.Quo(next, two)
+		if next.Cmp(guess) == 0 {
+			break
+		}
+		guess = next
+	}
+	return guess
+}
+
+// NewRateStats calculates statistics for rates. It returns nil if there are no rates
+func NewRateStats(rates []*ExchangeRate) *RateStats {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	values := []*decimal.Big{}
+	for _, rate := range rates {
+		values = append(values, rate.Value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Cmp(values[j]) < 0
+	})
+
+	count := decimal.New(int64(len(values)), 0)
+	stats := &RateStats{
+		Count: len(values),
+		Min:   newStatsDecimal().Set(values[0]),
+		Max:   newStatsDecimal().Set(values[len(values)-1]),
+	}
+
+	sum := newStatsDecimal()
+	for _, value := range values {
+		sum.Add(sum, value)
+	}
+	stats.Mean = newStatsDecimal().Quo(sum, count)
+
+	mid := len(values) / 2
+	if len(values)%2 == 1 {
+		stats.Median = newStatsDecimal().Set(values[mid])
+	} else {
+		stats.Median = newStatsDecimal().Add(values[mid-1], values[mid])
+		stats.Median.Quo(stats.Median, decimal.New(2, 0))
+	}
+
+	// population standard deviation, the window is the whole population of interest
+	squares := newStatsDecimal()
+	for _, value := range values {
+		diff := newStatsDecimal().Sub(value, stats.Mean)
+		squares.Add(squares, diff.Mul(diff, diff))
+	}
+	stats.StdDev = sqrt(squares.Quo(squares, count))
+
+	stats.Gap = newStatsDecimal()
+	if stats.Min.Sign() != 0 {
+		stats.Gap.Sub(stats.Max, stats.Min)
+		stats.Gap.Mul(stats.Gap, decimal.New(100, 0))
+		stats.Gap.Quo(stats.Gap, stats.Min)
+	}
+
+	for _, value := range []*decimal.Big{stats.Min, stats.Max, stats.Mean, stats.Median, stats.StdDev, stats.Gap} {
+		plain(value)
+	}
+
+	return stats
+}
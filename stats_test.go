@@ -0,0 +1,56 @@
+package cringletest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParseDecimal(value string) *decimal.Big {
+	d, _ := new(decimal.Big).SetString(value)
+	return d
+}
+
+func ratesOf(values ...string) []*ExchangeRate {
+	rates := []*ExchangeRate{}
+	for _, value := range values {
+		rates = append(rates, &ExchangeRate{From: "ABC", To: "DEF", Value: mustParseDecimal(value)})
+	}
+	return rates
+}
+
+func TestNewRateStats(t *testing.T) {
+	r := require.New(t)
+
+	stats := NewRateStats(ratesOf("2", "4", "4", "4", "5", "5", "7", "9"))
+	r.Equal(8, stats.Count)
+	r.Equal(0, stats.Min.Cmp(mustParseDecimal("2")))
+	r.Equal(0, stats.Max.Cmp(mustParseDecimal("9")))
+	r.Equal(0, stats.Mean.Cmp(mustParseDecimal("5")))
+	r.Equal(0, stats.Median.Cmp(mustParseDecimal("4.5")))
+	r.Equal(0, stats.StdDev.Cmp(mustParseDecimal("2")), stats.StdDev.String())
+	r.Equal(0, stats.Gap.Cmp(mustParseDecimal("350")))
+}
+
+func TestNewRateStatsWithOddNumberOfRates(t *testing.T) {
+	r := require.New(t)
+
+	stats := NewRateStats(ratesOf("1.10", "1.30", "1.20"))
+	r.Equal(0, stats.Median.Cmp(mustParseDecimal("1.20")))
+	r.Equal(0, stats.Mean.Cmp(mustParseDecimal("1.20")))
+}
+
+func TestNewRateStatsWithNoRates(t *testing.T) {
+	require.Nil(t, NewRateStats(nil))
+}
+
+func TestPercentChange(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("-2.00", fmt.Sprintf("%f", PercentChange(decimal.New(1, 0), decimal.New(98, 2))))
+	// 0.5 to 1 is a quotient of 1E+2, which must still format with two places
+	r.Equal("100.00", fmt.Sprintf("%f", PercentChange(decimal.New(5, 1), decimal.New(1, 0))))
+	r.Nil(PercentChange(new(decimal.Big), decimal.New(1, 0)))
+}
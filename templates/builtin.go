@@ -0,0 +1,233 @@
+package templates
+
+import "github.com/robotlovesyou/cringletest/report"
+
+// The built in templates for outgoing email. The html templates are rendered with plush and the
+// subjects and plain text templates with text/template, all from the same data. Any of them can
+// be overridden by a template directory. There are no built in console templates since the
+// console notifier writes its own layout unless it is overridden
+
+const ratesHTML = `
+<p><strong>Hello,</strong></p>
+<p><strong>here are the rates you requested for <%= date %></strong></p>
+<table>
+	<%= for (rate) in rates { %>
+		<tr>
+			<td><%= rate.Original %></td><td>Will buy you</td><td><%= rate.Converted %></td>
+		</tr>
+		<%= if (rate.Warning != "") { %><tr><td colspan="3"><em>Warning: <%= rate.Warning %></em></td></tr><% } %>
+	<% } %>
+</table>
+<%= if (provider != "") { %><p>Rates provided by <%= provider %></p><% } %>
+`
+
+const ratesText = `Hello,
+
+here are the rates you requested for {{.date}}
+
+{{range .rates}}{{.Original}} will buy you {{.Converted}}
+{{if .Warning}}    Warning: {{.Warning}}
+{{end}}{{end}}{{if .provider}}
+Rates provided by {{.provider}}
+{{end}}`
+
+const valueHTML = `
+<p><strong>Hello,</strong></p>
+<p><strong>here are the currency conversions requested for <%= date %></strong></p>
+<table>
+	<%= for (rate) in rates { %>
+		<tr>
+			<td><%= rate.Original %></td><td>Will buy you</td><td><%= rate.Converted %></td>
+		</tr>
+		<%= if (rate.Gross != "") { %><tr><td colspan="3">Gross <%= rate.Gross %>, fees <%= rate.Fees %>, net <%= rate.Converted %> at an effective rate of <%= rate.EffectiveRate %></td></tr><% } %>
+		<%= if (rate.Warning != "") { %><tr><td colspan="3"><em>Warning: <%= rate.Warning %></em></td></tr><% } %>
+	<% } %>
+</table>
+<%= if (fees != "") { %><p>Fees charged: <%= fees %></p><% } %>
+<%= if (provider != "") { %><p>Rates provided by <%= provider %></p><% } %>
+`
+
+const valueText = `Hello,
+
+here are the currency conversions requested for {{.date}}
+
+{{range .rates}}{{.Original}} will buy you {{.Converted}}
+{{if .Gross}}    Gross {{.Gross}}, fees {{.Fees}}, net {{.Converted}} at an effective rate of {{.EffectiveRate}}
+{{end}}{{if .Warning}}    Warning: {{.Warning}}
+{{end}}{{end}}{{if .fees}}
+Fees charged: {{.fees}}
+{{end}}{{if .provider}}
+Rates provided by {{.provider}}
+{{end}}`
+
+const needHTML = `
+<p><strong>Hello,</strong></p>
+<p><strong>here is what you need to convert to receive <%= target %> on <%= date %></strong></p>
+<table>
+	<%= for (rate) in rates { %>
+		<tr>
+			<td><%= rate.Original %></td><td>Will buy you</td><td><%= rate.Converted %></td>
+		</tr>
+		<%= if (rate.Gross != "") { %><tr><td colspan="3">Gross <%= rate.Gross %>, fees <%= rate.Fees %>, net <%= rate.Converted %> at an effective rate of <%= rate.EffectiveRate %></td></tr><% } %>
+		<%= if (rate.Warning != "") { %><tr><td colspan="3"><em>Warning: <%= rate.Warning %></em></td></tr><% } %>
+	<% } %>
+</table>
+<%= if (fees != "") { %><p>Fees charged: <%= fees %></p><% } %>
+<%= if (provider != "") { %><p>Rates provided by <%= provider %></p><% } %>
+`
+
+const needText = `Hello,
+
+here is what you need to convert to receive {{.target}} on {{.date}}
+
+{{range .rates}}{{.Original}} will buy you {{.Converted}}
+{{if .Gross}}    Gross {{.Gross}}, fees {{.Fees}}, net {{.Converted}} at an effective rate of {{.EffectiveRate}}
+{{end}}{{if .Warning}}    Warning: {{.Warning}}
+{{end}}{{end}}{{if .fees}}
+Fees charged: {{.fees}}
+{{end}}{{if .provider}}
+Rates provided by {{.provider}}
+{{end}}`
+
+const matrixHTML = `
+<p><strong>Hello,</strong></p>
+<p><strong>here are the cross rates of <%= basket %> for <%= date %></strong></p>
+<table>
+	<tr><th></th><%= for (to) in currencies { %><th><%= to %></th><% } %></tr>
+	<%= for (row) in rows { %>
+		<tr><th><%= row.From %></th><%= for (rate) in row.Rates { %><td><%= rate %></td><% } %></tr>
+	<% } %>
+</table>
+<%= for (warning) in warnings { %><p><em>Warning: <%= warning %></em></p><% } %>
+<%= if (provider != "") { %><p>Rates provided by <%= provider %></p><% } %>
+`
+
+const matrixText = `Hello,
+
+here are the cross rates of {{.basket}} for {{.date}}
+
+{{printf "%-4s" ""}}{{range .currencies}}{{printf " %12s" .}}{{end}}
+{{range .rows}}{{printf "%-4s" .From}}{{range .Rates}}{{printf " %12s" .}}{{end}}
+{{end}}{{range .warnings}}
+Warning: {{.}}{{end}}{{if .provider}}
+Rates provided by {{.provider}}
+{{end}}`
+
+const bestHTML = `
+<p><strong>Hello,</strong><p>
+<p>The <%= kind %> rate between <%= from %> and <%= to %> in <%= window %> was <%= rate %> on <%= date %></p>
+<%= if (hasStats) { %>
+<table>
+	<tr><td>Rates</td><td><%= stats.Count %></td></tr>
+	<tr><td>Min</td><td><%= stats.Min %></td></tr>
+	<tr><td>Max</td><td><%= stats.Max %></td></tr>
+	<tr><td>Mean</td><td><%= stats.Mean %></td></tr>
+	<tr><td>Median</td><td><%= stats.Median %></td></tr>
+	<tr><td>Standard deviation</td><td><%= stats.StdDev %></td></tr>
+	<tr><td>Best/worst gap</td><td><%= stats.Gap %></td></tr>
+</table>
+<% } %>
+<%= if (warning != "") { %><p><em>Warning: <%= warning %></em></p><% } %>
+<%= if (provider != "") { %><p>Rates provided by <%= provider %></p><% } %>
+`
+
+const bestText = `Hello,
+
+The {{.kind}} rate between {{.from}} and {{.to}} in {{.window}} was {{.rate}} on {{.date}}
+{{if .hasStats}}
+Rates:              {{.stats.Count}}
+Min:                {{.stats.Min}}
+Max:                {{.stats.Max}}
+Mean:               {{.stats.Mean}}
+Median:             {{.stats.Median}}
+Standard deviation: {{.stats.StdDev}}
+Best/worst gap:     {{.stats.Gap}}
+{{end}}{{if .warning}}
+Warning: {{.warning}}
+{{end}}{{if .provider}}
+Rates provided by {{.provider}}
+{{end}}`
+
+const historyHTML = `
+<p><strong>Hello,</strong></p>
+<p><strong>here is the history of <%= from %> from <%= start %> to <%= end %> by <%= interval %></strong></p>
+<%= for (table) in tables { %>
+	<h3><%= from %> to <%= table.To %></h3>
+	<table>
+		<tr><th>Date</th><th>Rate</th><th>Change</th><th>Change %</th></tr>
+		<%= for (row) in table.Rows { %>
+			<tr><td><%= row.Date %></td><td><%= row.Rate %></td><td><%= row.Change %></td><td><%= row.Percent %></td></tr>
+			<%= if (row.Warning != "") { %><tr><td colspan="4"><em>Warning: <%= row.Warning %></em></td></tr><% } %>
+		<% } %>
+	</table>
+<% } %>
+`
+
+const historyText = `Hello,
+
+here is the history of {{.from}} from {{.start}} to {{.end}} by {{.interval}}
+{{range .tables}}
+{{$.from}} to {{.To}}
+{{range .Rows}}{{printf "%-16s %12s %10s %9s" .Date .Rate .Change .Percent}}
+{{if .Warning}}    Warning: {{.Warning}}
+{{end}}{{end}}{{end}}`
+
+const alertHTML = `
+<p><strong>Hello,</strong></p>
+<p><strong>your watch rule <%= rule %> matched at <%= fired %></strong></p>
+<p><%= message %></p>
+<table>
+	<tr>
+		<td><%= rate.Original %></td><td>Will buy you</td><td><%= rate.Converted %></td>
+	</tr>
+	<%= if (rate.Warning != "") { %><tr><td colspan="3"><em>Warning: <%= rate.Warning %></em></td></tr><% } %>
+</table>
+<%= if (rate.Provider != "") { %><p>Rates provided by <%= rate.Provider %></p><% } %>
+`
+
+const alertText = `Hello,
+
+your watch rule {{.rule}} matched at {{.fired}}
+
+{{.message}}
+
+{{.rate.Original}} will buy you {{.rate.Converted}}
+{{if .rate.Warning}}    Warning: {{.rate.Warning}}
+{{end}}{{if .rate.Provider}}
+Rates provided by {{.rate.Provider}}
+{{end}}`
+
+const (
+	ratesSubject   = "Your exchange rates"
+	valuesSubject  = "Your currency conversions"
+	bestSubject    = "Your {{.kind}} exchange rate"
+	historySubject = "Your exchange rate history"
+	alertSubject   = "Exchange rate alert: {{.rule}}"
+	needSubject    = "What you need to receive {{.target}}"
+	matrixSubject  = "Your cross rates for {{.basket}}"
+)
+
+// builtin holds the built in templates by name
+var builtin = map[string]string{
+	name(report.KindRates, PartSubject):   ratesSubject,
+	name(report.KindRates, PartHTML):      ratesHTML,
+	name(report.KindRates, PartText):      ratesText,
+	name(report.KindValue, PartSubject):   valuesSubject,
+	name(report.KindValue, PartHTML):      valueHTML,
+	name(report.KindValue, PartText):      valueText,
+	name(report.KindBest, PartSubject):    bestSubject,
+	name(report.KindBest, PartHTML):       bestHTML,
+	name(report.KindBest, PartText):       bestText,
+	name(report.KindHistory, PartSubject): historySubject,
+	name(report.KindHistory, PartHTML):    historyHTML,
+	name(report.KindHistory, PartText):    historyText,
+	name(report.KindAlert, PartSubject):   alertSubject,
+	name(report.KindAlert, PartHTML):      alertHTML,
+	name(report.KindAlert, PartText):      alertText,
+	name(report.KindNeed, PartSubject):    needSubject,
+	name(report.KindNeed, PartHTML):       needHTML,
+	name(report.KindNeed, PartText):       needText,
+	name(report.KindMatrix, PartSubject):  matrixSubject,
+	name(report.KindMatrix, PartHTML):     matrixHTML,
+	name(report.KindMatrix, PartText):     matrixText,
+}
@@ -0,0 +1,112 @@
+package templates
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/locale"
+	"github.com/robotlovesyou/cringletest/report"
+)
+
+// sampleData returns Data for a made up notification of kind which sets every key, so that
+// checking a template exercises as much of it as possible
+func sampleData(kind string) (Data, error) {
+	l := locale.Default()
+	date := time.Date(2018, 5, 25, 0, 0, 0, 0, time.UTC)
+	rates := []*cringletest.ExchangeRate{
+		{From: "GBP", To: "EUR", Date: date, Value: decimal.New(114371, 5), Provider: "ecb", Warning: "providers disagree by 62 basis points"},
+		{From: "GBP", To: "USD", Date: date, Value: decimal.New(133410, 5), Provider: "ecb"},
+	}
+
+	switch kind {
+	case report.KindRates:
+		return RatesData(l, rates)
+	case report.KindValue:
+		fees := &cringletest.Fees{Percent: decimal.New(5, 1), Fixed: cringletest.NewMoney(decimal.New(2, 0), "GBP"), Spread: decimal.New(150, 0)}
+		conversions, err := cringletest.ConvertWithFees(cringletest.NewMoney(decimal.New(10050, 2), "GBP"), rates, cringletest.DefaultRounding, fees)
+		if err != nil {
+			return nil, err
+		}
+		return ValueData(l, conversions)
+	case report.KindNeed:
+		fees := &cringletest.Fees{Percent: decimal.New(5, 1), Spread: decimal.New(150, 0)}
+		inverted, err := rates[0].Invert()
+		if err != nil {
+			return nil, err
+		}
+		requirements, err := cringletest.Need(cringletest.NewMoney(decimal.New(50000, 2), "GBP"), []*cringletest.ExchangeRate{inverted}, cringletest.DefaultRounding, fees)
+		if err != nil {
+			return nil, err
+		}
+		return NeedData(l, requirements)
+	case report.KindMatrix:
+		matrix, err := cringletest.NewRateMatrix([]string{"GBP", "EUR", "USD"}, cringletest.RateMap{"EUR": rates[0], "USD": rates[1]})
+		if err != nil {
+			return nil, err
+		}
+		return MatrixData(l, matrix)
+	case report.KindBest:
+		earlier := &cringletest.ExchangeRate{From: "GBP", To: "EUR", Date: date.AddDate(0, 0, -1), Value: decimal.New(113902, 5), Provider: "ecb"}
+		return BestData(l, &cringletest.BestRate{
+			Rate:  rates[0],
+			Days:  7,
+			Until: date,
+			Stats: cringletest.NewRateStats([]*cringletest.ExchangeRate{rates[0], earlier}),
+		})
+	case report.KindHistory:
+		series := cringletest.RateSeries{}
+		for i := 2; i >= 0; i-- {
+			day := date.AddDate(0, 0, -i)
+			series = append(series, &cringletest.DatedRates{Date: day, Rates: cringletest.RateMap{
+				"EUR": {From: "GBP", To: "EUR", Date: day, Value: decimal.New(114371-int64(i)*250, 5)},
+				"USD": {From: "GBP", To: "USD", Date: day, Value: decimal.New(133410+int64(i)*120, 5)},
+			}})
+		}
+		return HistoryData(l, &cringletest.RateHistory{
+			From:     "GBP",
+			To:       []string{"EUR", "USD"},
+			Start:    date.AddDate(0, 0, -2),
+			End:      date,
+			Interval: cringletest.IntervalDay,
+			Series:   series,
+		})
+	case report.KindAlert:
+		return AlertData(l, &cringletest.Alert{
+			Rule:    "gbp-eur-high",
+			Message: "GBP to EUR is 1.1437, above 1.14",
+			Rate:    rates[0],
+			Fired:   date.Add(9 * time.Hour),
+		})
+	}
+	return nil, fmt.Errorf("unknown notification kind %s", kind)
+}
+
+// Check renders every email template of s, and every console template which has been
+// overridden, against sample data. It returns an error, naming the template, for each one which
+// fails
+func (s *Set) Check() []error {
+	problems := []error{}
+	for _, kind := range Kinds {
+		data, err := sampleData(kind)
+		if err != nil {
+			problems = append(problems, err)
+			continue
+		}
+
+		if _, err := s.renderSubject(kind, data); err != nil {
+			problems = append(problems, err)
+		}
+		if _, err := s.renderHTML(kind, data); err != nil {
+			problems = append(problems, err)
+		}
+		if _, err := s.renderText(kind, PartText, data); err != nil {
+			problems = append(problems, err)
+		}
+		if _, _, err := s.Console(kind, data); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	return problems
+}
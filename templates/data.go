@@ -0,0 +1,342 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/locale"
+)
+
+// Data is what a template is rendered with. Every number and date in it is already formatted as
+// a string by the locale whose tag is the key locale, which is empty for the plain locale. The
+// other keys depend on the kind of notification:
+//
+//	rates, value: rates ([]*Rate), date, provider, and for value fees, which describes the fees
+//	              paid or is empty
+//	best:         kind (best or worst), window, from, to, rate, date, provider, warning,
+//	              hasStats and stats (*Stats)
+//	history:      from, start, end, interval, tables ([]*HistoryTable)
+//	alert:        rule, fired, message, rate (*Rate)
+//	need:         the same keys as value, with a rate for each currency which could be
+//	              converted, and target, the amount to be received
+//	matrix:       date, provider, currencies, basket (the currencies as one string), rows
+//	              ([]*MatrixRow) in the order of currencies, and warnings, those of any rate
+type Data map[string]interface{}
+
+// Rate is a single exchange rate. OriginalValue is the amount converted, which is 1 for rates,
+// and ConvertedValue what it buys at Value. Original and Converted are the same amounts along
+// with their currency symbols. When fees were paid Converted is the net amount received, Gross
+// what the amount would have bought at Value and Fees the difference, both with their symbols,
+// and EffectiveRate what each unit bought once the fees were paid. Otherwise they are empty
+type Rate struct {
+	From           string
+	To             string
+	OriginalValue  string
+	ConvertedValue string
+	Original       string
+	Converted      string
+	Gross          string
+	Fees           string
+	EffectiveRate  string
+	Value          string
+	Date           string
+	Provider       string
+	Warning        string
+}
+
+// HistoryRow is one rate of a history. Change and Percent are empty for the first row
+type HistoryRow struct {
+	Date    string
+	Rate    string
+	Change  string
+	Percent string
+	Warning string
+}
+
+// HistoryTable is the history of the rate to one currency
+type HistoryTable struct {
+	To   string
+	Rows []*HistoryRow
+}
+
+// MatrixRow holds the rates from one currency of a matrix to each of its currencies, in order
+type MatrixRow struct {
+	From  string
+	Rates []string
+}
+
+// Stats summarises the rates a best rate was chosen from. Gap is a percentage, including its sign
+type Stats struct {
+	Count  int
+	Min    string
+	Max    string
+	Mean   string
+	Median string
+	StdDev string
+	Gap    string
+}
+
+// DescribeWindow describes the period a best rate was chosen from
+func DescribeWindow(l *locale.Locale, best *cringletest.BestRate) string {
+	if best.Until.IsZero() {
+		return fmt.Sprintf("the last %d days", best.Days)
+	}
+	return fmt.Sprintf("the %d days to %s", best.Days, l.Date(best.Until))
+}
+
+func formatStats(l *locale.Locale, stats *cringletest.RateStats) *Stats {
+	if stats == nil {
+		return &Stats{}
+	}
+
+	return &Stats{
+		Count:  stats.Count,
+		Min:    l.Rate(stats.Min),
+		Max:    l.Rate(stats.Max),
+		Mean:   l.Rate(stats.Mean),
+		Median: l.Rate(stats.Median),
+		StdDev: l.Number(stats.StdDev, 6),
+		Gap:    l.Percent(l.Number(stats.Gap, 2)),
+	}
+}
+
+// newRate returns the Rate for rate with its amounts left for the caller to fill in
+func newRate(l *locale.Locale, rate *cringletest.ExchangeRate) *Rate {
+	return &Rate{
+		From:     rate.From,
+		To:       rate.To,
+		Date:     l.Date(rate.Date),
+		Provider: rate.Provider,
+		Warning:  rate.Warning,
+		Value:    l.Rate(rate.Value),
+	}
+}
+
+// formatRate formats what 1 buys at rate, keeping the decimal places of a rate
+func formatRate(l *locale.Locale, rate *cringletest.ExchangeRate) *Rate {
+	formatted := newRate(l, rate)
+	formatted.OriginalValue = l.Rate(decimal.New(1, 0))
+	formatted.ConvertedValue = formatted.Value
+	formatted.Original = l.Currency(formatted.OriginalValue, rate.From)
+	formatted.Converted = l.Currency(formatted.ConvertedValue, rate.To)
+	return formatted
+}
+
+// formatMoney formats m padded to the decimal places of its currency, but never drops the digits
+// it was rounded to
+func formatMoney(l *locale.Locale, m *cringletest.Money) string {
+	places := l.Places(m.Currency)
+	if scale := m.Amount.Scale(); scale > places {
+		places = scale
+	}
+	return l.Number(m.Amount, places)
+}
+
+// formatConversion formats an amount and what it was converted into as money, exactly as they
+// were rounded
+func formatConversion(l *locale.Locale, conversion *cringletest.Conversion) *Rate {
+	formatted := newRate(l, conversion.Rate)
+	formatted.OriginalValue = formatMoney(l, conversion.Amount)
+	formatted.ConvertedValue = formatMoney(l, conversion.Converted)
+	formatted.Original = l.Currency(formatted.OriginalValue, conversion.Amount.Currency)
+	formatted.Converted = l.Currency(formatted.ConvertedValue, conversion.Converted.Currency)
+	if charges := conversion.Charges; charges != nil {
+		formatted.Gross = l.Currency(formatMoney(l, charges.Gross), charges.Gross.Currency)
+		formatted.Fees = l.Currency(formatMoney(l, charges.Charged), charges.Charged.Currency)
+		formatted.EffectiveRate = l.Rate(charges.EffectiveRate)
+	}
+	return formatted
+}
+
+func formatHistory(l *locale.Locale, history *cringletest.RateHistory) []*HistoryTable {
+	tables := []*HistoryTable{}
+	for _, currency := range history.To {
+		table := &HistoryTable{To: currency}
+
+		var previous *cringletest.ExchangeRate
+		for _, rate := range history.Rates(currency) {
+			row := &HistoryRow{
+				Date:    l.Date(rate.Date),
+				Rate:    l.Rate(rate.Value),
+				Warning: rate.Warning,
+			}
+			if previous != nil {
+				change := new(decimal.Big).Sub(rate.Value, previous.Value)
+				row.Change = l.Signed(new(decimal.Big).Copy(change).Quantize(locale.RatePlaces), locale.RatePlaces)
+				if percent := cringletest.PercentChange(previous.Value, rate.Value); percent != nil {
+					row.Percent = l.Percent(l.Signed(percent, 2))
+				}
+			}
+			table.Rows = append(table.Rows, row)
+			previous = rate
+		}
+
+		if len(table.Rows) != 0 {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// orDefault returns l, or the default locale if l is nil
+func orDefault(l *locale.Locale) *locale.Locale {
+	if l == nil {
+		return locale.Default()
+	}
+	return l
+}
+
+func rateListData(l *locale.Locale, formatted []*Rate) (Data, error) {
+	if len(formatted) == 0 {
+		return nil, cringletest.ErrNoRates
+	}
+
+	return Data{
+		"locale":   l.String(),
+		"rates":    formatted,
+		"date":     formatted[0].Date,
+		"provider": formatted[0].Provider,
+	}, nil
+}
+
+// RatesData returns the Data for a rates query formatted for l, or the default locale if l is nil.
+// The other builders treat l the same way
+func RatesData(l *locale.Locale, rates []*cringletest.ExchangeRate) (Data, error) {
+	l = orDefault(l)
+	formatted := []*Rate{}
+	for _, rate := range rates {
+		formatted = append(formatted, formatRate(l, rate))
+	}
+	return rateListData(l, formatted)
+}
+
+// ValueData returns the Data for an amount converted at each of several rates
+func ValueData(l *locale.Locale, conversions []*cringletest.Conversion) (Data, error) {
+	l = orDefault(l)
+	formatted := []*Rate{}
+	for _, conversion := range conversions {
+		formatted = append(formatted, formatConversion(l, conversion))
+	}
+
+	data, err := rateListData(l, formatted)
+	if err != nil {
+		return nil, err
+	}
+	data["fees"] = ""
+	if charges := conversions[0].Charges; charges != nil {
+		data["fees"] = charges.Fees.String()
+	}
+	return data, nil
+}
+
+// NeedData returns the Data for the amounts of several currencies needed to receive a target
+// amount
+func NeedData(l *locale.Locale, requirements []*cringletest.Requirement) (Data, error) {
+	if len(requirements) == 0 {
+		return nil, cringletest.ErrNoRates
+	}
+
+	l = orDefault(l)
+	conversions := []*cringletest.Conversion{}
+	for _, requirement := range requirements {
+		conversions = append(conversions, requirement.Conversion)
+	}
+
+	data, err := ValueData(l, conversions)
+	if err != nil {
+		return nil, err
+	}
+	target := requirements[0].Target
+	data["target"] = l.Currency(formatMoney(l, target), target.Currency)
+	return data, nil
+}
+
+// BestData returns the Data for a best rate query
+func BestData(l *locale.Locale, best *cringletest.BestRate) (Data, error) {
+	l = orDefault(l)
+	rate := formatRate(l, best.Rate)
+
+	kind := "best"
+	if best.Worst {
+		kind = "worst"
+	}
+
+	return Data{
+		"locale":   l.String(),
+		"kind":     kind,
+		"window":   DescribeWindow(l, best),
+		"hasStats": best.Stats != nil,
+		"stats":    formatStats(l, best.Stats),
+		"from":     rate.From,
+		"to":       rate.To,
+		"rate":     rate.ConvertedValue,
+		"date":     rate.Date,
+		"provider": rate.Provider,
+		"warning":  rate.Warning,
+	}, nil
+}
+
+// HistoryData returns the Data for a history query
+func HistoryData(l *locale.Locale, history *cringletest.RateHistory) (Data, error) {
+	l = orDefault(l)
+	tables := formatHistory(l, history)
+	if len(tables) == 0 {
+		return nil, cringletest.ErrNoRates
+	}
+
+	return Data{
+		"locale":   l.String(),
+		"from":     history.From,
+		"start":    l.Date(history.Start),
+		"end":      l.Date(history.End),
+		"interval": history.Interval,
+		"tables":   tables,
+	}, nil
+}
+
+// AlertData returns the Data for an alert fired by a watch rule
+func AlertData(l *locale.Locale, alert *cringletest.Alert) (Data, error) {
+	l = orDefault(l)
+	return Data{
+		"locale":  l.String(),
+		"rule":    alert.Rule,
+		"fired":   l.DateTime(alert.Fired),
+		"message": alert.Message,
+		"rate":    formatRate(l, alert.Rate),
+	}, nil
+}
+
+// MatrixData returns the Data for the cross rates of a basket of currencies
+func MatrixData(l *locale.Locale, matrix *cringletest.RateMatrix) (Data, error) {
+	rates := matrix.CrossRates()
+	if len(rates) == 0 {
+		return nil, cringletest.ErrNoRates
+	}
+
+	l = orDefault(l)
+	rows, warnings := []*MatrixRow{}, []string{}
+	for _, from := range matrix.Currencies {
+		row := &MatrixRow{From: from}
+		for _, to := range matrix.Currencies {
+			row.Rates = append(row.Rates, l.Rate(matrix.Rate(from, to).Value))
+		}
+		rows = append(rows, row)
+	}
+	for _, rate := range rates {
+		if len(rate.Warning) != 0 {
+			warnings = append(warnings, fmt.Sprintf("%s to %s: %s", rate.From, rate.To, rate.Warning))
+		}
+	}
+
+	return Data{
+		"locale":     l.String(),
+		"date":       l.Date(rates[0].Date),
+		"provider":   rates[0].Provider,
+		"currencies": matrix.Currencies,
+		"basket":     strings.Join(matrix.Currencies, ", "),
+		"rows":       rows,
+		"warnings":   warnings,
+	}, nil
+}
@@ -0,0 +1,115 @@
+package templates
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/locale"
+	"github.com/robotlovesyou/cringletest/report"
+)
+
+// Render renders the email for a notification of kind with the default Set, formatted for l or
+// the default locale if l is nil. payload is what the notifier was given, such as the
+// []*cringletest.ExchangeRate of a rates notification
+func Render(kind string, l *locale.Locale, payload interface{}) (*Message, error) {
+	data, err := dataFor(kind, l, payload)
+	if err != nil {
+		return nil, err
+	}
+	return Default().Mail(kind, data)
+}
+
+// dataFor builds the data a notification of kind is rendered with from its payload
+func dataFor(kind string, l *locale.Locale, payload interface{}) (Data, error) {
+	switch p := payload.(type) {
+	case []*cringletest.ExchangeRate:
+		if kind == report.KindRates {
+			return RatesData(l, p)
+		}
+	case []*cringletest.Conversion:
+		if kind == report.KindValue {
+			return ValueData(l, p)
+		}
+	case *cringletest.BestRate:
+		if kind == report.KindBest {
+			return BestData(l, p)
+		}
+	case *cringletest.RateHistory:
+		if kind == report.KindHistory {
+			return HistoryData(l, p)
+		}
+	case *cringletest.Alert:
+		if kind == report.KindAlert {
+			return AlertData(l, p)
+		}
+	case []*cringletest.Requirement:
+		if kind == report.KindNeed {
+			return NeedData(l, p)
+		}
+	case *cringletest.RateMatrix:
+		if kind == report.KindMatrix {
+			return MatrixData(l, p)
+		}
+	}
+	return nil, fmt.Errorf("cannot render a %s notification from %T", kind, payload)
+}
+
+// Sender sends a rendered email
+type Sender interface {
+	SendMail(ctx context.Context, msg *Message) error
+}
+
+// Mailer is a cringletest.Notifier which renders each notification as an email and sends it with
+// its Sender, so that an email notifier only has to send mail
+type Mailer struct {
+	// Locale formats the email, or nil for locale.Default()
+	Locale *locale.Locale
+	sender Sender
+}
+
+// NewMailer returns a Mailer which sends its emails with sender
+func NewMailer(sender Sender) *Mailer {
+	return &Mailer{sender: sender}
+}
+
+// send renders the email for a notification of kind and sends it unless it could not be rendered
+func (m *Mailer) send(ctx context.Context, kind string, payload interface{}) error {
+	msg, err := Render(kind, m.Locale, payload)
+	if err == cringletest.ErrNoRates {
+		return err
+	}
+	if err != nil {
+		return errors.Wrapf(err, "could not notify %s", kind)
+	}
+	return m.sender.SendMail(ctx, msg)
+}
+
+func (m *Mailer) NotifyRates(ctx context.Context, rates []*cringletest.ExchangeRate) error {
+	return m.send(ctx, report.KindRates, rates)
+}
+
+func (m *Mailer) NotifyValue(ctx context.Context, conversions []*cringletest.Conversion) error {
+	return m.send(ctx, report.KindValue, conversions)
+}
+
+func (m *Mailer) NotifyBest(ctx context.Context, best *cringletest.BestRate) error {
+	return m.send(ctx, report.KindBest, best)
+}
+
+func (m *Mailer) NotifyHistory(ctx context.Context, history *cringletest.RateHistory) error {
+	return m.send(ctx, report.KindHistory, history)
+}
+
+func (m *Mailer) NotifyAlert(ctx context.Context, alert *cringletest.Alert) error {
+	return m.send(ctx, report.KindAlert, alert)
+}
+
+func (m *Mailer) NotifyNeed(ctx context.Context, requirements []*cringletest.Requirement) error {
+	return m.send(ctx, report.KindNeed, requirements)
+}
+
+func (m *Mailer) NotifyMatrix(ctx context.Context, matrix *cringletest.RateMatrix) error {
+	return m.send(ctx, report.KindMatrix, matrix)
+}
@@ -0,0 +1,255 @@
+// Package templates renders notifications with templates: the subject, html and plain text
+// bodies of the emails sent by the email notifiers and, optionally, the layout written by the
+// console notifier. Built in templates are used unless they are overridden by a template
+// directory
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/gobuffalo/plush"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/locale"
+	"github.com/robotlovesyou/cringletest/report"
+)
+
+// The parts of a notification which can be templated
+const (
+	PartSubject = "subject"
+	PartHTML    = "html"
+	PartText    = "text"
+	PartConsole = "console"
+)
+
+// Kinds lists the kinds of notification, each of which has its own templates
+var Kinds = []string{report.KindRates, report.KindValue, report.KindBest, report.KindHistory, report.KindAlert, report.KindNeed, report.KindMatrix}
+
+// Parts lists the parts of a notification which can be templated
+var Parts = []string{PartSubject, PartHTML, PartText, PartConsole}
+
+const templateExt = ".tmpl"
+
+// name returns the name of the template for part of kind, which is also its file name in a
+// template directory, e.g. rates.html.tmpl
+func name(kind, part string) string {
+	return kind + "." + part + templateExt
+}
+
+// Names returns the name of every template which can be overridden, in order
+func Names() []string {
+	names := []string{}
+	for _, kind := range Kinds {
+		for _, part := range Parts {
+			names = append(names, name(kind, part))
+		}
+	}
+	return names
+}
+
+// Message is a rendered email
+type Message struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Set is the built in templates along with any overrides
+type Set struct {
+	dir       string
+	overrides map[string]string
+}
+
+// Builtin returns a Set of only the built in templates
+func Builtin() *Set {
+	return &Set{overrides: map[string]string{}}
+}
+
+// Load returns a Set which overrides the built in templates with those in dir. Every file in
+// dir with the .tmpl extension must be named after a template, such as rates.subject.tmpl or
+// history.console.tmpl. Other files are ignored
+func Load(dir string) (*Set, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read template directory")
+	}
+
+	known := map[string]bool{}
+	for _, n := range Names() {
+		known[n] = true
+	}
+
+	s := &Set{dir: dir, overrides: map[string]string{}}
+	for _, file := range files {
+		n := file.Name()
+		if file.IsDir() || filepath.Ext(n) != templateExt {
+			continue
+		}
+		if !known[n] {
+			return nil, fmt.Errorf("unknown template %s in %s, expected names like %s", n, dir, name(report.KindRates, PartHTML))
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, n))
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read template %s", n)
+		}
+		s.overrides[n] = string(data)
+	}
+	return s, nil
+}
+
+// Dir returns the directory the overrides were loaded from, if any
+func (s *Set) Dir() string {
+	return s.dir
+}
+
+// Overridden returns the names of the templates which have been overridden, in order
+func (s *Set) Overridden() []string {
+	names := []string{}
+	for n := range s.overrides {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// source returns the template for part of kind and whether it is an override
+func (s *Set) source(kind, part string) (string, bool) {
+	n := name(kind, part)
+	if src, ok := s.overrides[n]; ok {
+		return src, true
+	}
+	return builtin[n], false
+}
+
+// renderText renders part of kind with text/template. A key which is not in data is an error so
+// that typos are caught
+func (s *Set) renderText(kind, part string, data Data) (string, error) {
+	src, _ := s.source(kind, part)
+	tmpl, err := template.New(name(kind, part)).Option("missingkey=error").Parse(src)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not parse %s", name(kind, part))
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := tmpl.Execute(out, map[string]interface{}(data)); err != nil {
+		return "", errors.Wrapf(err, "could not render %s", name(kind, part))
+	}
+	return out.String(), nil
+}
+
+// renderHTML renders the html part of kind with plush, which escapes everything it outputs
+func (s *Set) renderHTML(kind string, data Data) (string, error) {
+	src, _ := s.source(kind, PartHTML)
+	html, err := plush.Render(src, plush.NewContextWith(data))
+	if err != nil {
+		return "", errors.Wrapf(err, "could not render %s", name(kind, PartHTML))
+	}
+	return html, nil
+}
+
+// renderSubject renders the subject of kind, which must be a single line
+func (s *Set) renderSubject(kind string, data Data) (string, error) {
+	subject, err := s.renderText(kind, PartSubject, data)
+	if err != nil {
+		return "", err
+	}
+	// editors like to end files with a newline but a subject is a single line
+	subject = strings.TrimSpace(subject)
+	if strings.ContainsAny(subject, "\r\n") {
+		return "", fmt.Errorf("%s must render a single line", name(kind, PartSubject))
+	}
+	return subject, nil
+}
+
+// Mail renders the email for a notification of kind
+func (s *Set) Mail(kind string, data Data) (*Message, error) {
+	subject, err := s.renderSubject(kind, data)
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := s.renderHTML(kind, data)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := s.renderText(kind, PartText, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{Subject: subject, HTML: html, Text: text}, nil
+}
+
+// Console renders the console layout for a notification of kind. It reports false if the
+// layout has not been overridden, in which case the console notifier writes its own
+func (s *Set) Console(kind string, data Data) (string, bool, error) {
+	if _, ok := s.source(kind, PartConsole); !ok {
+		return "", false, nil
+	}
+	out, err := s.renderText(kind, PartConsole, data)
+	return out, true, err
+}
+
+var (
+	defaultMu  sync.RWMutex
+	defaultSet = Builtin()
+)
+
+// Default returns the Set the notifiers render with, which is the built in templates unless
+// SetDefault has been called
+func Default() *Set {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultSet
+}
+
+// SetDefault makes s the Set the notifiers render with
+func SetDefault(s *Set) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultSet = s
+}
+
+// Rates renders the email for a rates query formatted for l, or the default locale if l is nil
+func Rates(l *locale.Locale, rates []*cringletest.ExchangeRate) (*Message, error) {
+	return Render(report.KindRates, l, rates)
+}
+
+// Value renders the email for an amount converted at each of several rates
+func Value(l *locale.Locale, conversions []*cringletest.Conversion) (*Message, error) {
+	return Render(report.KindValue, l, conversions)
+}
+
+// Best renders the email for a best rate query
+func Best(l *locale.Locale, best *cringletest.BestRate) (*Message, error) {
+	return Render(report.KindBest, l, best)
+}
+
+// History renders the email for a history query
+func History(l *locale.Locale, history *cringletest.RateHistory) (*Message, error) {
+	return Render(report.KindHistory, l, history)
+}
+
+// Alert renders the email for an alert fired by a watch rule
+func Alert(l *locale.Locale, alert *cringletest.Alert) (*Message, error) {
+	return Render(report.KindAlert, l, alert)
+}
+
+// Need renders the email for the amounts of several currencies needed to receive a target amount
+func Need(l *locale.Locale, requirements []*cringletest.Requirement) (*Message, error) {
+	return Render(report.KindNeed, l, requirements)
+}
+
+// Matrix renders the email for the cross rates of a basket of currencies
+func Matrix(l *locale.Locale, matrix *cringletest.RateMatrix) (*Message, error) {
+	return Render(report.KindMatrix, l, matrix)
+}
@@ -0,0 +1,305 @@
+package templates
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/locale"
+	"github.com/robotlovesyou/cringletest/report"
+	"github.com/stretchr/testify/require"
+)
+
+var testDate = time.Date(2018, 5, 25, 0, 0, 0, 0, time.UTC)
+
+func convert(r *require.Assertions, amount *decimal.Big, rates ...*cringletest.ExchangeRate) []*cringletest.Conversion {
+	conversions, err := cringletest.Convert(cringletest.NewMoney(amount, rates[0].From), rates, cringletest.DefaultRounding)
+	r.NoError(err)
+	return conversions
+}
+
+func TestRatesRendersBothBodies(t *testing.T) {
+	r := require.New(t)
+
+	msg, err := Rates(nil, []*cringletest.ExchangeRate{
+		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: testDate, Value: decimal.New(1234, 3), Provider: "ecb"},
+	})
+	r.NoError(err)
+
+	r.Equal(ratesSubject, msg.Subject)
+	r.Contains(msg.HTML, "<td>1.0000 ABC</td><td>Will buy you</td><td>1.2340 DEF</td>")
+	r.Contains(msg.HTML, "<p>Rates provided by ecb</p>")
+
+	expected := "Hello,\n\n" +
+		"here are the rates you requested for Fri 25 May 2018\n\n" +
+		"1.0000 ABC will buy you 1.2340 DEF\n\n" +
+		"Rates provided by ecb\n"
+	r.Equal(expected, msg.Text)
+}
+
+func TestValueTextIsNotEscaped(t *testing.T) {
+	r := require.New(t)
+
+	msg, err := Value(nil, convert(r, decimal.New(2, 0),
+		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: testDate, Value: decimal.New(1234, 3), Warning: "providers <disagree>"},
+	))
+	r.NoError(err)
+
+	r.Equal(valuesSubject, msg.Subject)
+	r.Contains(msg.HTML, "providers &lt;disagree&gt;")
+	r.Contains(msg.Text, "2.0000 ABC will buy you 2.4700 DEF\n    Warning: providers <disagree>\n")
+}
+
+func TestValueIsFormattedForLocale(t *testing.T) {
+	r := require.New(t)
+
+	de, err := locale.Parse("de-DE")
+	r.NoError(err)
+
+	msg, err := Value(de, convert(r, decimal.New(12345, 1),
+		&cringletest.ExchangeRate{From: "GBP", To: "EUR", Date: testDate, Value: decimal.New(114371, 5)},
+		&cringletest.ExchangeRate{From: "GBP", To: "JPY", Date: testDate, Value: decimal.New(14987, 2)},
+	))
+	r.NoError(err)
+
+	r.Contains(msg.Text, "here are the currency conversions requested for Fr., 25. Mai 2018\n")
+	r.Contains(msg.Text, "1.234,50\u00a0£ will buy you 1.411,91\u00a0€\n")
+	r.Contains(msg.Text, "1.234,50\u00a0£ will buy you 185.015\u00a0¥\n")
+
+	// rates keep their decimal places
+	msg, err = Rates(de, []*cringletest.ExchangeRate{
+		&cringletest.ExchangeRate{From: "GBP", To: "JPY", Date: testDate, Value: decimal.New(14987, 2)},
+	})
+	r.NoError(err)
+	r.Contains(msg.Text, "1,0000\u00a0£ will buy you 149,8700\u00a0¥\n")
+}
+
+func TestRatesFailsWithNoRates(t *testing.T) {
+	r := require.New(t)
+
+	_, err := Rates(nil, nil)
+	r.Equal(cringletest.ErrNoRates, err)
+}
+
+func TestBestRendersStats(t *testing.T) {
+	r := require.New(t)
+
+	rates := []*cringletest.ExchangeRate{
+		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: testDate, Value: decimal.New(1, 0)},
+		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: testDate.AddDate(0, 0, -1), Value: decimal.New(2, 0)},
+	}
+	msg, err := Best(nil, &cringletest.BestRate{Rate: rates[0], Worst: true, Days: 2, Until: testDate, Stats: cringletest.NewRateStats(rates)})
+	r.NoError(err)
+
+	r.Equal("Your worst exchange rate", msg.Subject)
+	r.Contains(msg.HTML, "<tr><td>Mean</td><td>1.5000</td></tr>")
+	r.Contains(msg.Text, "The worst rate between ABC and DEF in the 2 days to Fri 25 May 2018 was 1.0000 on Fri 25 May 2018\n")
+	r.Contains(msg.Text, "Best/worst gap:     100.00%\n")
+}
+
+func TestHistoryRendersChanges(t *testing.T) {
+	r := require.New(t)
+
+	history := &cringletest.RateHistory{From: "ABC", To: []string{"DEF"}, Start: testDate, End: testDate.AddDate(0, 0, 1), Interval: cringletest.IntervalDay}
+	for i, value := range []int64{100, 110} {
+		date := testDate.AddDate(0, 0, i)
+		history.Series = append(history.Series, &cringletest.DatedRates{
+			Date:  date,
+			Rates: cringletest.RateMap{"DEF": &cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: date, Value: decimal.New(value, 2)}},
+		})
+	}
+
+	msg, err := History(nil, history)
+	r.NoError(err)
+
+	r.Equal(historySubject, msg.Subject)
+	r.Contains(msg.HTML, "<td>+0.1000</td><td>+10.00%</td>")
+	r.Contains(msg.Text, "ABC to DEF\n")
+	r.Contains(msg.Text, "Sat 26 May 2018        1.1000    +0.1000   +10.00%\n")
+}
+
+func TestHistoryFailsWithNoRates(t *testing.T) {
+	r := require.New(t)
+
+	_, err := History(nil, &cringletest.RateHistory{To: []string{"DEF"}})
+	r.Equal(cringletest.ErrNoRates, err)
+}
+
+func TestAlertSubjectNamesRule(t *testing.T) {
+	r := require.New(t)
+
+	fired := time.Date(2018, 5, 25, 9, 30, 0, 0, time.UTC)
+	msg, err := Alert(nil, &cringletest.Alert{
+		Rule:    "abc-def-high",
+		Message: "ABC to DEF is 1.1600, above 1.15",
+		Rate:    &cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: fired, Value: decimal.New(116, 2)},
+		Fired:   fired,
+	})
+	r.NoError(err)
+
+	r.Equal("Exchange rate alert: abc-def-high", msg.Subject)
+	r.Contains(msg.HTML, "matched at Fri 25 May 2018 09:30 UTC")
+	r.Contains(msg.Text, "ABC to DEF is 1.1600, above 1.15\n\n1.0000 ABC will buy you 1.1600 DEF\n")
+}
+
+func TestNeedNamesTarget(t *testing.T) {
+	r := require.New(t)
+
+	rates := []*cringletest.ExchangeRate{{From: "DEF", To: "ABC", Date: testDate, Value: decimal.New(2, 0)}}
+	requirements, err := cringletest.Need(cringletest.NewMoney(decimal.New(10, 0), "ABC"), rates, cringletest.DefaultRounding, nil)
+	r.NoError(err)
+
+	msg, err := Need(nil, requirements)
+	r.NoError(err)
+
+	r.Equal("What you need to receive 10.0000 ABC", msg.Subject)
+	r.Contains(msg.HTML, "<td>5.0000 DEF</td><td>Will buy you</td><td>10.0000 ABC</td>")
+	r.Contains(msg.Text, "to receive 10.0000 ABC on Fri 25 May 2018\n\n5.0000 DEF will buy you 10.0000 ABC\n")
+
+	_, err = Need(nil, nil)
+	r.EqualError(err, cringletest.ErrNoRates.Error())
+}
+
+func TestMatrixTabulatesCrossRates(t *testing.T) {
+	r := require.New(t)
+
+	matrix, err := cringletest.NewRateMatrix([]string{"ABC", "DEF", "GHI"}, cringletest.RateMap{
+		"DEF": {From: "ABC", To: "DEF", Date: testDate, Value: decimal.New(2, 0), Provider: "ecb"},
+		"GHI": {From: "ABC", To: "GHI", Date: testDate, Value: decimal.New(5, 0), Provider: "ecb", Warning: "stale"},
+	})
+	r.NoError(err)
+
+	msg, err := Matrix(nil, matrix)
+	r.NoError(err)
+
+	r.Equal("Your cross rates for ABC, DEF, GHI", msg.Subject)
+	r.Contains(msg.HTML, "<th>DEF</th><td>0.5000</td><td>1.0000</td><td>2.5000</td>")
+	r.Contains(msg.Text, "DEF        0.5000       1.0000       2.5000\n")
+	r.Contains(msg.Text, "Warning: ABC to GHI: stale")
+}
+
+// recordingSender is a Sender which keeps the messages it is asked to send
+type recordingSender struct {
+	sent []*Message
+}
+
+func (s *recordingSender) SendMail(ctx context.Context, msg *Message) error {
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func TestMailerRendersEachKind(t *testing.T) {
+	r := require.New(t)
+
+	sender := &recordingSender{}
+	var notifier cringletest.Notifier = NewMailer(sender)
+
+	rates := []*cringletest.ExchangeRate{&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: testDate, Value: decimal.New(2, 0)}}
+	r.NoError(notifier.NotifyRates(context.Background(), rates))
+	r.NoError(notifier.NotifyBest(context.Background(), &cringletest.BestRate{Rate: rates[0], Days: 1, Until: testDate}))
+	r.Equal(cringletest.ErrNoRates, notifier.NotifyValue(context.Background(), nil))
+
+	r.Len(sender.sent, 2)
+	r.Equal(ratesSubject, sender.sent[0].Subject)
+	r.Equal("Your best exchange rate", sender.sent[1].Subject)
+}
+
+func TestRenderRejectsPayloadOfAnotherKind(t *testing.T) {
+	r := require.New(t)
+
+	_, err := Render(report.KindBest, nil, []*cringletest.ExchangeRate{})
+	r.EqualError(err, "cannot render a best notification from []*cringletest.ExchangeRate")
+}
+
+// writeTemplates writes files to a new template directory and returns it along with a function
+// which removes it
+func writeTemplates(r *require.Assertions, files map[string]string) (string, func()) {
+	dir, err := ioutil.TempDir("", "templates")
+	r.NoError(err)
+	for name, contents := range files {
+		r.NoError(ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644))
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func TestLoadOverridesBuiltins(t *testing.T) {
+	r := require.New(t)
+
+	dir, cleanup := writeTemplates(r, map[string]string{
+		"rates.subject.tmpl": "Rates for {{.date}}\n",
+		"rates.text.tmpl":    "{{range .rates}}{{.From}}/{{.To}} {{.Value}}\n{{end}}",
+		"rates.console.tmpl": "{{len .rates}} rates\n",
+		"README.md":          "not a template",
+	})
+	defer cleanup()
+
+	set, err := Load(dir)
+	r.NoError(err)
+	r.Equal(dir, set.Dir())
+	r.Equal([]string{"rates.console.tmpl", "rates.subject.tmpl", "rates.text.tmpl"}, set.Overridden())
+
+	data, err := RatesData(nil, []*cringletest.ExchangeRate{
+		&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: testDate, Value: decimal.New(1234, 3), Provider: "ecb"},
+	})
+	r.NoError(err)
+
+	msg, err := set.Mail(report.KindRates, data)
+	r.NoError(err)
+	r.Equal("Rates for Fri 25 May 2018", msg.Subject)
+	r.Equal("ABC/DEF 1.2340\n", msg.Text)
+	// the html is still the built in template
+	r.Contains(msg.HTML, "<p>Rates provided by ecb</p>")
+
+	out, ok, err := set.Console(report.KindRates, data)
+	r.NoError(err)
+	r.True(ok)
+	r.Equal("1 rates\n", out)
+
+	_, ok, err = Builtin().Console(report.KindRates, data)
+	r.NoError(err)
+	r.False(ok)
+}
+
+func TestLoadRejectsUnknownTemplates(t *testing.T) {
+	r := require.New(t)
+
+	dir, cleanup := writeTemplates(r, map[string]string{"rate.html.tmpl": "<p></p>"})
+	defer cleanup()
+
+	_, err := Load(dir)
+	r.Error(err)
+	r.Contains(err.Error(), "unknown template rate.html.tmpl")
+}
+
+func TestCheckFindsBrokenTemplates(t *testing.T) {
+	r := require.New(t)
+
+	r.Empty(Builtin().Check())
+
+	dir, cleanup := writeTemplates(r, map[string]string{
+		"value.text.tmpl":      "{{.rate}}",
+		"best.html.tmpl":       "<%= if (kind { %>",
+		"alert.subject.tmpl":   "{{.rule}}\n{{.fired}}",
+		"history.console.tmpl": "{{range .tables}}{{.To}}{{end}",
+		"rates.console.tmpl":   "{{.date}}",
+	})
+	defer cleanup()
+
+	set, err := Load(dir)
+	r.NoError(err)
+
+	problems := []string{}
+	for _, problem := range set.Check() {
+		problems = append(problems, problem.Error())
+	}
+	r.Len(problems, 4)
+	r.Contains(problems[0], "could not render value.text.tmpl")
+	r.Contains(problems[1], "could not render best.html.tmpl")
+	r.Contains(problems[2], "could not parse history.console.tmpl")
+	r.Contains(problems[3], "alert.subject.tmpl must render a single line")
+}
@@ -4,7 +4,6 @@ package testnotifier
 import (
 	"context"
 
-	"github.com/ericlagergren/decimal"
 	"github.com/robotlovesyou/cringletest"
 )
 
@@ -21,10 +20,26 @@ func (n *notifier) NotifyRates(ctx context.Context, rates []*cringletest.Exchang
 	return n.err
 }
 
-func (n *notifier) NotifyValue(ctx context.Context, value *decimal.Big, rates []*cringletest.ExchangeRate) error {
+func (n *notifier) NotifyValue(ctx context.Context, conversions []*cringletest.Conversion) error {
 	return n.err
 }
 
-func (n *notifier) NotifyBest(ctx context.Context, rate *cringletest.ExchangeRate) error {
+func (n *notifier) NotifyBest(ctx context.Context, best *cringletest.BestRate) error {
+	return n.err
+}
+
+func (n *notifier) NotifyHistory(ctx context.Context, history *cringletest.RateHistory) error {
+	return n.err
+}
+
+func (n *notifier) NotifyAlert(ctx context.Context, alert *cringletest.Alert) error {
+	return n.err
+}
+
+func (n *notifier) NotifyNeed(ctx context.Context, requirements []*cringletest.Requirement) error {
+	return n.err
+}
+
+func (n *notifier) NotifyMatrix(ctx context.Context, matrix *cringletest.RateMatrix) error {
 	return n.err
 }
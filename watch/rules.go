@@ -0,0 +1,242 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+)
+
+// Number is a decimal which may be written in a rules file as either a JSON number or a string
+type Number struct {
+	decimal.Big
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (n *Number) UnmarshalJSON(data []byte) error {
+	s := string(bytes.Trim(data, `"`))
+	if _, ok := n.SetString(s); !ok {
+		return fmt.Errorf("%s cannot be formatted as a number", s)
+	}
+	return nil
+}
+
+// Duration is a time.Duration written in a rules file as a string such as "6h" or "30m"
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.New("durations must be strings such as \"6h\" or \"30m\"")
+	}
+
+	var err error
+	d.Duration, err = time.ParseDuration(s)
+	return err
+}
+
+// Rule describes when an alert should fire for the rate from From to To. Exactly one of Above,
+// Below, ChangePercent, HighDays or LowDays must be set:
+//
+// Above and Below fire when the rate is above or below a threshold.
+// ChangePercent fires when the rate has moved by more than that percentage, in either
+// direction, since yesterday.
+// HighDays and LowDays fire when the rate is higher or lower than on any of that many
+// previous days.
+//
+// Once a rule has fired it will not fire again until Cooldown has passed
+type Rule struct {
+	Name          string    `json:"name"`
+	From          string    `json:"from"`
+	To            string    `json:"to"`
+	Above         *Number   `json:"above,omitempty"`
+	Below         *Number   `json:"below,omitempty"`
+	ChangePercent *Number   `json:"change_percent,omitempty"`
+	HighDays      int       `json:"high_days,omitempty"`
+	LowDays       int       `json:"low_days,omitempty"`
+	Cooldown      *Duration `json:"cooldown,omitempty"`
+
+	condition condition
+}
+
+// rulesFile is the layout of a rules file
+type rulesFile struct {
+	Rules []*Rule `json:"rules"`
+}
+
+// LoadRules reads and validates the rules in the JSON file at path
+func LoadRules(path string) ([]*Rule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read rules")
+	}
+
+	return ParseRules(data)
+}
+
+// ParseRules parses and validates JSON rules
+func ParseRules(data []byte) ([]*Rule, error) {
+	file := &rulesFile{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(file); err != nil {
+		return nil, errors.Wrap(err, "could not parse rules")
+	}
+
+	if len(file.Rules) == 0 {
+		return nil, errors.New("no rules to watch")
+	}
+
+	names := map[string]bool{}
+	for i, rule := range file.Rules {
+		if err := rule.validate(); err != nil {
+			return nil, errors.Wrapf(err, "rule %d", i+1)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("rule %d: there is already a rule named %s", i+1, rule.Name)
+		}
+		names[rule.Name] = true
+	}
+	return file.Rules, nil
+}
+
+func (r *Rule) validate() error {
+	if len(r.Name) == 0 {
+		return errors.New("no name")
+	}
+
+	r.From = strings.ToUpper(r.From)
+	r.To = strings.ToUpper(r.To)
+	if len(r.From) == 0 || len(r.To) == 0 {
+		return fmt.Errorf("%s needs both from and to currencies", r.Name)
+	}
+
+	conditions := []condition{}
+	if r.Above != nil {
+		conditions = append(conditions, &threshold{value: &r.Above.Big, above: true})
+	}
+	if r.Below != nil {
+		conditions = append(conditions, &threshold{value: &r.Below.Big})
+	}
+	if r.ChangePercent != nil {
+		conditions = append(conditions, &change{percent: &r.ChangePercent.Big})
+	}
+	if r.HighDays != 0 {
+		conditions = append(conditions, &extreme{days: r.HighDays, high: true})
+	}
+	if r.LowDays != 0 {
+		conditions = append(conditions, &extreme{days: r.LowDays})
+	}
+	if len(conditions) != 1 {
+		return fmt.Errorf("%s needs exactly one of above, below, change_percent, high_days or low_days", r.Name)
+	}
+
+	if r.HighDays < 0 || r.LowDays < 0 {
+		return fmt.Errorf("%s must look back at least 1 day", r.Name)
+	}
+	if r.Cooldown != nil && r.Cooldown.Duration < 0 {
+		return fmt.Errorf("%s has a negative cooldown", r.Name)
+	}
+
+	r.condition = conditions[0]
+	return nil
+}
+
+// cooldown returns how long the rule must wait after firing before it can fire again
+func (r *Rule) cooldown() time.Duration {
+	if r.Cooldown == nil {
+		return cringletest.WatchCooldown
+	}
+	return r.Cooldown.Duration
+}
+
+// condition decides whether a rule matches the current rate, which applies to day. It returns
+// a message describing the match if it does
+type condition interface {
+	match(ctx context.Context, client cringletest.RangeRateClient, day time.Time, rate *cringletest.ExchangeRate) (bool, string, error)
+}
+
+type threshold struct {
+	value *decimal.Big
+	above bool
+}
+
+func (t *threshold) match(ctx context.Context, client cringletest.RangeRateClient, day time.Time, rate *cringletest.ExchangeRate) (bool, string, error) {
+	cmp := rate.Value.Cmp(t.value)
+	if t.above && cmp > 0 {
+		return true, fmt.Sprintf("%s to %s is %.4f, above %f", rate.From, rate.To, rate.Value, t.value), nil
+	}
+	if !t.above && cmp < 0 {
+		return true, fmt.Sprintf("%s to %s is %.4f, below %f", rate.From, rate.To, rate.Value, t.value), nil
+	}
+	return false, "", nil
+}
+
+type change struct {
+	percent *decimal.Big
+}
+
+func (c *change) match(ctx context.Context, client cringletest.RangeRateClient, day time.Time, rate *cringletest.ExchangeRate) (bool, string, error) {
+	rates, err := client.GetOn(ctx, day.AddDate(0, 0, -1), rate.From, rate.To)
+	if err != nil {
+		return false, "", errors.Wrap(err, "could not get yesterday's rate")
+	}
+
+	yesterday, ok := rates[rate.To]
+	if !ok || yesterday.Value.Sign() == 0 {
+		return false, "", fmt.Errorf("no rate from %s to %s yesterday", rate.From, rate.To)
+	}
+
+	moved := cringletest.PercentChange(yesterday.Value, rate.Value)
+	if new(decimal.Big).Abs(moved).Cmp(c.percent) <= 0 {
+		return false, "", nil
+	}
+
+	return true, fmt.Sprintf("%s to %s moved %+.2f%% since yesterday, from %.4f to %.4f",
+		rate.From, rate.To, moved, yesterday.Value, rate.Value), nil
+}
+
+type extreme struct {
+	days int
+	high bool
+}
+
+func (e *extreme) match(ctx context.Context, client cringletest.RangeRateClient, day time.Time, rate *cringletest.ExchangeRate) (bool, string, error) {
+	series, err := client.GetRange(ctx, day.AddDate(0, 0, -e.days), day.AddDate(0, 0, -1), rate.From, rate.To)
+	if err != nil {
+		return false, "", errors.Wrapf(err, "could not get the last %d days of rates", e.days)
+	}
+
+	var previous *decimal.Big
+	for _, day := range series {
+		past, ok := day.Rates[rate.To]
+		if !ok {
+			continue
+		}
+		if previous == nil || (e.high && past.Value.Cmp(previous) > 0) || (!e.high && past.Value.Cmp(previous) < 0) {
+			previous = past.Value
+		}
+	}
+	if previous == nil {
+		return false, "", fmt.Errorf("no rates from %s to %s in the last %d days", rate.From, rate.To, e.days)
+	}
+
+	cmp := rate.Value.Cmp(previous)
+	if e.high && cmp > 0 {
+		return true, fmt.Sprintf("%s to %s is %.4f, a new %d day high above %.4f", rate.From, rate.To, rate.Value, e.days, previous), nil
+	}
+	if !e.high && cmp < 0 {
+		return true, fmt.Sprintf("%s to %s is %.4f, a new %d day low below %.4f", rate.From, rate.To, rate.Value, e.days, previous), nil
+	}
+	return false, "", nil
+}
@@ -0,0 +1,81 @@
+package watch
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// State records when each rule last fired so that cooldowns survive a restart
+type State struct {
+	Rules map[string]*RuleState `json:"rules"`
+}
+
+// RuleState is the state of a single rule
+type RuleState struct {
+	LastFired   time.Time `json:"last_fired"`
+	LastMessage string    `json:"last_message"`
+}
+
+// LoadState reads the state saved at path. A missing file is an empty state
+func LoadState(path string) (*State, error) {
+	state := &State{Rules: map[string]*RuleState{}}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read watch state")
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, errors.Wrap(err, "could not parse watch state")
+	}
+	if state.Rules == nil {
+		state.Rules = map[string]*RuleState{}
+	}
+	return state, nil
+}
+
+// Save writes the state to path. It is written to a temporary file first and then renamed so
+// that a crash part way through cannot leave a truncated state behind
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrap(err, "could not create watch state directory")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".watch-state")
+	if err != nil {
+		return errors.Wrap(err, "could not save watch state")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "could not save watch state")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "could not save watch state")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), path), "could not save watch state")
+}
+
+// cooling reports whether the rule named name fired less than cooldown before now
+func (s *State) cooling(name string, cooldown time.Duration, now time.Time) bool {
+	rule, ok := s.Rules[name]
+	return ok && now.Sub(rule.LastFired) < cooldown
+}
+
+func (s *State) fired(name, message string, now time.Time) {
+	s.Rules[name] = &RuleState{LastFired: now, LastMessage: message}
+}
@@ -0,0 +1,146 @@
+// Package watch polls a cringletest.RateClient and fires alerts when the rates match a set of rules
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/rangeclient"
+)
+
+// NotifyFunc sends an alert. An alert which fails to send is tried again on the next poll
+type NotifyFunc func(context.Context, *cringletest.Alert) error
+
+// Watcher checks a set of rules against the current rates
+type Watcher struct {
+	client    cringletest.RangeRateClient
+	rules     []*Rule
+	statePath string
+	state     *State
+	notify    NotifyFunc
+
+	// set the log and clock as members of the watcher so that they can be
+	// modified during testing
+	log io.Writer
+	now func() time.Time
+}
+
+// New returns a Watcher which checks rules against the rates from client, sends alerts with
+// notify and keeps its state in the file at statePath
+func New(client cringletest.RateClient, rules []*Rule, statePath string, notify NotifyFunc) (*Watcher, error) {
+	state, err := LoadState(statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Watcher{
+		client:    rangeclient.New(client, cringletest.RangeConcurrency),
+		rules:     rules,
+		statePath: statePath,
+		state:     state,
+		notify:    notify,
+		log:       os.Stderr,
+		now:       time.Now,
+	}, nil
+}
+
+func (w *Watcher) logf(format string, args ...interface{}) {
+	fmt.Fprintf(w.log, "%s %s\n", w.now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
+
+// rateDay returns the day rate applies to. Providers which only publish on working days return
+// the last working day's rate as the live rate, so comparisons with earlier days must start there
+func rateDay(rate *cringletest.ExchangeRate, now time.Time) time.Time {
+	if rate.Date.IsZero() {
+		return rangeclient.Day(now)
+	}
+	return rangeclient.Day(rate.Date)
+}
+
+// liveRates gets the current rates for every rule, asking once for each from currency
+func (w *Watcher) liveRates(ctx context.Context) map[string]cringletest.RateMap {
+	wanted := map[string][]string{}
+	for _, rule := range w.rules {
+		wanted[rule.From] = append(wanted[rule.From], rule.To)
+	}
+
+	live := map[string]cringletest.RateMap{}
+	for from, to := range wanted {
+		rates, err := w.client.Get(ctx, from, to...)
+		if err != nil {
+			w.logf("could not get rates from %s: %v", from, err)
+			continue
+		}
+		live[from] = rates
+	}
+	return live
+}
+
+// Check checks every rule once and sends an alert for each rule which matches and is not
+// cooling down. It returns the alerts which were sent
+func (w *Watcher) Check(ctx context.Context) ([]*cringletest.Alert, error) {
+	live := w.liveRates(ctx)
+	now := w.now()
+
+	sent := []*cringletest.Alert{}
+	for _, rule := range w.rules {
+		if w.state.cooling(rule.Name, rule.cooldown(), now) {
+			continue
+		}
+
+		rate, ok := live[rule.From][rule.To]
+		if !ok {
+			if _, fetched := live[rule.From]; fetched {
+				w.logf("rule %s: no rate from %s to %s", rule.Name, rule.From, rule.To)
+			}
+			continue
+		}
+
+		matched, message, err := rule.condition.match(ctx, w.client, rateDay(rate, now), rate)
+		if err != nil {
+			w.logf("rule %s: %v", rule.Name, err)
+			continue
+		}
+		if !matched {
+			continue
+		}
+
+		alert := &cringletest.Alert{Rule: rule.Name, Message: message, Rate: rate, Fired: now}
+		if err := w.notify(ctx, alert); err != nil {
+			w.logf("rule %s: could not send alert: %v", rule.Name, err)
+			continue
+		}
+
+		w.logf("rule %s: %s", rule.Name, message)
+		w.state.fired(rule.Name, message, now)
+		sent = append(sent, alert)
+	}
+
+	if len(sent) == 0 {
+		return sent, nil
+	}
+	return sent, errors.Wrap(w.state.Save(w.statePath), "alerts were sent but their cooldowns may not survive a restart")
+}
+
+// Run checks the rules immediately and then every interval until ctx is done
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.Check(ctx); err != nil {
+			w.logf("%v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package watch
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2018, 5, 25, 12, 0, 0, 0, time.UTC)
+
+// dailyClient is a cringletest.RateClient which returns the rate set for each day
+type dailyClient struct {
+	rates map[string]*decimal.Big
+}
+
+func (c *dailyClient) rate(date time.Time, from string, to ...string) (cringletest.RateMap, error) {
+	rates := cringletest.RateMap{}
+	value, ok := c.rates[date.Format("2006-01-02")]
+	if !ok {
+		return rates, nil
+	}
+	for _, currency := range to {
+		rates[currency] = &cringletest.ExchangeRate{From: from, To: currency, Date: date, Value: value}
+	}
+	return rates, nil
+}
+
+func (c *dailyClient) Get(ctx context.Context, from string, to ...string) (cringletest.RateMap, error) {
+	return c.rate(time.Date(2018, 5, 25, 0, 0, 0, 0, time.UTC), from, to...)
+}
+
+func (c *dailyClient) GetOn(ctx context.Context, date time.Time, from string, to ...string) (cringletest.RateMap, error) {
+	return c.rate(date, from, to...)
+}
+
+func newDailyClient(values ...int64) *dailyClient {
+	client := &dailyClient{rates: map[string]*decimal.Big{}}
+	// the last value is today's rate and the ones before it are the days before
+	for i, value := range values {
+		date := testNow.AddDate(0, 0, i+1-len(values))
+		client.rates[date.Format("2006-01-02")] = decimal.New(value, 2)
+	}
+	return client
+}
+
+type testWatcher struct {
+	*Watcher
+	sent []*cringletest.Alert
+	err  error
+}
+
+func getTestWatcher(t *testing.T, client cringletest.RateClient, rules string) (*testWatcher, string, func()) {
+	dir, err := ioutil.TempDir("", "watch")
+	require.NoError(t, err)
+	statePath := filepath.Join(dir, "state.json")
+
+	return newTestWatcher(t, client, rules, statePath), statePath, func() { os.RemoveAll(dir) }
+}
+
+func newTestWatcher(t *testing.T, client cringletest.RateClient, rules, statePath string) *testWatcher {
+	parsed, err := ParseRules([]byte(rules))
+	require.NoError(t, err)
+
+	tw := &testWatcher{}
+	tw.Watcher, err = New(client, parsed, statePath, func(ctx context.Context, alert *cringletest.Alert) error {
+		if tw.err != nil {
+			return tw.err
+		}
+		tw.sent = append(tw.sent, alert)
+		return nil
+	})
+	require.NoError(t, err)
+
+	tw.log = ioutil.Discard
+	tw.now = func() time.Time { return testNow }
+	return tw
+}
+
+func TestParseRulesRejectsBadRules(t *testing.T) {
+	for _, rules := range []string{
+		`{"rules": []}`,
+		`{"rules": [{"from": "GBP", "to": "EUR", "above": 1.15}]}`,
+		`{"rules": [{"name": "a", "from": "GBP", "above": 1.15}]}`,
+		`{"rules": [{"name": "a", "from": "GBP", "to": "EUR"}]}`,
+		`{"rules": [{"name": "a", "from": "GBP", "to": "EUR", "above": 1.15, "below": 1.1}]}`,
+		`{"rules": [{"name": "a", "from": "GBP", "to": "EUR", "above": "lots"}]}`,
+		`{"rules": [{"name": "a", "from": "GBP", "to": "EUR", "high_days": -3}]}`,
+		`{"rules": [{"name": "a", "from": "GBP", "to": "EUR", "above": 1.15, "cooldown": "soon"}]}`,
+		`{"rules": [{"name": "a", "from": "GBP", "to": "EUR", "above": 1.15, "colldown": "1h"}]}`,
+		`{"rules": [{"name": "a", "from": "GBP", "to": "EUR", "above": 1.15}, {"name": "a", "from": "GBP", "to": "EUR", "below": 1.1}]}`,
+	} {
+		_, err := ParseRules([]byte(rules))
+		require.Error(t, err, rules)
+	}
+}
+
+func TestThresholdRuleFiresAndCoolsDown(t *testing.T) {
+	r := require.New(t)
+	rules := `{"rules": [
+		{"name": "gbp-eur-high", "from": "gbp", "to": "eur", "above": "1.15", "cooldown": "6h"},
+		{"name": "gbp-eur-low", "from": "GBP", "to": "EUR", "below": 1.10}
+	]}`
+	w, statePath, done := getTestWatcher(t, newDailyClient(116), rules)
+	defer done()
+
+	alerts, err := w.Check(context.Background())
+	r.NoError(err)
+	r.Len(alerts, 1)
+	r.Equal("gbp-eur-high", alerts[0].Rule)
+	r.Equal("GBP to EUR is 1.1600, above 1.15", alerts[0].Message)
+	r.Equal(testNow, alerts[0].Fired)
+
+	// the cooldown survives a restart
+	w = newTestWatcher(t, newDailyClient(116), rules, statePath)
+	w.now = func() time.Time { return testNow.Add(5 * time.Hour) }
+	alerts, err = w.Check(context.Background())
+	r.NoError(err)
+	r.Len(alerts, 0)
+
+	w.now = func() time.Time { return testNow.Add(7 * time.Hour) }
+	alerts, err = w.Check(context.Background())
+	r.NoError(err)
+	r.Len(alerts, 1)
+}
+
+func TestChangeRuleComparesWithYesterday(t *testing.T) {
+	r := require.New(t)
+	rules := `{"rules": [{"name": "move", "from": "USD", "to": "JPY", "change_percent": 1}]}`
+
+	w, _, done := getTestWatcher(t, newDailyClient(100, 101), rules)
+	defer done()
+	alerts, err := w.Check(context.Background())
+	r.NoError(err)
+	r.Len(alerts, 0)
+
+	w, _, done = getTestWatcher(t, newDailyClient(100, 98), rules)
+	defer done()
+	alerts, err = w.Check(context.Background())
+	r.NoError(err)
+	r.Len(alerts, 1)
+	r.Equal("USD to JPY moved -2.00% since yesterday, from 1.0000 to 0.9800", alerts[0].Message)
+}
+
+func TestExtremeRulesLookBackOverDays(t *testing.T) {
+	r := require.New(t)
+	rules := `{"rules": [
+		{"name": "high", "from": "EUR", "to": "CAD", "high_days": 3},
+		{"name": "low", "from": "EUR", "to": "CAD", "low_days": 3}
+	]}`
+
+	// a higher rate four days ago is outside the window
+	w, _, done := getTestWatcher(t, newDailyClient(200, 120, 130, 125, 140), rules)
+	defer done()
+	alerts, err := w.Check(context.Background())
+	r.NoError(err)
+	r.Len(alerts, 1)
+	r.Equal("high", alerts[0].Rule)
+	r.Equal("EUR to CAD is 1.4000, a new 3 day high above 1.3000", alerts[0].Message)
+
+	w, _, done = getTestWatcher(t, newDailyClient(150, 120, 130, 110), rules)
+	defer done()
+	alerts, err = w.Check(context.Background())
+	r.NoError(err)
+	r.Len(alerts, 1)
+	r.Equal("low", alerts[0].Rule)
+}
+
+func TestFailedAlertsAreRetried(t *testing.T) {
+	r := require.New(t)
+	rules := `{"rules": [{"name": "high", "from": "GBP", "to": "EUR", "above": 1}]}`
+	w, statePath, done := getTestWatcher(t, newDailyClient(116), rules)
+	defer done()
+
+	w.err = errors.New("mail is down")
+	alerts, err := w.Check(context.Background())
+	r.NoError(err)
+	r.Len(alerts, 0)
+	_, err = os.Stat(statePath)
+	r.True(os.IsNotExist(err))
+
+	w.err = nil
+	alerts, err = w.Check(context.Background())
+	r.NoError(err)
+	r.Len(alerts, 1)
+
+	state, err := LoadState(statePath)
+	r.NoError(err)
+	r.Equal(testNow, state.Rules["high"].LastFired.UTC())
+}
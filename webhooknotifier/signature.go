@@ -0,0 +1,70 @@
+package webhooknotifier
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The headers added to every webhook request
+const (
+	// TimestampHeader holds the unix time the request was signed at
+	TimestampHeader = "X-Cconv-Timestamp"
+	// SignatureHeader holds "sha256=" followed by the hex HMAC-SHA256 of the timestamp, a full
+	// stop and the body, keyed with the shared secret
+	SignatureHeader = "X-Cconv-Signature"
+	// DeliveryHeader holds an id which is the same for every attempt to deliver the same body, so
+	// that receivers can ignore retries they have already handled
+	DeliveryHeader = "X-Cconv-Delivery"
+)
+
+const signaturePrefix = "sha256="
+
+var (
+	// ErrBadSignature is returned by Verify when the signature does not match the body
+	ErrBadSignature = errors.New("webhook signature does not match")
+	// ErrStaleTimestamp is returned by Verify when the request was signed too long ago
+	ErrStaleTimestamp = errors.New("webhook timestamp is too old")
+)
+
+func mac(secret, timestamp string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// Sign returns the value of the SignatureHeader for body signed at timestamp
+func Sign(secret, timestamp string, body []byte) string {
+	return signaturePrefix + hex.EncodeToString(mac(secret, timestamp, body))
+}
+
+// Verify checks the signature of a webhook request and that it was signed no more than
+// tolerance before now. Receivers should use it to reject requests which were not sent by
+// cconv or which are being replayed
+func Verify(secret, timestamp, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "bad webhook timestamp")
+	}
+
+	age := now.Sub(time.Unix(unix, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrStaleTimestamp
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrBadSignature
+	}
+	sent, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil || !hmac.Equal(sent, mac(secret, timestamp, body)) {
+		return ErrBadSignature
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+// Package webhooknotifier implements the cringletest.Notifier interface by POSTing a signed
+// report.Document to one or more URLs
+package webhooknotifier
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/envy"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/report"
+	"gopkg.in/resty.v1"
+)
+
+type notifier struct {
+	urls           []*url.URL
+	secret         string
+	attempts       int
+	backoff        time.Duration
+	attemptTimeout time.Duration
+}
+
+// New returns a new cringletest.Notifier which will POST to each of urls, signing the body with
+// the secret in CCONV_WEBHOOK_SECRET
+func New(urls []string) (cringletest.Notifier, error) {
+	secret, err := envy.MustGet(cringletest.WebhookSecretEnvVar)
+	if err != nil {
+		return nil, errors.New("no webhook secret configured")
+	}
+	return NewWithSecret(urls, secret)
+}
+
+// NewWithSecret returns a new cringletest.Notifier which will POST to each of urls, signing the
+// body with secret
+func NewWithSecret(urls []string, secret string) (cringletest.Notifier, error) {
+	if len(urls) == 0 {
+		return nil, cringletest.ErrNoRecipient
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("no webhook secret configured")
+	}
+
+	n := &notifier{
+		secret:         secret,
+		attempts:       cringletest.WebhookAttempts,
+		backoff:        cringletest.WebhookBackoff,
+		attemptTimeout: cringletest.WebhookAttemptTimeout,
+	}
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "bad webhook url %s", raw)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return nil, fmt.Errorf("webhook url %s must be an absolute http or https url", u.Redacted())
+		}
+		n.urls = append(n.urls, u)
+	}
+	return n, nil
+}
+
+func init() {
+	// webhook+https://example.com/hook posts to https://example.com/hook
+	factory := func(u *url.URL) (cringletest.Notifier, error) {
+		target := *u
+		target.Scheme = strings.TrimPrefix(u.Scheme, "webhook+")
+		return New([]string{target.String()})
+	}
+	cringletest.RegisterNotifier("webhook+http", factory)
+	cringletest.RegisterNotifier("webhook+https", factory)
+}
+
+// deliveryID returns a random id for a delivery
+func deliveryID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// attempt makes a single attempt to POST body to u. It reports whether a failure is worth
+// trying again
+func (n *notifier) attempt(ctx context.Context, u *url.URL, delivery string, body []byte) (bool, error) {
+	cx, cancel := context.WithTimeout(ctx, n.attemptTimeout)
+	defer cancel()
+
+	// sign every attempt afresh so that a retry is not rejected as stale
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	resp, err := resty.R().
+		SetContext(cx).
+		SetHeader("Content-Type", "application/json").
+		SetHeader(TimestampHeader, timestamp).
+		SetHeader(SignatureHeader, Sign(n.secret, timestamp, body)).
+		SetHeader(DeliveryHeader, delivery).
+		SetBody(body).
+		Post(u.String())
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, errors.Wrap(ctx.Err(), "could not post")
+		}
+		// the attempt timed out or could not connect
+		return true, errors.Wrap(err, "could not post")
+	}
+
+	code := resp.StatusCode()
+	switch {
+	case code >= 200 && code < 300:
+		return false, nil
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return false, cringletest.ErrBadAuth
+	case code >= 500:
+		return true, fmt.Errorf("server error %s", resp.Status())
+	}
+	return false, fmt.Errorf("rejected with %s", resp.Status())
+}
+
+// post POSTs body to u, retrying with exponential backoff for as long as the attempts and ctx
+// allow. The delivery id is the idempotency key of ctx if it has one, so that a notification
+// sent again later keeps the same id
+func (n *notifier) post(ctx context.Context, u *url.URL, body []byte) error {
+	delivery := cringletest.IdempotencyKey(ctx)
+	if len(delivery) == 0 {
+		var err error
+		if delivery, err = deliveryID(); err != nil {
+			return errors.Wrap(err, "could not create delivery id")
+		}
+	}
+
+	backoff := n.backoff
+	for i := 1; ; i++ {
+		retry, err := n.attempt(ctx, u, delivery, body)
+		if !retry {
+			return err
+		}
+		if i == n.attempts {
+			return errors.Wrapf(err, "gave up after %d attempts", i)
+		}
+
+		// there is no point waiting to retry if the deadline will pass first
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			return errors.Wrapf(err, "gave up after %d attempts", i)
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return errors.Wrapf(err, "gave up after %d attempts", i)
+		}
+		backoff *= 2
+	}
+}
+
+// deliveryError collects the failures of every url which could not be delivered to
+type deliveryError struct {
+	failures []string
+	cause    error
+}
+
+func (e *deliveryError) Error() string {
+	return fmt.Sprintf("%d webhooks failed: %s", len(e.failures), strings.Join(e.failures, "; "))
+}
+
+// Cause returns the error of the first url to fail
+func (e *deliveryError) Cause() error {
+	return e.cause
+}
+
+// notify POSTs doc to every url at once
+func (n *notifier) notify(ctx context.Context, doc *report.Document) error {
+	if len(doc.Rows) == 0 {
+		return cringletest.ErrNoRates
+	}
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return errors.Wrap(err, "could not encode webhook body")
+	}
+
+	errs := make([]error, len(n.urls))
+	wg := sync.WaitGroup{}
+	for i, u := range n.urls {
+		wg.Add(1)
+		go func(i int, u *url.URL) {
+			defer wg.Done()
+			errs[i] = n.post(ctx, u, body)
+		}(i, u)
+	}
+	wg.Wait()
+
+	failed := &deliveryError{}
+	var first *url.URL
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if failed.cause == nil {
+			failed.cause, first = err, n.urls[i]
+		}
+		failed.failures = append(failed.failures, fmt.Sprintf("%s: %v", n.urls[i].Redacted(), err))
+	}
+
+	switch len(failed.failures) {
+	case 0:
+		return nil
+	case 1:
+		return errors.Wrapf(failed.cause, "webhook %s", first.Redacted())
+	}
+	return failed
+}
+
+func (n *notifier) NotifyRates(ctx context.Context, rates []*cringletest.ExchangeRate) error {
+	return n.notify(ctx, report.FromRates(rates))
+}
+
+func (n *notifier) NotifyValue(ctx context.Context, conversions []*cringletest.Conversion) error {
+	return n.notify(ctx, report.FromValue(conversions))
+}
+
+func (n *notifier) NotifyBest(ctx context.Context, best *cringletest.BestRate) error {
+	return n.notify(ctx, report.FromBest(best))
+}
+
+func (n *notifier) NotifyHistory(ctx context.Context, history *cringletest.RateHistory) error {
+	return n.notify(ctx, report.FromHistory(history))
+}
+
+func (n *notifier) NotifyAlert(ctx context.Context, alert *cringletest.Alert) error {
+	return n.notify(ctx, report.FromAlert(alert))
+}
+
+func (n *notifier) NotifyNeed(ctx context.Context, requirements []*cringletest.Requirement) error {
+	return n.notify(ctx, report.FromNeed(requirements))
+}
+
+func (n *notifier) NotifyMatrix(ctx context.Context, matrix *cringletest.RateMatrix) error {
+	return n.notify(ctx, report.FromMatrix(matrix))
+}
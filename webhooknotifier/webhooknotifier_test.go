@@ -0,0 +1,294 @@
+package webhooknotifier
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/gobuffalo/envy"
+	"github.com/pkg/errors"
+	"github.com/robotlovesyou/cringletest"
+	"github.com/robotlovesyou/cringletest/report"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "shh"
+
+var testRates = []*cringletest.ExchangeRate{
+	&cringletest.ExchangeRate{From: "ABC", To: "DEF", Date: time.Date(2018, 5, 25, 0, 0, 0, 0, time.UTC), Value: decimal.New(1234, 3)},
+}
+
+// received is a request received by a test server
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// testServer records every request it receives and answers each with the next of statuses,
+// repeating the last one once they run out
+type testServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*received
+	statuses []int
+	delay    time.Duration
+}
+
+func newTestServer(statuses ...int) *testServer {
+	s := &testServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+
+		s.mu.Lock()
+		s.requests = append(s.requests, &received{header: req.Header, body: body})
+		status := s.statuses[len(s.statuses)-1]
+		if len(s.requests) <= len(s.statuses) {
+			status = s.statuses[len(s.requests)-1]
+		}
+		delay := s.delay
+		s.delay = 0
+		s.mu.Unlock()
+
+		time.Sleep(delay)
+		w.WriteHeader(status)
+	}))
+	return s
+}
+
+func (s *testServer) received() []*received {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*received{}, s.requests...)
+}
+
+func getTestNotifier(r *require.Assertions, urls ...string) cringletest.Notifier {
+	sender, err := NewWithSecret(urls, testSecret)
+	r.NoError(err)
+	sender.(*notifier).backoff = time.Millisecond
+	return sender
+}
+
+func TestNotifyRatesPostsSignedDocument(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusOK)
+	defer server.Close()
+
+	sender := getTestNotifier(r, server.URL)
+	r.NoError(sender.NotifyRates(context.Background(), testRates))
+
+	requests := server.received()
+	r.Len(requests, 1)
+
+	header := requests[0].header
+	r.Equal("application/json", header.Get("Content-Type"))
+	r.NotEmpty(header.Get(DeliveryHeader))
+	r.NoError(Verify(testSecret, header.Get(TimestampHeader), header.Get(SignatureHeader), requests[0].body, time.Minute, time.Now()))
+
+	doc := &report.Document{}
+	r.NoError(json.Unmarshal(requests[0].body, doc))
+	r.Equal(report.Schema, doc.Schema)
+	r.Equal(report.KindRates, doc.Kind)
+	r.Len(doc.Rows, 1)
+	r.Equal("1.234", doc.Rows[0].Rate)
+}
+
+func TestNotifyBestPostsBest(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusNoContent)
+	defer server.Close()
+
+	sender := getTestNotifier(r, server.URL)
+	r.NoError(sender.NotifyBest(context.Background(), &cringletest.BestRate{Rate: testRates[0], Days: 7}))
+
+	doc := &report.Document{}
+	r.NoError(json.Unmarshal(server.received()[0].body, doc))
+	r.Equal(report.KindBest, doc.Kind)
+	r.Equal(7, doc.Best.Days)
+}
+
+func TestRetriesServerErrorsWithTheSameDeliveryID(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK)
+	defer server.Close()
+
+	sender := getTestNotifier(r, server.URL)
+	conversions, err := cringletest.Convert(cringletest.NewMoney(decimal.New(2, 0), "ABC"), testRates, cringletest.DefaultRounding)
+	r.NoError(err)
+	r.NoError(sender.NotifyValue(context.Background(), conversions))
+
+	requests := server.received()
+	r.Len(requests, 3)
+	for _, req := range requests {
+		r.Equal(requests[0].header.Get(DeliveryHeader), req.header.Get(DeliveryHeader))
+		r.NoError(Verify(testSecret, req.header.Get(TimestampHeader), req.header.Get(SignatureHeader), req.body, time.Minute, time.Now()))
+	}
+}
+
+func TestIdempotencyKeyIsTheDeliveryID(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusOK)
+	defer server.Close()
+
+	sender := getTestNotifier(r, server.URL)
+	ctx := cringletest.WithIdempotencyKey(context.Background(), "0123abcd")
+	r.NoError(sender.NotifyRates(ctx, testRates))
+	r.Equal("0123abcd", server.received()[0].header.Get(DeliveryHeader))
+}
+
+func TestGivesUpAfterTheLastAttempt(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusInternalServerError)
+	defer server.Close()
+
+	sender := getTestNotifier(r, server.URL)
+	err := sender.NotifyRates(context.Background(), testRates)
+	r.Error(err)
+	r.Contains(err.Error(), "gave up after 4 attempts")
+	r.Len(server.received(), cringletest.WebhookAttempts)
+}
+
+func TestDoesNotRetryClientErrors(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusBadRequest)
+	defer server.Close()
+
+	sender := getTestNotifier(r, server.URL)
+	err := sender.NotifyRates(context.Background(), testRates)
+	r.Error(err)
+	r.Contains(err.Error(), "rejected with 400")
+	r.Len(server.received(), 1)
+}
+
+func TestUnauthorizedIsBadAuth(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusUnauthorized)
+	defer server.Close()
+
+	sender := getTestNotifier(r, server.URL)
+	err := sender.NotifyRates(context.Background(), testRates)
+	r.Equal(cringletest.ErrBadAuth, errors.Cause(err))
+	r.Len(server.received(), 1)
+}
+
+func TestRetriesAttemptsWhichTimeOut(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusOK)
+	server.delay = 500 * time.Millisecond
+	defer server.Close()
+
+	sender := getTestNotifier(r, server.URL)
+	sender.(*notifier).attemptTimeout = 50 * time.Millisecond
+
+	r.NoError(sender.NotifyRates(context.Background(), testRates))
+	r.Len(server.received(), 2)
+}
+
+func TestStopsRetryingAtTheDeadline(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusServiceUnavailable)
+	defer server.Close()
+
+	sender := getTestNotifier(r, server.URL)
+	sender.(*notifier).backoff = time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := sender.NotifyRates(ctx, testRates)
+	r.Error(err)
+	r.Contains(err.Error(), "gave up after 1 attempts")
+	r.True(time.Since(start) < time.Second)
+	r.Len(server.received(), 1)
+}
+
+func TestPostsToEveryURL(t *testing.T) {
+	r := require.New(t)
+
+	good := newTestServer(http.StatusOK)
+	defer good.Close()
+	bad := newTestServer(http.StatusNotFound)
+	defer bad.Close()
+
+	sender := getTestNotifier(r, good.URL, bad.URL)
+	err := sender.NotifyRates(context.Background(), testRates)
+	r.Error(err)
+	r.Contains(err.Error(), "webhook "+bad.URL+": rejected with 404")
+	r.NotContains(err.Error(), good.URL)
+
+	r.Len(good.received(), 1)
+	r.Len(bad.received(), 1)
+}
+
+func TestNotifyRatesFailsWithNoRates(t *testing.T) {
+	r := require.New(t)
+
+	sender := getTestNotifier(r, "http://example.com/hook")
+	r.Equal(cringletest.ErrNoRates, sender.NotifyRates(context.Background(), nil))
+}
+
+func TestNewWithSecretValidates(t *testing.T) {
+	r := require.New(t)
+
+	_, err := NewWithSecret(nil, testSecret)
+	r.Equal(cringletest.ErrNoRecipient, err)
+
+	_, err = NewWithSecret([]string{"http://example.com/hook"}, "")
+	r.EqualError(err, "no webhook secret configured")
+
+	_, err = NewWithSecret([]string{"ftp://example.com/hook"}, testSecret)
+	r.EqualError(err, "webhook url ftp://example.com/hook must be an absolute http or https url")
+
+	_, err = NewWithSecret([]string{"/hook"}, testSecret)
+	r.Error(err)
+}
+
+func TestVerifyRejectsBadRequests(t *testing.T) {
+	r := require.New(t)
+
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	body := []byte(`{"kind":"rates"}`)
+	signature := Sign(testSecret, timestamp, body)
+
+	r.NoError(Verify(testSecret, timestamp, signature, body, time.Minute, now))
+	r.Equal(ErrBadSignature, Verify(testSecret, timestamp, signature, []byte(`{"kind":"value"}`), time.Minute, now))
+	r.Equal(ErrBadSignature, Verify("other", timestamp, signature, body, time.Minute, now))
+	r.Equal(ErrBadSignature, Verify(testSecret, timestamp, "md5=abc", body, time.Minute, now))
+	r.Equal(ErrStaleTimestamp, Verify(testSecret, timestamp, signature, body, time.Minute, now.Add(2*time.Minute)))
+	r.Error(Verify(testSecret, "yesterday", signature, body, time.Minute, now))
+}
+
+func TestRegisteredSchemePostsToTheURL(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(http.StatusOK)
+	defer server.Close()
+
+	var sender cringletest.Notifier
+	var err error
+	envy.Temp(func() {
+		envy.Set(cringletest.WebhookSecretEnvVar, testSecret)
+		sender, err = cringletest.NewNotifier("webhook+" + server.URL + "/hook?key=1#ops")
+	})
+	r.NoError(err)
+	r.Equal(server.URL+"/hook?key=1", sender.(*notifier).urls[0].String())
+	r.NoError(sender.NotifyRates(context.Background(), testRates))
+	r.Len(server.received(), 1)
+}